@@ -0,0 +1,80 @@
+package activitywatch
+
+import (
+	"sort"
+	"time"
+)
+
+// События aw-watcher-afk хранятся в lake как приложение AFKApp, статус - в title
+const (
+	AFKApp       = "afk"
+	StatusAFK    = "afk"
+	StatusNotAFK = "not-afk"
+)
+
+// Span отрезок времени в приложении. URL и Domain заполнены у событий aw-watcher-web
+type Span struct {
+	App    string
+	Title  string
+	URL    string
+	Domain string
+	Start  time.Time
+	End    time.Time
+}
+
+// Duration длительность отрезка
+func (s Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// ActiveSpans оставляет от событий окон только время внутри периодов not-afk,
+// как filter_period_intersect в query2 aw-server. Оба списка должны относиться к одному устройству.
+// Событие, пересекающее несколько периодов, разбивается на части
+func ActiveSpans(window []Span, notAFK []Span) []Span {
+	periods := mergeSpans(notAFK)
+	if len(periods) == 0 {
+		return nil
+	}
+
+	var active []Span
+	for _, span := range window {
+		// Первый период, который заканчивается после начала события
+		i := sort.Search(len(periods), func(i int) bool { return periods[i].End.After(span.Start) })
+		for ; i < len(periods) && periods[i].Start.Before(span.End); i++ {
+			part := span
+			if periods[i].Start.After(part.Start) {
+				part.Start = periods[i].Start
+			}
+			if periods[i].End.Before(part.End) {
+				part.End = periods[i].End
+			}
+			if part.End.After(part.Start) {
+				active = append(active, part)
+			}
+		}
+	}
+	return active
+}
+
+// mergeSpans объединяет пересекающиеся отрезки в отсортированные непрерывные периоды
+func mergeSpans(spans []Span) []Span {
+	merged := make([]Span, 0, len(spans))
+	for _, span := range spans {
+		if span.End.After(span.Start) {
+			merged = append(merged, Span{Start: span.Start, End: span.End})
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Start.Before(merged[j].Start) })
+
+	result := merged[:0]
+	for _, span := range merged {
+		if n := len(result); n > 0 && !span.Start.After(result[n-1].End) {
+			if span.End.After(result[n-1].End) {
+				result[n-1].End = span.End
+			}
+			continue
+		}
+		result = append(result, span)
+	}
+	return result
+}
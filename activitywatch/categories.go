@@ -0,0 +1,181 @@
+package activitywatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Поля события, по которым проверяется правило
+const (
+	RuleFieldApp   = "app"
+	RuleFieldTitle = "title"
+	RuleFieldURL   = "url"
+	RuleFieldAny   = "any"
+)
+
+// CategorySeparator разделитель уровней в имени категории: "Work > Programming > Go"
+const CategorySeparator = " > "
+
+// Uncategorized категория событий, не подошедших ни под одно правило
+var Uncategorized = []string{"Uncategorized"}
+
+var colorRe = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+
+// CategoryRule правило категоризации: регулярное выражение (синтаксис Go RE2) по полю события
+// и путь категории от корня, например {"Work", "Programming", "Go"}
+type CategoryRule struct {
+	Category   []string
+	Field      string
+	Pattern    string
+	IgnoreCase bool
+	Color      string
+}
+
+// Validate проверяет путь категории, поле и выражение правила
+func (r CategoryRule) Validate() error {
+	if len(r.Category) == 0 {
+		return fmt.Errorf("category is required")
+	}
+	for _, name := range r.Category {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("category names must not be empty")
+		}
+	}
+	switch r.Field {
+	case RuleFieldApp, RuleFieldTitle, RuleFieldURL, RuleFieldAny:
+	default:
+		return fmt.Errorf("field must be app, title, url or any")
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	if _, err := r.compile(); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	return nil
+}
+
+func (r CategoryRule) compile() (*regexp.Regexp, error) {
+	if r.IgnoreCase {
+		return regexp.Compile("(?i)" + r.Pattern)
+	}
+	return regexp.Compile(r.Pattern)
+}
+
+// Categorizer относит события к категориям по правилам пользователя
+type Categorizer struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	category []string
+	field    string
+	re       *regexp.Regexp
+}
+
+// NewCategorizer компилирует правила. Некорректные правила пропускаются:
+// они проверяются при сохранении, а сломанное правило не должно ломать статистику
+func NewCategorizer(rules []CategoryRule) *Categorizer {
+	c := &Categorizer{rules: make([]compiledRule, 0, len(rules))}
+	for _, rule := range rules {
+		re, err := rule.compile()
+		if err != nil || len(rule.Category) == 0 {
+			continue
+		}
+		c.rules = append(c.rules, compiledRule{category: rule.Category, field: rule.Field, re: re})
+	}
+	return c
+}
+
+// Categorize возвращает категорию самого глубокого подошедшего правила, как aw-webui.
+// При одинаковой глубине побеждает правило, заданное раньше
+func (c *Categorizer) Categorize(app, title, url string) []string {
+	var best []string
+	for _, rule := range c.rules {
+		if len(rule.category) <= len(best) || !rule.match(app, title, url) {
+			continue
+		}
+		best = rule.category
+	}
+	if best == nil {
+		return Uncategorized
+	}
+	return best
+}
+
+func (r compiledRule) match(app, title, url string) bool {
+	switch r.field {
+	case RuleFieldApp:
+		return r.re.MatchString(app)
+	case RuleFieldTitle:
+		return r.re.MatchString(title)
+	case RuleFieldURL:
+		return url != "" && r.re.MatchString(url)
+	default:
+		return r.re.MatchString(app) || r.re.MatchString(title) || (url != "" && r.re.MatchString(url))
+	}
+}
+
+// awCategory категория в экспорте aw-webui (Settings > Categorization > Export)
+type awCategory struct {
+	Name []string `json:"name"`
+	Rule struct {
+		Type       string `json:"type"`
+		Regex      string `json:"regex"`
+		IgnoreCase bool   `json:"ignore_case"`
+	} `json:"rule"`
+	Data struct {
+		Color string `json:"color"`
+	} `json:"data"`
+}
+
+// ParseAWCategories разбирает экспорт категорий aw-webui: {"categories": [...]},
+// {"classes": [...]} из настроек aw-server или просто массив.
+// Категории без регулярного выражения (type "none") служат только родителями и не дают правил,
+// невалидные для RE2 выражения возвращаются в skipped с причиной
+func ParseAWCategories(data []byte) (rules []CategoryRule, skipped []string, err error) {
+	var categories []awCategory
+	if err := json.Unmarshal(data, &categories); err != nil {
+		var wrapped struct {
+			Categories []awCategory `json:"categories"`
+			Classes    []awCategory `json:"classes"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, nil, fmt.Errorf("invalid ActivityWatch categories export: %w", err)
+		}
+		categories = append(wrapped.Categories, wrapped.Classes...)
+	}
+
+	for _, category := range categories {
+		if category.Rule.Type != "regex" || category.Rule.Regex == "" {
+			continue
+		}
+		rule := CategoryRule{
+			Category:   category.Name,
+			Field:      RuleFieldAny,
+			Pattern:    category.Rule.Regex,
+			IgnoreCase: category.Rule.IgnoreCase,
+			Color:      NormalizeColor(category.Data.Color),
+		}
+		if err := rule.Validate(); err != nil {
+			skipped = append(skipped, strings.Join(category.Name, CategorySeparator)+": "+err.Error())
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, skipped, nil
+}
+
+// NormalizeColor приводит цвет (#rgb или #rrggbb) к #rrggbb, для нераспознанных возвращает пустую строку
+func NormalizeColor(color string) string {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if len(color) == 4 && colorRe.MatchString(color) {
+		return "#" + strings.Repeat(color[1:2], 2) + strings.Repeat(color[2:3], 2) + strings.Repeat(color[3:4], 2)
+	}
+	if colorRe.MatchString(color) {
+		return color
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+// Package activitywatch читает бакеты и события из REST API aw-server.
+package activitywatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultHost адрес aw-server по умолчанию
+const DefaultHost = "http://localhost:5600"
+
+// Client клиент REST API aw-server (/api/0)
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewClient создаёт клиента aw-server, host вида http://localhost:5600
+func NewClient(host string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(host, "/") + "/api/0",
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Buckets возвращает все бакеты aw-server
+func (c *Client) Buckets(ctx context.Context) ([]Bucket, error) {
+	var buckets map[string]Bucket
+	if err := c.get(ctx, "/buckets/", nil, &buckets); err != nil {
+		return nil, err
+	}
+
+	result := make([]Bucket, 0, len(buckets))
+	for id, bucket := range buckets {
+		if bucket.ID == "" {
+			bucket.ID = id
+		}
+		result = append(result, bucket)
+	}
+	return result, nil
+}
+
+// Events возвращает события бакета, пересекающие [start, end), от старых к новым
+func (c *Client) Events(ctx context.Context, bucketID string, start, end time.Time) ([]Event, error) {
+	query := url.Values{}
+	query.Set("start", start.UTC().Format(time.RFC3339Nano))
+	query.Set("end", end.UTC().Format(time.RFC3339Nano))
+	query.Set("limit", "-1")
+
+	var events []Event
+	if err := c.get(ctx, "/buckets/"+url.PathEscape(bucketID)+"/events", query, &events); err != nil {
+		return nil, err
+	}
+
+	// aw-server отдаёт события от новых к старым
+	sort.Slice(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+	return events, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	reqURL := c.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("aw-server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package activitywatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// exportChunkSize событий бакета, передаваемых за один вызов обработчика ReadExport
+const exportChunkSize = 1000
+
+// Export выгружает все бакеты с событиями (GET /api/0/export). Выгрузка за годы занимает
+// сотни мегабайт, поэтому тело возвращается потоком для ReadExport и читается без таймаута
+func (c *Client) Export(ctx context.Context) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/export", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("aw-server returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return resp.Body, nil
+}
+
+// ReadExport читает экспорт aw-server {"buckets": {"<id>": {..., "events": [...]}}} - полный
+// (GET /api/0/export) или одного бакета из aw-webui - и вызывает fn для событий каждого бакета
+// частями по exportChunkSize. Для каждого бакета fn вызывается хотя бы раз, в том числе без событий.
+// Если события в объекте бакета идут раньше его типа, они накапливаются до конца объекта
+func ReadExport(r io.Reader, fn func(bucket Bucket, events []Event) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	found := false
+	for dec.More() {
+		key, err := readKey(dec)
+		if err != nil {
+			return err
+		}
+		if key != "buckets" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		found = true
+		if err := readExportBuckets(dec, fn); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return exportSyntaxError(fmt.Errorf("buckets not found"))
+	}
+	return expectDelim(dec, '}')
+}
+
+// readExportBuckets читает buckets: объект по id бакета или массив бакетов
+func readExportBuckets(dec *json.Decoder, fn func(bucket Bucket, events []Event) error) error {
+	token, err := dec.Token()
+	if err != nil {
+		return exportSyntaxError(err)
+	}
+	switch token {
+	case json.Delim('{'):
+		for dec.More() {
+			id, err := readKey(dec)
+			if err != nil {
+				return err
+			}
+			if err := readExportBucket(dec, id, fn); err != nil {
+				return err
+			}
+		}
+		return expectDelim(dec, '}')
+	case json.Delim('['):
+		for dec.More() {
+			if err := readExportBucket(dec, "", fn); err != nil {
+				return err
+			}
+		}
+		return expectDelim(dec, ']')
+	}
+	return exportSyntaxError(fmt.Errorf("buckets must be an object or an array"))
+}
+
+func readExportBucket(dec *json.Decoder, id string, fn func(bucket Bucket, events []Event) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	bucket := Bucket{ID: id}
+	var pending []Event
+	sent := false
+	for dec.More() {
+		key, err := readKey(dec)
+		if err != nil {
+			return err
+		}
+
+		var target interface{}
+		switch key {
+		case "id":
+			target = &bucket.ID
+		case "type":
+			target = &bucket.Type
+		case "client":
+			target = &bucket.Client
+		case "hostname":
+			target = &bucket.Hostname
+		case "events":
+			if err := expectDelim(dec, '['); err != nil {
+				return err
+			}
+			for dec.More() {
+				var event Event
+				if err := dec.Decode(&event); err != nil {
+					return exportSyntaxError(fmt.Errorf("bucket %s: %w", bucket.ID, err))
+				}
+				pending = append(pending, event)
+				// Без типа бакета события не разобрать, поэтому до него они только копятся
+				if bucket.Type != "" && len(pending) >= exportChunkSize {
+					if err := fn(bucket, pending); err != nil {
+						return err
+					}
+					pending, sent = pending[:0], true
+				}
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return err
+			}
+			continue
+		default:
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := dec.Decode(target); err != nil {
+			return exportSyntaxError(fmt.Errorf("bucket %s: invalid %s: %w", bucket.ID, key, err))
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+	if bucket.ID == "" {
+		return exportSyntaxError(fmt.Errorf("bucket without id"))
+	}
+
+	for start := 0; start < len(pending) || !sent; start += exportChunkSize {
+		end := min(start+exportChunkSize, len(pending))
+		if err := fn(bucket, pending[start:end]); err != nil {
+			return err
+		}
+		sent = true
+	}
+	return nil
+}
+
+// ExportSyntaxError экспорт не удалось разобрать
+type ExportSyntaxError struct {
+	err error
+}
+
+func (e *ExportSyntaxError) Error() string {
+	return "invalid ActivityWatch export: " + e.err.Error()
+}
+
+func (e *ExportSyntaxError) Unwrap() error { return e.err }
+
+func exportSyntaxError(err error) error {
+	var syntaxErr *ExportSyntaxError
+	if errors.As(err, &syntaxErr) {
+		return err
+	}
+	return &ExportSyntaxError{err: err}
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return exportSyntaxError(err)
+	}
+	if token != delim {
+		return exportSyntaxError(fmt.Errorf("expected %s, got %v", delim, token))
+	}
+	return nil
+}
+
+func readKey(dec *json.Decoder) (string, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return "", exportSyntaxError(err)
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", exportSyntaxError(fmt.Errorf("expected object key, got %v", token))
+	}
+	return key, nil
+}
+
+func skipValue(dec *json.Decoder) error {
+	var skip json.RawMessage
+	if err := dec.Decode(&skip); err != nil {
+		return exportSyntaxError(err)
+	}
+	return nil
+}
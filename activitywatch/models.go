@@ -0,0 +1,132 @@
+package activitywatch
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Типы бакетов aw-server
+const (
+	BucketTypeWindow = "currentwindow"
+	BucketTypeAFK    = "afkstatus"
+	BucketTypeWeb    = "web.tab.current"
+	BucketTypeEditor = "app.editor.activity"
+)
+
+// Bucket бакет aw-server: события одного watcher на одном хосте
+type Bucket struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Client      string    `json:"client"`
+	Hostname    string    `json:"hostname"`
+	Created     time.Time `json:"created"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// Event событие aw-server. Data зависит от типа бакета
+type Event struct {
+	ID        int64           `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Duration  float64         `json:"duration"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// End возвращает время окончания события
+func (e Event) End() time.Time {
+	return e.Timestamp.Add(time.Duration(e.Duration * float64(time.Second)))
+}
+
+// IngestEvent событие в формате POST /api/v1/activitywatch/events
+type IngestEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Duration  float64   `json:"duration"`
+	App       string    `json:"app"`
+	Title     string    `json:"title"`
+	BucketID  string    `json:"bucket_id"`
+	EventID   int64     `json:"event_id,omitempty"`
+	Hostname  string    `json:"hostname,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Audible   bool      `json:"audible,omitempty"`
+	Incognito bool      `json:"incognito,omitempty"`
+}
+
+// Supported сообщает, умеет ли lake принимать события бакета этого типа
+func (b Bucket) Supported() bool {
+	switch b.Type {
+	case BucketTypeWindow, BucketTypeAFK, BucketTypeWeb, BucketTypeEditor:
+		return true
+	}
+	return false
+}
+
+// ToIngest приводит событие к формату lake:
+//   - currentwindow: app и title окна
+//   - afkstatus: app "afk", title - статус afk или not-afk
+//   - web.tab.current: app - браузер из id бакета (aw-watcher-web-chrome), title, url, audible и incognito вкладки
+//   - app.editor.activity: app - редактор из id бакета (aw-watcher-vscode), title - файл или проект
+//
+// Возвращает false для событий неподдерживаемых бакетов и событий без данных
+func ToIngest(bucket Bucket, event Event) (IngestEvent, bool) {
+	var data struct {
+		App       string `json:"app"`
+		Title     string `json:"title"`
+		Status    string `json:"status"`
+		URL       string `json:"url"`
+		Audible   bool   `json:"audible"`
+		Incognito bool   `json:"incognito"`
+		File      string `json:"file"`
+		Project   string `json:"project"`
+	}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return IngestEvent{}, false
+	}
+
+	ingest := IngestEvent{
+		Timestamp: event.Timestamp,
+		Duration:  event.Duration,
+		Title:     data.Title,
+		BucketID:  bucket.ID,
+		EventID:   event.ID,
+		Hostname:  bucket.Hostname,
+	}
+	// Старые aw-watcher-web не знают hostname и пишут "unknown"
+	if ingest.Hostname == "unknown" {
+		ingest.Hostname = ""
+	}
+
+	switch bucket.Type {
+	case BucketTypeWindow:
+		ingest.App = data.App
+	case BucketTypeAFK:
+		ingest.App = AFKApp
+		ingest.Title = data.Status
+	case BucketTypeWeb:
+		ingest.App = BrowserFromBucketID(bucket.ID)
+		ingest.URL = data.URL
+		ingest.Audible = data.Audible
+		ingest.Incognito = data.Incognito
+	case BucketTypeEditor:
+		ingest.App = EditorFromBucketID(bucket.ID)
+		if ingest.App == "" {
+			ingest.App = strings.TrimPrefix(bucket.Client, "aw-watcher-")
+		}
+		ingest.Title = data.File
+		if ingest.Title == "" {
+			ingest.Title = data.Project
+		}
+	default:
+		return IngestEvent{}, false
+	}
+
+	return ingest, ingest.App != ""
+}
+
+// HostnameFromBucketID достаёт hostname из id бакета вида aw-watcher-window_<hostname>.
+// Возвращает пустую строку для бакетов без hostname (старые aw-watcher-web-chrome)
+func HostnameFromBucketID(bucketID string) string {
+	if i := strings.Index(bucketID, "_"); i > 0 {
+		return bucketID[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package activitywatch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Действия правил редактирования
+const (
+	RedactDrop    = "drop"    // поле не сохраняется
+	RedactHash    = "hash"    // поле заменяется хешем: одинаковые заголовки остаются одинаковыми
+	RedactReplace = "replace" // совпадения pattern заменяются на replacement
+)
+
+// redactedHashPrefix префикс захешированных значений, повторно они не хешируются
+const redactedHashPrefix = "hmac-sha256:"
+
+// redactedHashSize байт HMAC в захешированном значении
+const redactedHashSize = 16
+
+// RedactionRule правило редактирования заголовка или адреса события.
+// App и Pattern - регулярные выражения (синтаксис Go RE2, без учёта регистра) по приложению
+// и по полю Field; пустое выражение подходит любому значению
+type RedactionRule struct {
+	App         string
+	Field       string
+	Pattern     string
+	Action      string
+	Replacement string
+}
+
+// Validate проверяет поле, действие и выражения правила
+func (r RedactionRule) Validate() error {
+	switch r.Field {
+	case RuleFieldTitle, RuleFieldURL:
+	default:
+		return fmt.Errorf("field must be title or url")
+	}
+	switch r.Action {
+	case RedactDrop, RedactHash:
+	case RedactReplace:
+		if r.Pattern == "" {
+			return fmt.Errorf("pattern is required for replace")
+		}
+	default:
+		return fmt.Errorf("action must be drop, hash or replace")
+	}
+	if r.App == "" && r.Pattern == "" {
+		return fmt.Errorf("app or pattern is required")
+	}
+	if _, err := compileRedaction(r.App); err != nil {
+		return fmt.Errorf("invalid app pattern: %w", err)
+	}
+	if _, err := compileRedaction(r.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	return nil
+}
+
+func compileRedaction(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// Redactor применяет к событиям правила редактирования и список приватных приложений пользователя
+type Redactor struct {
+	rules   []compiledRedaction
+	private map[string]bool
+	key     []byte
+}
+
+type compiledRedaction struct {
+	app         *regexp.Regexp
+	field       string
+	pattern     *regexp.Regexp
+	action      string
+	replacement string
+}
+
+// NewRedactor компилирует правила, некорректные пропускаются. key - секретный ключ HMAC для действия hash:
+// без него по хешу нельзя перебором восстановить заголовок. Ключ нужен только правилам hash
+func NewRedactor(rules []RedactionRule, privateApps []string, key []byte) *Redactor {
+	r := &Redactor{private: map[string]bool{}, key: key}
+	for _, rule := range rules {
+		if rule.Validate() != nil {
+			continue
+		}
+		app, _ := compileRedaction(rule.App)
+		pattern, _ := compileRedaction(rule.Pattern)
+		r.rules = append(r.rules, compiledRedaction{
+			app:         app,
+			field:       rule.Field,
+			pattern:     pattern,
+			action:      rule.Action,
+			replacement: rule.Replacement,
+		})
+	}
+	for _, app := range privateApps {
+		r.private[strings.ToLower(app)] = true
+	}
+	return r
+}
+
+// Empty сообщает, что редактировать нечего
+func (r *Redactor) Empty() bool {
+	return len(r.rules) == 0 && len(r.private) == 0
+}
+
+// HasHashRules сообщает, есть ли среди правил действие hash
+func HasHashRules(rules []RedactionRule) bool {
+	for _, rule := range rules {
+		if rule.Action == RedactHash {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact возвращает заголовок, адрес и домен события после редактирования.
+// У приватных приложений не остаётся ничего, кроме приложения и длительности.
+// Правила применяются по порядку, каждое к результату предыдущих; домен сохраняется,
+// даже если адрес удалён. События aw-watcher-afk не редактируются: в title их статус
+func (r *Redactor) Redact(app, title, url, domain string) (string, string, string) {
+	if app == AFKApp {
+		return title, url, domain
+	}
+	if r.private[strings.ToLower(app)] {
+		return "", "", ""
+	}
+	for _, rule := range r.rules {
+		if rule.app != nil && !rule.app.MatchString(app) {
+			continue
+		}
+		if rule.field == RuleFieldURL {
+			url = rule.apply(url, r.key)
+		} else {
+			title = rule.apply(title, r.key)
+		}
+	}
+	return title, url, domain
+}
+
+func (r compiledRedaction) apply(value string, key []byte) string {
+	if value == "" || (r.pattern != nil && !r.pattern.MatchString(value)) {
+		return value
+	}
+	switch r.action {
+	case RedactDrop:
+		return ""
+	case RedactHash:
+		if strings.HasPrefix(value, redactedHashPrefix) {
+			return value
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		return redactedHashPrefix + hex.EncodeToString(mac.Sum(nil)[:redactedHashSize])
+	default:
+		return r.pattern.ReplaceAllString(value, r.replacement)
+	}
+}
@@ -0,0 +1,122 @@
+package activitywatch
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// webBucketPrefix префикс id бакетов aw-watcher-web: aw-watcher-web-chrome_<hostname>
+const webBucketPrefix = "aw-watcher-web"
+
+// browserAppNames имена окон браузера у aw-watcher-window на разных ОС по имени браузера
+// из id бакета веб-watcher, как browser_appnames в query2 aw-server. Сравнение без учёта регистра и .exe
+var browserAppNames = map[string][]string{
+	"chrome": {
+		"google chrome", "google-chrome", "google-chrome-stable", "google-chrome-beta", "google-chrome-unstable",
+		"chrome", "chromium", "chromium-browser", "chromium-browser-chromium",
+	},
+	"firefox": {
+		"firefox", "firefox developer edition", "firefoxdeveloperedition", "firefox-esr", "firefox beta",
+		"nightly", "org.mozilla.firefox",
+	},
+	"opera":   {"opera"},
+	"brave":   {"brave", "brave browser", "brave-browser"},
+	"edge":    {"msedge", "microsoft edge", "microsoft-edge"},
+	"vivaldi": {"vivaldi", "vivaldi-stable", "vivaldi-snapshot"},
+	"arc":     {"arc"},
+	"safari":  {"safari"},
+}
+
+// IsWebBucket сообщает, относится ли бакет к aw-watcher-web
+func IsWebBucket(bucketID string) bool {
+	return strings.HasPrefix(bucketID, webBucketPrefix)
+}
+
+// BrowserFromBucketID достаёт браузер из id бакета веб-watcher, для остальных бакетов возвращает "browser"
+func BrowserFromBucketID(bucketID string) string {
+	name := strings.TrimPrefix(bucketID, webBucketPrefix+"-")
+	if name == bucketID {
+		return "browser"
+	}
+	if i := strings.Index(name, "_"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// editorWatchers редакторы с watcher-ами ActivityWatch: бакеты aw-watcher-<editor>_<hostname>
+var editorWatchers = []string{"vscode", "vim", "nvim", "neovim", "jetbrains", "sublime", "atom", "emacs", "zed", "vs"}
+
+// IsEditorBucket сообщает, относится ли бакет к watcher-у редактора. Время в редакторе
+// уже учтено в его окне, поэтому такие события, как и события вкладок, не входят в статистику окон
+func IsEditorBucket(bucketID string) bool {
+	return EditorFromBucketID(bucketID) != ""
+}
+
+// EditorFromBucketID достаёт редактор из id бакета aw-watcher-vscode_<hostname>,
+// для остальных бакетов возвращает пустую строку
+func EditorFromBucketID(bucketID string) string {
+	name, _, _ := strings.Cut(strings.TrimPrefix(bucketID, "aw-watcher-"), "_")
+	for _, editor := range editorWatchers {
+		if name == editor && bucketID != name {
+			return name
+		}
+	}
+	return ""
+}
+
+// IsBrowserApp сообщает, является ли приложение окна браузером browser.
+// Неизвестный браузер совпадает с приложением того же имени
+func IsBrowserApp(app, browser string) bool {
+	app = strings.TrimSuffix(strings.ToLower(app), ".exe")
+	browser = strings.ToLower(browser)
+	names, ok := browserAppNames[browser]
+	if !ok {
+		return app == browser
+	}
+	for _, name := range names {
+		if app == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeDomain возвращает домен адреса вкладки: нижний регистр, без порта и www.
+// Для адресов без хоста (file:, about:blank) и не-http схем (chrome://extensions) возвращает пустую строку
+func NormalizeDomain(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return normalizeHost(u.Host)
+}
+
+// NormalizeDomainName приводит домен, переданный без адреса, к виду NormalizeDomain
+func NormalizeDomainName(domain string) string {
+	domain = strings.TrimSpace(domain)
+	if strings.Contains(domain, "://") {
+		return NormalizeDomain(domain)
+	}
+	return normalizeHost(domain)
+}
+
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return strings.TrimPrefix(host, "www.")
+}
+
+// MatchDomain сообщает, совпадает ли домен с одним из шаблонов: сам домен или его поддомен,
+// так "google.com" включает "docs.google.com"
+func MatchDomain(domain string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if domain == pattern || strings.HasSuffix(domain, "."+pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,16 @@
 package handlers_api_v1
 
 import (
+	"DataLake/activitywatch"
+	models_api_v1 "DataLake/api/v1/models"
 	internal_db "DataLake/internal/db"
 	activitywatch_db "DataLake/internal/db/activitywatch"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -24,121 +29,292 @@ func NewActivityWatchHandler(store *internal_db.Store, logger *zerolog.Logger) *
 	}
 }
 
-type ActivityEventRequest struct {
-	Timestamp time.Time `json:"timestamp"`
-	Duration  float64   `json:"duration"`
-	App       string    `json:"app"`
-	Title     string    `json:"title"`
-	BucketID  string    `json:"bucket_id"`
-}
-
-// HandleEvents обрабатывает POST /api/v1/activitywatch/events
-func (h *ActivityWatchHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// GetStats обрабатывает GET /api/v1/activitywatch/stats
+// @Summary Получить статистику ActivityWatch
+// @Description Возвращает активное время в приложениях за период: время, когда aw-watcher-afk считал пользователя отошедшим, не учитывается
+// @Tags ActivityWatch
+// @Accept json
+// @Produce json
+// @Param start query string false "Время начала (RFC3339)" default(24 hours ago)
+// @Param end query string false "Время окончания (RFC3339)" default(now)
+// @Param device query string false "Hostname устройств через запятую, по умолчанию все устройства вместе"
+// @Param by_device query bool false "Статистика отдельно по каждому устройству"
+// @Success 200 {array} models_api_v1.ActivityAppStat
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security ApiKeyAuth
+// @Router /activitywatch/stats [get]
+func (h *ActivityWatchHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var events []ActivityEventRequest
-	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
-		h.logger.Error().Err(err).Msg("Failed to decode events")
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
 		return
 	}
 
-	if len(events) == 0 {
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(map[string]string{"message": "No events to insert"})
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	hostnames := parseDevices(r)
 
-	params := make([]activitywatch_db.BulkInsertEventsParams, len(events))
-	for i, event := range events {
-		params[i] = activitywatch_db.BulkInsertEventsParams{
-			Timestamp: pgtype.Timestamptz{Time: event.Timestamp, Valid: true},
-			Duration:  event.Duration,
-			App:       event.App,
-			Title:     pgtype.Text{String: event.Title, Valid: event.Title != ""},
-			BucketID:  event.BucketID,
+	spans, err := activeSpans(r.Context(), h.store, userID, start, end, hostnames)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get stats")
+		http.Error(w, "Failed to get stats", http.StatusInternalServerError)
+		return
+	}
+
+	byDevice := r.URL.Query().Get("by_device") == "true"
+	type statKey struct{ hostname, app string }
+	index := map[statKey]int{}
+	stats := []models_api_v1.ActivityAppStat{}
+	for _, span := range spans {
+		key := statKey{app: span.App}
+		if byDevice {
+			key.hostname = span.Hostname
 		}
+		i, ok := index[key]
+		if !ok {
+			i = len(stats)
+			index[key] = i
+			stats = append(stats, models_api_v1.ActivityAppStat{Hostname: key.hostname, App: key.app})
+		}
+		stats[i].TotalDuration += span.Duration().Seconds()
+		stats[i].EventCount++
 	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Hostname != stats[j].Hostname {
+			return stats[i].Hostname < stats[j].Hostname
+		}
+		return stats[i].TotalDuration > stats[j].TotalDuration
+	})
 
-	ctx := context.Background()
-	count, err := h.store.ActivityWatch.BulkInsertEvents(ctx, params)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
+}
+
+// parseTimeRange разбирает период: start и end (RFC3339) или start_date и end_date
+// (YYYY-MM-DD, локальные дни включительно), не длиннее maxRangeDays. По умолчанию последние 24 часа
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	if r.URL.Query().Get("start_date") != "" || r.URL.Query().Get("end_date") != "" {
+		startDate, endDate, err := parseDateRange(r)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if endDate.Sub(startDate) > maxRangeDays*24*time.Hour {
+			return time.Time{}, time.Time{}, errRangeTooLong
+		}
+		start, end := localRange(startDate, endDate)
+		return start, end, nil
+	}
+
+	end := time.Now()
+	start := end.Add(-24 * time.Hour)
+
+	var err error
+	if val := r.URL.Query().Get("start"); val != "" {
+		if start, err = time.Parse(time.RFC3339, val); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start time")
+		}
+	}
+	if val := r.URL.Query().Get("end"); val != "" {
+		if end, err = time.Parse(time.RFC3339, val); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end time")
+		}
+	}
+	if end.Sub(start) > maxRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, errRangeTooLong
+	}
+	return start, end, nil
+}
+
+// parseDevices разбирает device - hostname устройств через запятую, которые складываются вместе.
+// Пустой список - все устройства
+func parseDevices(r *http.Request) []string {
+	hostnames := []string{}
+	for _, hostname := range strings.Split(r.URL.Query().Get("device"), ",") {
+		if hostname = strings.TrimSpace(hostname); hostname != "" {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	return hostnames
+}
+
+// deviceSpan отрезок активного времени на устройстве
+type deviceSpan struct {
+	Hostname string
+	activitywatch.Span
+}
+
+// activeSpans возвращает время в приложениях внутри [start, end) без AFK: события окон
+// каждого устройства обрезаются по периодам not-afk этого же устройства.
+// Устройства без событий aw-watcher-afk за период не фильтруются, как в query2 aw-server.
+// События aw-watcher-web и watcher-ов редакторов сюда не входят: их время уже учтено в окне браузера или редактора
+func activeSpans(ctx context.Context, store *internal_db.Store, userID pgtype.UUID, start, end time.Time, hostnames []string) ([]deviceSpan, error) {
+	devices, err := listDeviceEvents(ctx, store, userID, start, end, hostnames)
 	if err != nil {
-		h.logger.Error().Err(err).Int("count", len(events)).Msg("Failed to insert events")
-		http.Error(w, "Failed to save events", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
-	h.logger.Info().Int64("count", count).Msg("Inserted activity events")
+	period := []activitywatch.Span{{Start: start, End: end}}
+	var result []deviceSpan
+	for _, d := range devices {
+		for _, span := range activitywatch.ActiveSpans(d.active(d.window), period) {
+			result = append(result, deviceSpan{Hostname: d.hostname, Span: span})
+		}
+	}
+	return result, nil
+}
+
+// activeWebSpans возвращает время на вкладках внутри [start, end): события aw-watcher-web
+// обрезаются по периодам not-afk и по времени, когда окно этого браузера было активным,
+// иначе вкладка, открытая за редактором, считалась бы просмотренной.
+// Если на устройстве нет событий окон, фильтр по окну браузера не применяется
+func (h *ActivityWatchHandler) activeWebSpans(ctx context.Context, userID pgtype.UUID, start, end time.Time, hostnames []string) ([]deviceSpan, error) {
+	devices, err := listDeviceEvents(ctx, h.store, userID, start, end, hostnames)
+	if err != nil {
+		return nil, err
+	}
+
+	period := []activitywatch.Span{{Start: start, End: end}}
+	var result []deviceSpan
+	for _, d := range devices {
+		web := d.active(d.web)
+		if len(d.window) > 0 {
+			byBrowser := map[string][]activitywatch.Span{}
+			for _, span := range web {
+				byBrowser[span.App] = append(byBrowser[span.App], span)
+			}
+			web = web[:0:0]
+			for browser, spans := range byBrowser {
+				var focused []activitywatch.Span
+				for _, span := range d.window {
+					if activitywatch.IsBrowserApp(span.App, browser) {
+						focused = append(focused, span)
+					}
+				}
+				web = append(web, activitywatch.ActiveSpans(spans, focused)...)
+			}
+		}
+		for _, span := range activitywatch.ActiveSpans(web, period) {
+			result = append(result, deviceSpan{Hostname: d.hostname, Span: span})
+		}
+	}
+	return result, nil
+}
+
+// deviceEvents события одного устройства за период, разобранные по watcher-ам
+type deviceEvents struct {
+	hostname string
+	hasAFK   bool
+	window   []activitywatch.Span
+	web      []activitywatch.Span
+	notAFK   []activitywatch.Span
+}
 
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":  "Events saved successfully",
-		"inserted": count,
+// active обрезает отрезки по периодам not-afk, если на устройстве работает aw-watcher-afk
+func (d *deviceEvents) active(spans []activitywatch.Span) []activitywatch.Span {
+	if !d.hasAFK {
+		return spans
+	}
+	return activitywatch.ActiveSpans(spans, d.notAFK)
+}
+
+// listDeviceEvents загружает события за период и группирует их по устройствам в порядке первого события
+func listDeviceEvents(ctx context.Context, store *internal_db.Store, userID pgtype.UUID, start, end time.Time, hostnames []string) ([]*deviceEvents, error) {
+	rows, err := store.ActivityWatch.ListEventSpans(ctx, activitywatch_db.ListEventSpansParams{
+		UserID:    userID,
+		StartTime: pgtype.Timestamptz{Time: start, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: end, Valid: true},
+		Hostnames: hostnames,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[int64]*deviceEvents{}
+	var devices []*deviceEvents
+	for _, row := range rows {
+		// События без устройства собираются под id 0: BIGSERIAL начинается с 1
+		d, ok := index[row.DeviceID.Int64]
+		if !ok {
+			d = &deviceEvents{hostname: row.Hostname.String}
+			index[row.DeviceID.Int64] = d
+			devices = append(devices, d)
+		}
+
+		span := activitywatch.Span{
+			App:    row.App,
+			Title:  row.Title.String,
+			URL:    row.Url.String,
+			Domain: row.Domain.String,
+			Start:  row.Timestamp.Time,
+			End:    row.Timestamp.Time.Add(time.Duration(row.Duration * float64(time.Second))),
+		}
+		switch {
+		case row.App == activitywatch.AFKApp:
+			d.hasAFK = true
+			if span.Title == activitywatch.StatusNotAFK {
+				d.notAFK = append(d.notAFK, span)
+			}
+		case activitywatch.IsWebBucket(row.BucketID):
+			d.web = append(d.web, span)
+		case activitywatch.IsEditorBucket(row.BucketID):
+			// время в редакторе уже учтено в его окне
+		default:
+			d.window = append(d.window, span)
+		}
+	}
+	return devices, nil
 }
 
-// GetStats обрабатывает GET /api/v1/activitywatch/stats
-// @Summary Получить статистику ActivityWatch
-// @Description Возвращает статистику по использованию приложений за указанный период
+// GetDevices обрабатывает GET /api/v1/activitywatch/devices
+// @Summary Устройства ActivityWatch
+// @Description Возвращает устройства пользователя, определённые по hostname бакетов aw-server
 // @Tags ActivityWatch
-// @Accept json
 // @Produce json
-// @Param start query string false "Время начала (RFC3339)" default(24 hours ago)
-// @Param end query string false "Время окончания (RFC3339)" default(now)
-// @Success 200 {array} activitywatch_db.GetAppStatsRow
-// @Failure 400 {object} map[string]string
+// @Success 200 {array} models_api_v1.ActivityDevice
+// @Failure 401 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Security ApiKeyAuth
-// @Router /activitywatch/stats [get]
-func (h *ActivityWatchHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+// @Router /activitywatch/devices [get]
+func (h *ActivityWatchHandler) GetDevices(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	query := r.URL.Query()
-	startStr := query.Get("start")
-	endStr := query.Get("end")
-
-	var start, end time.Time
-	var err error
-
-	if startStr != "" {
-		start, err = time.Parse(time.RFC3339, startStr)
-		if err != nil {
-			http.Error(w, "Invalid start time", http.StatusBadRequest)
-			return
-		}
-	} else {
-		start = time.Now().Add(-24 * time.Hour)
-	}
-
-	if endStr != "" {
-		end, err = time.Parse(time.RFC3339, endStr)
-		if err != nil {
-			http.Error(w, "Invalid end time", http.StatusBadRequest)
-			return
-		}
-	} else {
-		end = time.Now()
-	}
-
-	ctx := context.Background()
-	params := activitywatch_db.GetAppStatsParams{
-		Timestamp:   pgtype.Timestamptz{Time: start, Valid: true},
-		Timestamp_2: pgtype.Timestamptz{Time: end, Valid: true},
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
 	}
 
-	stats, err := h.store.ActivityWatch.GetAppStats(ctx, params)
+	rows, err := h.store.ActivityWatch.ListDevices(r.Context(), userID)
 	if err != nil {
-		h.logger.Error().Err(err).Msg("Failed to get stats")
-		http.Error(w, "Failed to get stats", http.StatusInternalServerError)
+		h.logger.Error().Err(err).Msg("Failed to list devices")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
 		return
 	}
 
+	devices := make([]models_api_v1.ActivityDevice, 0, len(rows))
+	for _, row := range rows {
+		devices = append(devices, models_api_v1.ActivityDevice{
+			ID:        row.ID,
+			Hostname:  row.Hostname,
+			FirstSeen: row.FirstSeen.Time.Format(time.RFC3339),
+			LastSeen:  row.LastSeen.Time.Format(time.RFC3339),
+		})
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(stats)
+	_ = json.NewEncoder(w).Encode(devices)
 }
@@ -0,0 +1,314 @@
+package handlers_api_v1
+
+import (
+	"DataLake/activitywatch"
+	models_api_v1 "DataLake/api/v1/models"
+	activitywatch_db "DataLake/internal/db/activitywatch"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxCategoriesImportSize экспорт aw-webui с сотнями категорий занимает десятки килобайт
+const maxCategoriesImportSize = 1 << 20
+
+// Categories обрабатывает GET, POST, PUT?id= и DELETE?id= /api/v1/activitywatch/categories.
+// Правила применяются при запросе статистики, поэтому изменения сразу видны и для старых событий.
+func (h *ActivityWatchHandler) Categories(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		dbResult, err := h.store.ActivityWatch.ListCategoryRules(r.Context(), userID)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get category rules from DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]models_api_v1.ActivityCategoryRule, 0, len(dbResult))
+		for _, row := range dbResult {
+			response = append(response, categoryRuleFromRow(row))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodPost, http.MethodPut:
+		rule, err := decodeCategoryRule(w, r)
+		if err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+
+		var row activitywatch_db.ActivityCategoryRule
+		status := http.StatusCreated
+		if r.Method == http.MethodPost {
+			row, err = h.store.ActivityWatch.CreateCategoryRule(r.Context(), activitywatch_db.CreateCategoryRuleParams{
+				UserID:     userID,
+				Category:   rule.Category,
+				Field:      rule.Field,
+				Pattern:    rule.Pattern,
+				IgnoreCase: rule.IgnoreCase,
+				Color:      pgtype.Text{String: rule.Color, Valid: rule.Color != ""},
+			})
+		} else {
+			id, parseErr := strconv.ParseInt(r.URL.Query().Get("id"), 10, 32)
+			if parseErr != nil {
+				http.Error(w, `{"error": "Invalid id"}`, http.StatusBadRequest)
+				return
+			}
+			status = http.StatusOK
+			row, err = h.store.ActivityWatch.UpdateCategoryRule(r.Context(), activitywatch_db.UpdateCategoryRuleParams{
+				Category:   rule.Category,
+				Field:      rule.Field,
+				Pattern:    rule.Pattern,
+				IgnoreCase: rule.IgnoreCase,
+				Color:      pgtype.Text{String: rule.Color, Valid: rule.Color != ""},
+				UserID:     userID,
+				ID:         int32(id),
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, `{"error": "Category rule not found"}`, http.StatusNotFound)
+				return
+			}
+		}
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to save category rule")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(categoryRuleFromRow(row))
+
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "Invalid id"}`, http.StatusBadRequest)
+			return
+		}
+
+		deleted, err := h.store.ActivityWatch.DeleteCategoryRule(r.Context(), activitywatch_db.DeleteCategoryRuleParams{
+			UserID: userID,
+			ID:     int32(id),
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to delete category rule")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			http.Error(w, `{"error": "Category rule not found"}`, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+	}
+}
+
+// ImportCategories обрабатывает POST /api/v1/activitywatch/categories/import.
+// Принимает экспорт категорий aw-webui; с replace=true заменяет все правила пользователя.
+// Категории-родители без выражения пропускаются молча, невалидные для RE2 - с причиной в skipped.
+func (h *ActivityWatchHandler) ImportCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCategoriesImportSize))
+	if err != nil {
+		http.Error(w, `{"error": "Request body too large"}`, http.StatusRequestEntityTooLarge)
+		return
+	}
+	rules, skipped, err := activitywatch.ParseAWCategories(body)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	replace := r.URL.Query().Get("replace") == "true"
+	err = h.store.ExecTxActivityWatch(r.Context(), func(q *activitywatch_db.Queries) error {
+		if replace {
+			if err := q.DeleteAllCategoryRules(r.Context(), userID); err != nil {
+				return err
+			}
+		}
+		for _, rule := range rules {
+			if _, err := q.CreateCategoryRule(r.Context(), activitywatch_db.CreateCategoryRuleParams{
+				UserID:     userID,
+				Category:   rule.Category,
+				Field:      rule.Field,
+				Pattern:    rule.Pattern,
+				IgnoreCase: rule.IgnoreCase,
+				Color:      pgtype.Text{String: rule.Color, Valid: rule.Color != ""},
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to import category rules")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info().Int("imported", len(rules)).Int("skipped", len(skipped)).Bool("replace", replace).Msg("Imported ActivityWatch categories")
+
+	if skipped == nil {
+		skipped = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models_api_v1.ActivityCategoryImportResponse{
+		Imported: len(rules),
+		Skipped:  skipped,
+	})
+}
+
+// GetCategoryStats обрабатывает GET /api/v1/activitywatch/categories/stats.
+// Возвращает активное время каждого узла дерева категорий вместе с подкатегориями:
+// время "Work > Programming > Go" входит и в "Work > Programming", и в "Work".
+func (h *ActivityWatchHandler) GetCategoryStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	categorizer, colors, err := h.categorizer(r.Context(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get category rules from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+	spans, err := activeSpans(r.Context(), h.store, userID, start, end, parseDevices(r))
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get activity events from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	index := map[string]int{}
+	stats := []models_api_v1.ActivityCategoryStat{}
+	for _, span := range spans {
+		path := categorizer.Categorize(span.App, span.Title, span.URL)
+		for depth := 1; depth <= len(path); depth++ {
+			name := strings.Join(path[:depth], activitywatch.CategorySeparator)
+			i, ok := index[name]
+			if !ok {
+				i = len(stats)
+				index[name] = i
+				stats = append(stats, models_api_v1.ActivityCategoryStat{
+					Category: name,
+					Path:     path[:depth],
+					Color:    colors[name],
+				})
+			}
+			stats[i].TotalDuration += span.Duration().Seconds()
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Category < stats[j].Category })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// categorizer загружает правила пользователя и цвета категорий по имени "Work > Programming"
+func (h *ActivityWatchHandler) categorizer(ctx context.Context, userID pgtype.UUID) (*activitywatch.Categorizer, map[string]string, error) {
+	rows, err := h.store.ActivityWatch.ListCategoryRules(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rules := make([]activitywatch.CategoryRule, 0, len(rows))
+	colors := map[string]string{}
+	for _, row := range rows {
+		rules = append(rules, activitywatch.CategoryRule{
+			Category:   row.Category,
+			Field:      row.Field,
+			Pattern:    row.Pattern,
+			IgnoreCase: row.IgnoreCase,
+		})
+		name := strings.Join(row.Category, activitywatch.CategorySeparator)
+		if _, ok := colors[name]; !ok && row.Color.Valid {
+			colors[name] = row.Color.String
+		}
+	}
+	return activitywatch.NewCategorizer(rules), colors, nil
+}
+
+// decodeCategoryRule читает и проверяет тело POST и PUT /activitywatch/categories
+func decodeCategoryRule(w http.ResponseWriter, r *http.Request) (activitywatch.CategoryRule, error) {
+	var req models_api_v1.ActivityCategoryRuleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16384)).Decode(&req); err != nil {
+		return activitywatch.CategoryRule{}, fmt.Errorf("invalid JSON format")
+	}
+
+	rule := activitywatch.CategoryRule{
+		Field:      req.Field,
+		Pattern:    req.Pattern,
+		IgnoreCase: req.IgnoreCase == nil || *req.IgnoreCase,
+		Color:      activitywatch.NormalizeColor(req.Color),
+	}
+	for _, name := range req.Category {
+		rule.Category = append(rule.Category, strings.TrimSpace(name))
+	}
+	if rule.Field == "" {
+		rule.Field = activitywatch.RuleFieldAny
+	}
+	if req.Color != "" && rule.Color == "" {
+		return rule, fmt.Errorf("color must be #rrggbb")
+	}
+	return rule, rule.Validate()
+}
+
+func categoryRuleFromRow(row activitywatch_db.ActivityCategoryRule) models_api_v1.ActivityCategoryRule {
+	return models_api_v1.ActivityCategoryRule{
+		ID:         row.ID,
+		Category:   row.Category,
+		Field:      row.Field,
+		Pattern:    row.Pattern,
+		IgnoreCase: row.IgnoreCase,
+		Color:      row.Color.String,
+	}
+}
@@ -0,0 +1,194 @@
+package handlers_api_v1
+
+import (
+	"DataLake/activitywatch"
+	models_api_v1 "DataLake/api/v1/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Экспорт aw-server за несколько лет - сотни мегабайт JSON, сжатый gzip в 10-20 раз меньше
+const (
+	maxImportBodySize    = 1 << 30
+	maxImportDecodedSize = 4 << 30
+)
+
+// ImportExport обрабатывает POST /api/v1/activitywatch/import.
+// Принимает экспорт aw-server (GET /api/0/export или экспорт бакета из aw-webui), в том числе сжатый gzip,
+// и загружает события бакетов window, afk, web и редакторов через ту же проверку и редактирование,
+// что и /activitywatch/events. Экспорт читается потоком, события сохраняются пачками.
+// Повторный импорт безопасен: события определяются парой bucket_id + timestamp.
+func (h *ActivityWatchHandler) ImportExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	body, err := limitBody(w, r, maxImportBodySize, maxImportDecodedSize)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	defer body.Close()
+
+	ctx := r.Context()
+	redactor, err := h.redactor(ctx, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get redaction rules from DB")
+		http.Error(w, `{"error": "Failed to import events"}`, http.StatusInternalServerError)
+		return
+	}
+
+	imp := &exportImporter{h: h, userID: userID, redactor: redactor, index: map[string]int{}}
+	err = activitywatch.ReadExport(body, func(bucket activitywatch.Bucket, events []activitywatch.Event) error {
+		return imp.add(r, bucket, events)
+	})
+	if err == nil {
+		err = imp.finish(r)
+	}
+
+	var syntaxErr *activitywatch.ExportSyntaxError
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		http.Error(w, `{"error": "Request body too large"}`, http.StatusRequestEntityTooLarge)
+		return
+	case errors.As(err, &syntaxErr):
+		writeBadRequest(w, err)
+		return
+	case err != nil:
+		h.logger.Error().Err(err).Int("buckets", len(imp.response.Buckets)).Msg("Failed to import activity events")
+		http.Error(w, `{"error": "Failed to import events"}`, http.StatusInternalServerError)
+		return
+	}
+
+	response := imp.response
+	if response.Buckets == nil {
+		response.Buckets = []models_api_v1.ActivityImportBucket{}
+	}
+	for _, bucket := range response.Buckets {
+		response.Events += bucket.Events
+	}
+	response.Message = "Export imported successfully"
+
+	h.logger.Info().
+		Int("buckets", len(response.Buckets)).
+		Int("events", response.Events).
+		Int("inserted", response.Inserted).
+		Int("updated", response.Updated).
+		Int("rejected", response.Rejected).
+		Msg("Imported ActivityWatch export")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// exportImporter раскладывает события экспорта по бакетам: ReadExport отдаёт бакеты по очереди,
+// поэтому ingester нужен только текущему бакету
+type exportImporter struct {
+	h        *ActivityWatchHandler
+	userID   pgtype.UUID
+	redactor *activitywatch.Redactor
+	index    map[string]int
+	current  string
+	ingester *eventIngester
+	response models_api_v1.ActivityImportResponse
+}
+
+func (imp *exportImporter) add(r *http.Request, bucket activitywatch.Bucket, events []activitywatch.Event) error {
+	if bucket.ID != imp.current || imp.ingester == nil {
+		if err := imp.finish(r); err != nil {
+			return err
+		}
+		if _, ok := imp.index[bucket.ID]; !ok {
+			imp.index[bucket.ID] = len(imp.response.Buckets)
+			imp.response.Buckets = append(imp.response.Buckets, models_api_v1.ActivityImportBucket{
+				BucketID: bucket.ID,
+				Type:     bucket.Type,
+				Hostname: bucket.Hostname,
+			})
+		}
+		imp.current = bucket.ID
+		imp.ingester = imp.h.newIngester(imp.userID, imp.redactor)
+	}
+
+	stat := &imp.response.Buckets[imp.index[bucket.ID]]
+	if !bucket.Supported() {
+		stat.Skipped = "unsupported bucket type"
+		stat.Events += len(events)
+		return nil
+	}
+	for _, event := range events {
+		index := stat.Events
+		stat.Events++
+
+		ingest, ok := activitywatch.ToIngest(bucket, event)
+		if !ok {
+			imp.ingester.reject(index, fmt.Errorf("event data has no app"))
+			continue
+		}
+		if err := imp.ingester.add(r.Context(), index, ActivityEventRequest{
+			Timestamp: ingest.Timestamp,
+			Duration:  ingest.Duration,
+			App:       ingest.App,
+			Title:     ingest.Title,
+			BucketID:  ingest.BucketID,
+			EventID:   ingest.EventID,
+			Hostname:  ingest.Hostname,
+			URL:       ingest.URL,
+			Audible:   ingest.Audible,
+			Incognito: ingest.Incognito,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finish сохраняет остаток текущего бакета и добавляет его счётчики к бакету и итогам
+func (imp *exportImporter) finish(r *http.Request) error {
+	if imp.ingester == nil {
+		return nil
+	}
+	if err := imp.ingester.flush(r.Context()); err != nil {
+		return err
+	}
+
+	result := imp.ingester.result
+	stat := &imp.response.Buckets[imp.index[imp.current]]
+	stat.Accepted += result.Accepted
+	stat.Inserted += result.Inserted
+	stat.Updated += result.Updated
+	stat.Unchanged += result.Unchanged
+	stat.Rejected += result.Rejected
+	if room := maxReportedErrors - len(stat.Errors); room > 0 {
+		stat.Errors = append(stat.Errors, result.Errors[:min(room, len(result.Errors))]...)
+	}
+
+	imp.response.Accepted += result.Accepted
+	imp.response.Inserted += result.Inserted
+	imp.response.Updated += result.Updated
+	imp.response.Unchanged += result.Unchanged
+	imp.response.Rejected += result.Rejected
+	imp.ingester = nil
+
+	imp.h.logger.Info().
+		Str("bucket_id", stat.BucketID).
+		Int("events", stat.Events).
+		Int("inserted", stat.Inserted).
+		Int("rejected", stat.Rejected).
+		Str("skipped", stat.Skipped).
+		Msg("Imported ActivityWatch bucket")
+	return nil
+}
@@ -0,0 +1,467 @@
+package handlers_api_v1
+
+import (
+	"DataLake/activitywatch"
+	models_api_v1 "DataLake/api/v1/models"
+	activitywatch_db "DataLake/internal/db/activitywatch"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Ограничения приёма событий
+const (
+	maxIngestBodySize    = 32 << 20  // тело запроса как пришло, в том числе сжатое
+	maxIngestDecodedSize = 256 << 20 // после распаковки gzip
+	maxIngestLineSize    = 1 << 20   // одна строка NDJSON
+	ingestBatchSize      = 1000      // событий в одной пачке upsert
+	maxReportedErrors    = 100       // ошибок событий в ответе, остальные только считаются
+
+	maxEventDuration   = 7 * 24 * time.Hour // длиннее бывают только сломанные heartbeat-ы
+	maxEventClockSkew  = 5 * time.Minute    // допуск расхождения часов устройства и lake
+	maxEventTextLength = 4096               // app, title, url и bucket_id
+)
+
+// errBodyTooLarge тело запроса больше maxIngestBodySize или maxIngestDecodedSize
+var errBodyTooLarge = errors.New("request body too large")
+
+type ActivityEventRequest struct {
+	Timestamp time.Time `json:"timestamp"`
+	Duration  float64   `json:"duration"`
+	App       string    `json:"app"`
+	Title     string    `json:"title"`
+	BucketID  string    `json:"bucket_id"`
+	EventID   int64     `json:"event_id,omitempty"` // id события в aw-server
+	Hostname  string    `json:"hostname,omitempty"` // по умолчанию из bucket_id
+	Status    string    `json:"status,omitempty"`   // событие aw-watcher-afk: afk или not-afk
+	URL       string    `json:"url,omitempty"`      // событие aw-watcher-web: адрес вкладки
+	Domain    string    `json:"domain,omitempty"`   // по умолчанию из url
+	Audible   bool      `json:"audible,omitempty"`
+	Incognito bool      `json:"incognito,omitempty"`
+}
+
+// HandleEvents обрабатывает POST /api/v1/activitywatch/events.
+// Принимает JSON-массив событий или NDJSON (Content-Type application/x-ndjson) по одному событию в строке,
+// тело может быть сжато gzip (Content-Encoding: gzip). NDJSON читается потоком и сохраняется пачками,
+// поэтому размер выгрузки ограничен только maxIngestDecodedSize.
+// Невалидные события пропускаются с указанием индекса и причины, остальные сохраняются;
+// если не принято ни одно событие, ответ 422.
+func (h *ActivityWatchHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	body, err := ingestBody(w, r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	defer body.Close()
+
+	// Заголовки и адреса редактируются до записи, исходные значения не сохраняются и не логируются
+	ctx := r.Context()
+	redactor, err := h.redactor(ctx, userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get redaction rules from DB")
+		http.Error(w, `{"error": "Failed to save events"}`, http.StatusInternalServerError)
+		return
+	}
+
+	ingester := h.newIngester(userID, redactor)
+	err = decodeEvents(body, isNDJSON(r), func(index int, raw []byte) error {
+		var event ActivityEventRequest
+		if err := json.Unmarshal(raw, &event); err != nil {
+			ingester.reject(index, fmt.Errorf("invalid event: %w", err))
+			return nil
+		}
+		return ingester.add(ctx, index, event)
+	})
+	if err == nil {
+		err = ingester.flush(ctx)
+	}
+	response := ingester.result
+
+	var syntaxErr *ingestSyntaxError
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		http.Error(w, `{"error": "Request body too large"}`, http.StatusRequestEntityTooLarge)
+		return
+	case errors.As(err, &syntaxErr):
+		writeBadRequest(w, err)
+		return
+	case err != nil:
+		h.logger.Error().Err(err).Int("accepted", response.Accepted).Msg("Failed to upsert events")
+		http.Error(w, `{"error": "Failed to save events"}`, http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	switch {
+	case response.Accepted == 0 && response.Rejected == 0:
+		response.Message = "No events to insert"
+	case response.Accepted == 0:
+		response.Message = "All events rejected"
+		status = http.StatusUnprocessableEntity
+	default:
+		response.Message = "Events saved successfully"
+	}
+
+	h.logger.Info().
+		Int("inserted", response.Inserted).
+		Int("updated", response.Updated).
+		Int("accepted", response.Accepted).
+		Int("rejected", response.Rejected).
+		Msg("Saved activity events")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// eventIngester проверяет, редактирует и сохраняет события пачками по ingestBatchSize,
+// собирая счётчики и причины отклонения событий в result
+type eventIngester struct {
+	h        *ActivityWatchHandler
+	userID   pgtype.UUID
+	redactor *activitywatch.Redactor
+	now      time.Time
+	pending  []ActivityEventRequest
+	result   models_api_v1.ActivityIngestResponse
+}
+
+func (h *ActivityWatchHandler) newIngester(userID pgtype.UUID, redactor *activitywatch.Redactor) *eventIngester {
+	return &eventIngester{
+		h:        h,
+		userID:   userID,
+		redactor: redactor,
+		now:      time.Now(),
+		pending:  make([]ActivityEventRequest, 0, ingestBatchSize),
+		result:   models_api_v1.ActivityIngestResponse{Errors: []models_api_v1.ActivityIngestError{}},
+	}
+}
+
+// add проверяет событие и добавляет его в пачку, невалидное событие отклоняется без ошибки.
+// Ошибку возвращает только сохранение заполненной пачки
+func (in *eventIngester) add(ctx context.Context, index int, event ActivityEventRequest) error {
+	if err := normalizeEvent(&event, in.now); err != nil {
+		in.reject(index, err)
+		return nil
+	}
+	event.Title, event.URL, event.Domain = in.redactor.Redact(event.App, event.Title, event.URL, event.Domain)
+
+	in.pending = append(in.pending, event)
+	if len(in.pending) >= ingestBatchSize {
+		return in.flush(ctx)
+	}
+	return nil
+}
+
+func (in *eventIngester) reject(index int, err error) {
+	in.result.Rejected++
+	if len(in.result.Errors) < maxReportedErrors {
+		in.result.Errors = append(in.result.Errors, models_api_v1.ActivityIngestError{Index: index, Error: err.Error()})
+	}
+}
+
+// flush сохраняет накопленную пачку
+func (in *eventIngester) flush(ctx context.Context) error {
+	if len(in.pending) == 0 {
+		return nil
+	}
+	inserted, updated, err := in.h.saveEvents(ctx, in.userID, in.pending)
+	if err != nil {
+		return err
+	}
+	in.result.Accepted += len(in.pending)
+	in.result.Inserted += inserted
+	in.result.Updated += updated
+	in.result.Unchanged = in.result.Accepted - in.result.Inserted - in.result.Updated
+	in.pending = in.pending[:0]
+	return nil
+}
+
+// ingestBody ограничивает тело запроса и распаковывает gzip
+func ingestBody(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	return limitBody(w, r, maxIngestBodySize, maxIngestDecodedSize)
+}
+
+// limitBody ограничивает тело запроса maxSize байт как пришло и maxDecodedSize после распаковки gzip
+func limitBody(w http.ResponseWriter, r *http.Request, maxSize, maxDecodedSize int64) (io.ReadCloser, error) {
+	body := http.MaxBytesReader(w, r.Body, maxSize)
+
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return &limitedBody{ReadCloser: body, remaining: maxDecodedSize}, nil
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		return &limitedBody{ReadCloser: zr, remaining: maxDecodedSize}, nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding, use gzip")
+	}
+}
+
+// limitedBody возвращает errBodyTooLarge, когда прочитано больше remaining байт,
+// в том числе после распаковки: сжатое тело может быть во много раз меньше исходного
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n, errBodyTooLarge
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
+
+// isNDJSON сообщает, что тело - NDJSON: application/x-ndjson, application/jsonl и похожие
+func isNDJSON(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return true
+	}
+	return false
+}
+
+// ingestSyntaxError тело нельзя разобрать дальше: ответ 400 без частичного приёма оставшихся событий
+type ingestSyntaxError struct {
+	err error
+}
+
+func (e *ingestSyntaxError) Error() string { return e.err.Error() }
+func (e *ingestSyntaxError) Unwrap() error { return e.err }
+
+// decodeEvents вызывает fn для каждого события тела по порядку. Ошибка синтаксиса JSON-массива
+// прерывает разбор, а в NDJSON строка с ошибкой отклоняется через fn как обычное невалидное событие
+func decodeEvents(body io.Reader, ndjson bool, fn func(index int, raw []byte) error) error {
+	if ndjson {
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxIngestLineSize)
+		index := 0
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			if err := fn(index, line); err != nil {
+				return err
+			}
+			index++
+		}
+		switch err := scanner.Err(); {
+		case err == nil, errors.Is(err, errBodyTooLarge):
+			return err
+		case errors.Is(err, bufio.ErrTooLong):
+			return &ingestSyntaxError{err: fmt.Errorf("line %d is longer than %d bytes", index+1, maxIngestLineSize)}
+		default:
+			return &ingestSyntaxError{err: fmt.Errorf("failed to read request body: %w", err)}
+		}
+	}
+
+	decoder := json.NewDecoder(body)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		if errors.Is(err, errBodyTooLarge) {
+			return err
+		}
+		return &ingestSyntaxError{err: fmt.Errorf("request body must be a JSON array of events")}
+	}
+	for index := 0; decoder.More(); index++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, errBodyTooLarge) {
+				return err
+			}
+			return &ingestSyntaxError{err: fmt.Errorf("invalid JSON at event %d: %w", index, err)}
+		}
+		if err := fn(index, raw); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		if errors.Is(err, errBodyTooLarge) {
+			return err
+		}
+		return &ingestSyntaxError{err: fmt.Errorf("invalid JSON: %w", err)}
+	}
+	return nil
+}
+
+// normalizeEvent проверяет событие и приводит его к виду хранения: события aw-watcher-afk -
+// приложение afk со статусом в title, у событий aw-watcher-web домен без www и порта,
+// а приложение по умолчанию - браузер из id бакета
+func normalizeEvent(event *ActivityEventRequest, now time.Time) error {
+	if event.Status != "" {
+		if event.Status != activitywatch.StatusAFK && event.Status != activitywatch.StatusNotAFK {
+			return fmt.Errorf("status must be afk or not-afk")
+		}
+		event.App = activitywatch.AFKApp
+		event.Title = event.Status
+	}
+	if event.URL != "" {
+		event.Domain = activitywatch.NormalizeDomain(event.URL)
+	} else {
+		event.Domain = activitywatch.NormalizeDomainName(event.Domain)
+	}
+	if event.App == "" && (event.URL != "" || activitywatch.IsWebBucket(event.BucketID)) {
+		event.App = activitywatch.BrowserFromBucketID(event.BucketID)
+	}
+	event.App = strings.TrimSpace(event.App)
+	event.BucketID = strings.TrimSpace(event.BucketID)
+	event.Hostname = strings.TrimSpace(event.Hostname)
+
+	switch {
+	case event.Timestamp.IsZero():
+		return fmt.Errorf("timestamp is required")
+	case event.Timestamp.After(now.Add(maxEventClockSkew)):
+		return fmt.Errorf("timestamp is in the future")
+	case event.Duration < 0:
+		return fmt.Errorf("duration must not be negative")
+	case event.Duration > maxEventDuration.Seconds():
+		return fmt.Errorf("duration must not exceed %s", maxEventDuration)
+	case event.BucketID == "":
+		return fmt.Errorf("bucket_id is required")
+	case event.App == "":
+		return fmt.Errorf("app is required")
+	case event.EventID < 0:
+		return fmt.Errorf("event_id must not be negative")
+	}
+	for _, field := range []struct{ name, value string }{
+		{"app", event.App}, {"title", event.Title}, {"url", event.URL}, {"bucket_id", event.BucketID}, {"hostname", event.Hostname},
+	} {
+		if len(field.value) > maxEventTextLength {
+			return fmt.Errorf("%s is longer than %d bytes", field.name, maxEventTextLength)
+		}
+		if !utf8.ValidString(field.value) || strings.ContainsRune(field.value, 0) {
+			return fmt.Errorf("%s must be valid UTF-8 without NUL", field.name)
+		}
+	}
+	return nil
+}
+
+// saveEvents сохраняет пачку проверенных событий: повторно отправленные события обновляются, а не дублируются
+func (h *ActivityWatchHandler) saveEvents(ctx context.Context, userID pgtype.UUID, events []ActivityEventRequest) (int, int, error) {
+	devices, err := h.upsertDevices(ctx, userID, events)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to upsert devices: %w", err)
+	}
+
+	params := make([]activitywatch_db.UpsertEventsParams, len(events))
+	for i, event := range events {
+		deviceID, hasDevice := devices[eventHostname(event)]
+		params[i] = activitywatch_db.UpsertEventsParams{
+			UserID:    userID,
+			Timestamp: pgtype.Timestamptz{Time: event.Timestamp, Valid: true},
+			Duration:  event.Duration,
+			App:       event.App,
+			Title:     pgtype.Text{String: event.Title, Valid: event.Title != ""},
+			BucketID:  event.BucketID,
+			AwEventID: pgtype.Int8{Int64: event.EventID, Valid: event.EventID > 0},
+			DeviceID:  pgtype.Int8{Int64: deviceID, Valid: hasDevice},
+			Url:       pgtype.Text{String: event.URL, Valid: event.URL != ""},
+			Domain:    pgtype.Text{String: event.Domain, Valid: event.Domain != ""},
+			Audible:   event.Audible,
+			Incognito: event.Incognito,
+		}
+	}
+
+	inserted, updated := 0, 0
+	var batchErr error
+	h.store.ActivityWatch.UpsertEvents(ctx, params).QueryRow(func(_ int, isNew bool, err error) {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			// событие не изменилось
+		case err != nil:
+			if batchErr == nil {
+				batchErr = err
+			}
+		case isNew:
+			inserted++
+		default:
+			updated++
+		}
+	})
+	return inserted, updated, batchErr
+}
+
+// eventHostname hostname устройства события: из запроса или из id бакета
+func eventHostname(event ActivityEventRequest) string {
+	if event.Hostname != "" {
+		return event.Hostname
+	}
+	return activitywatch.HostnameFromBucketID(event.BucketID)
+}
+
+// upsertDevices регистрирует устройства событий пачки и возвращает их id по hostname
+func (h *ActivityWatchHandler) upsertDevices(ctx context.Context, userID pgtype.UUID, events []ActivityEventRequest) (map[string]int64, error) {
+	type seen struct{ first, last time.Time }
+	hosts := map[string]*seen{}
+	for _, event := range events {
+		hostname := eventHostname(event)
+		if hostname == "" {
+			continue
+		}
+		end := event.Timestamp.Add(time.Duration(event.Duration * float64(time.Second)))
+		if host, ok := hosts[hostname]; ok {
+			if event.Timestamp.Before(host.first) {
+				host.first = event.Timestamp
+			}
+			if end.After(host.last) {
+				host.last = end
+			}
+			continue
+		}
+		hosts[hostname] = &seen{first: event.Timestamp, last: end}
+	}
+
+	devices := make(map[string]int64, len(hosts))
+	for hostname, host := range hosts {
+		id, err := h.store.ActivityWatch.UpsertDevice(ctx, activitywatch_db.UpsertDeviceParams{
+			UserID:    userID,
+			Hostname:  hostname,
+			FirstSeen: pgtype.Timestamptz{Time: host.first, Valid: true},
+			LastSeen:  pgtype.Timestamptz{Time: host.last, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		devices[hostname] = id
+	}
+	return devices, nil
+}
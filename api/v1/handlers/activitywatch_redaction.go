@@ -0,0 +1,348 @@
+package handlers_api_v1
+
+import (
+	"DataLake/activitywatch"
+	models_api_v1 "DataLake/api/v1/models"
+	"DataLake/auth"
+	activitywatch_db "DataLake/internal/db/activitywatch"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// redactionBatchSize событий в одной транзакции при редактировании загруженных событий
+const redactionBatchSize = 1000
+
+// redactionKeyPurpose назначение ключа HMAC правил hash, производного от ENCRYPTION_KEY
+const redactionKeyPurpose = "activitywatch-redaction"
+
+// RedactionRules обрабатывает GET, POST, PUT?id= и DELETE?id= /api/v1/activitywatch/redaction-rules.
+// Правила применяются к новым событиям при приёме, к загруженным - через /activitywatch/redaction/apply.
+func (h *ActivityWatchHandler) RedactionRules(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		dbResult, err := h.store.ActivityWatch.ListRedactionRules(r.Context(), userID)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get redaction rules from DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]models_api_v1.ActivityRedactionRule, 0, len(dbResult))
+		for _, row := range dbResult {
+			response = append(response, redactionRuleFromRow(row))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodPost, http.MethodPut:
+		rule, err := decodeRedactionRule(w, r)
+		if err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+
+		var row activitywatch_db.ActivityRedactionRule
+		status := http.StatusCreated
+		if r.Method == http.MethodPost {
+			row, err = h.store.ActivityWatch.CreateRedactionRule(r.Context(), activitywatch_db.CreateRedactionRuleParams{
+				UserID:      userID,
+				App:         rule.App,
+				Field:       rule.Field,
+				Pattern:     rule.Pattern,
+				Action:      rule.Action,
+				Replacement: rule.Replacement,
+			})
+		} else {
+			id, parseErr := strconv.ParseInt(r.URL.Query().Get("id"), 10, 32)
+			if parseErr != nil {
+				http.Error(w, `{"error": "Invalid id"}`, http.StatusBadRequest)
+				return
+			}
+			status = http.StatusOK
+			row, err = h.store.ActivityWatch.UpdateRedactionRule(r.Context(), activitywatch_db.UpdateRedactionRuleParams{
+				App:         rule.App,
+				Field:       rule.Field,
+				Pattern:     rule.Pattern,
+				Action:      rule.Action,
+				Replacement: rule.Replacement,
+				UserID:      userID,
+				ID:          int32(id),
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.Error(w, `{"error": "Redaction rule not found"}`, http.StatusNotFound)
+				return
+			}
+		}
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to save redaction rule")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(redactionRuleFromRow(row))
+
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "Invalid id"}`, http.StatusBadRequest)
+			return
+		}
+
+		deleted, err := h.store.ActivityWatch.DeleteRedactionRule(r.Context(), activitywatch_db.DeleteRedactionRuleParams{
+			UserID: userID,
+			ID:     int32(id),
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to delete redaction rule")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			http.Error(w, `{"error": "Redaction rule not found"}`, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+	}
+}
+
+// PrivateApps обрабатывает GET и PUT /api/v1/activitywatch/private-apps.
+// PUT заменяет список целиком; от событий приватных приложений сохраняются только приложение и длительность.
+func (h *ActivityWatchHandler) PrivateApps(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var apps []string
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 65536)).Decode(&apps); err != nil {
+			http.Error(w, `{"error": "invalid JSON format"}`, http.StatusBadRequest)
+			return
+		}
+
+		err := h.store.ExecTxActivityWatch(r.Context(), func(q *activitywatch_db.Queries) error {
+			if err := q.DeleteAllPrivateApps(r.Context(), userID); err != nil {
+				return err
+			}
+			for _, app := range apps {
+				if app = strings.TrimSpace(app); app == "" {
+					continue
+				}
+				if err := q.AddPrivateApp(r.Context(), activitywatch_db.AddPrivateAppParams{UserID: userID, App: app}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to save private apps")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	apps, err := h.store.ActivityWatch.ListPrivateApps(r.Context(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get private apps from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+	if apps == nil {
+		apps = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(apps)
+}
+
+// ApplyRedaction обрабатывает POST /api/v1/activitywatch/redaction/apply.
+// Применяет текущие правила и список приватных приложений ко всем загруженным событиям пользователя.
+// События обрабатываются пачками в отдельных транзакциях, повторный запуск безопасен:
+// захешированные значения не хешируются снова.
+func (h *ActivityWatchHandler) ApplyRedaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	redactor, err := h.redactor(r.Context(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get redaction rules from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	var response models_api_v1.ActivityRedactionResponse
+	var afterID int64
+	for !redactor.Empty() {
+		rows, err := h.store.ActivityWatch.ListEventsForRedaction(r.Context(), activitywatch_db.ListEventsForRedactionParams{
+			UserID:   userID,
+			AfterID:  afterID,
+			RowLimit: redactionBatchSize,
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get activity events from DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		if len(rows) == 0 {
+			break
+		}
+		afterID = rows[len(rows)-1].ID
+		response.Scanned += len(rows)
+
+		updated := 0
+		err = h.store.ExecTxActivityWatch(r.Context(), func(q *activitywatch_db.Queries) error {
+			for _, row := range rows {
+				title, url, domain := redactor.Redact(row.App, row.Title.String, row.Url.String, row.Domain.String)
+				if title == row.Title.String && url == row.Url.String && domain == row.Domain.String {
+					continue
+				}
+				if err := q.RedactEvent(r.Context(), activitywatch_db.RedactEventParams{
+					Title:  pgtype.Text{String: title, Valid: title != ""},
+					Url:    pgtype.Text{String: url, Valid: url != ""},
+					Domain: pgtype.Text{String: domain, Valid: domain != ""},
+					UserID: userID,
+					ID:     row.ID,
+				}); err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Int64("after_id", afterID).Msg("Failed to redact activity events")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		response.Updated += updated
+	}
+
+	h.logger.Info().Int("scanned", response.Scanned).Int("updated", response.Updated).Msg("Redacted activity events")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// redactor загружает правила редактирования и приватные приложения пользователя
+func (h *ActivityWatchHandler) redactor(ctx context.Context, userID pgtype.UUID) (*activitywatch.Redactor, error) {
+	rows, err := h.store.ActivityWatch.ListRedactionRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	apps, err := h.store.ActivityWatch.ListPrivateApps(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]activitywatch.RedactionRule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, activitywatch.RedactionRule{
+			App:         row.App,
+			Field:       row.Field,
+			Pattern:     row.Pattern,
+			Action:      row.Action,
+			Replacement: row.Replacement,
+		})
+	}
+	var key []byte
+	if activitywatch.HasHashRules(rules) {
+		if key, err = redactionKey(userID); err != nil {
+			return nil, err
+		}
+	}
+	return activitywatch.NewRedactor(rules, apps, key), nil
+}
+
+// redactionKey возвращает ключ HMAC правил hash пользователя: он производный от ENCRYPTION_KEY сервера
+// и id пользователя, поэтому хеши разных пользователей не совпадают
+func redactionKey(userID pgtype.UUID) ([]byte, error) {
+	secret, err := auth.DeriveKeyFromEnv(redactionKeyPurpose)
+	if err != nil {
+		return nil, fmt.Errorf("hash redaction rules require ENCRYPTION_KEY: %w", err)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(userID.Bytes[:])
+	return mac.Sum(nil), nil
+}
+
+// decodeRedactionRule читает и проверяет тело POST и PUT /activitywatch/redaction-rules
+func decodeRedactionRule(w http.ResponseWriter, r *http.Request) (activitywatch.RedactionRule, error) {
+	var req models_api_v1.ActivityRedactionRuleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16384)).Decode(&req); err != nil {
+		return activitywatch.RedactionRule{}, fmt.Errorf("invalid JSON format")
+	}
+
+	rule := activitywatch.RedactionRule{
+		App:         req.App,
+		Field:       req.Field,
+		Pattern:     req.Pattern,
+		Action:      req.Action,
+		Replacement: req.Replacement,
+	}
+	if rule.Field == "" {
+		rule.Field = activitywatch.RuleFieldTitle
+	}
+	if err := rule.Validate(); err != nil {
+		return rule, err
+	}
+	if rule.Action == activitywatch.RedactHash {
+		if _, err := auth.DeriveKeyFromEnv(redactionKeyPurpose); err != nil {
+			return rule, fmt.Errorf("hash action requires ENCRYPTION_KEY on the server")
+		}
+	}
+	return rule, nil
+}
+
+func redactionRuleFromRow(row activitywatch_db.ActivityRedactionRule) models_api_v1.ActivityRedactionRule {
+	return models_api_v1.ActivityRedactionRule{
+		ID:          row.ID,
+		App:         row.App,
+		Field:       row.Field,
+		Pattern:     row.Pattern,
+		Action:      row.Action,
+		Replacement: row.Replacement,
+	}
+}
@@ -0,0 +1,549 @@
+package handlers_api_v1
+
+import (
+	"DataLake/activitywatch"
+	models_api_v1 "DataLake/api/v1/models"
+	activitywatch_db "DataLake/internal/db/activitywatch"
+	"DataLake/internal/timezone"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// categorizedSpan отрезок активного времени с категорией по правилам пользователя
+type categorizedSpan struct {
+	deviceSpan
+	Category []string
+}
+
+// GetTimeline обрабатывает GET /api/v1/activitywatch/timeline.
+// Возвращает отрезки активного времени по порядку, постранично.
+func (h *ActivityWatchHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := h.parseStatsRequest(w, r)
+	if !ok {
+		return
+	}
+	limit, offset, err := parsePagination(r, 100, 1000)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	spans, ok := h.loadSpans(w, r, start, end)
+	if !ok {
+		return
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+
+	response := models_api_v1.ActivityTimelineResponse{
+		Total:  len(spans),
+		Limit:  limit,
+		Offset: offset,
+		Spans:  []models_api_v1.ActivitySpan{},
+	}
+	for _, span := range page(spans, limit, offset) {
+		response.Spans = append(response.Spans, models_api_v1.ActivitySpan{
+			Start:    span.Start.Format(time.RFC3339),
+			End:      span.End.Format(time.RFC3339),
+			Duration: span.Duration().Seconds(),
+			App:      span.App,
+			Title:    span.Title,
+			Hostname: span.Hostname,
+			Category: strings.Join(span.Category, activitywatch.CategorySeparator),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetHourly обрабатывает GET /api/v1/activitywatch/hourly.
+// Отрезки делятся по границам часов локального времени, часы без активности не возвращаются.
+func (h *ActivityWatchHandler) GetHourly(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := h.parseStatsRequest(w, r)
+	if !ok {
+		return
+	}
+	spans, ok := h.loadSpans(w, r, start, end)
+	if !ok {
+		return
+	}
+
+	loc := timezone.Location()
+	hours := aggregateByPeriod(spans, func(t time.Time) time.Time {
+		// Начало часа отсчитывается от момента, а не собирается через time.Date: при переводе
+		// часов назад time.Date относит повторяющийся час к первому из двух
+		t = t.In(loc)
+		return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	}, func(t time.Time) time.Time {
+		return t.Add(time.Hour)
+	})
+
+	response := make([]models_api_v1.ActivityHourStat, 0, len(hours))
+	for _, hour := range hours {
+		response = append(response, models_api_v1.ActivityHourStat{
+			Hour:          hour.start.Format(time.RFC3339),
+			TotalDuration: hour.total,
+			Apps:          durationList(hour.apps),
+			Categories:    durationList(hour.categories),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetDaily обрабатывает GET /api/v1/activitywatch/daily.
+// Период задаётся start_date и end_date (по умолчанию последние 7 дней), дни без активности
+// возвращаются с нулевым временем.
+func (h *ActivityWatchHandler) GetDaily(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	if endDate.Before(startDate) {
+		http.Error(w, `{"error": "end_date must not be before start_date"}`, http.StatusBadRequest)
+		return
+	}
+	if endDate.Sub(startDate) > maxRangeDays*24*time.Hour {
+		writeBadRequest(w, errRangeTooLong)
+		return
+	}
+	start, end := localRange(startDate, endDate)
+	spans, ok := h.loadSpans(w, r, start, end)
+	if !ok {
+		return
+	}
+
+	loc := timezone.Location()
+	days := aggregateByPeriod(spans, func(t time.Time) time.Time {
+		return timezone.StartOfDay(t, loc)
+	}, func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 1)
+	})
+	byDate := make(map[string]*periodStat, len(days))
+	for _, day := range days {
+		byDate[day.start.Format("2006-01-02")] = day
+	}
+
+	response := make([]models_api_v1.ActivityDayStat, 0)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		stat := models_api_v1.ActivityDayStat{
+			Date:       day.Format("2006-01-02"),
+			Apps:       []models_api_v1.ActivityDuration{},
+			Categories: []models_api_v1.ActivityDuration{},
+		}
+		if p, ok := byDate[stat.Date]; ok {
+			stat.TotalDuration = p.total
+			stat.Apps = durationList(p.apps)
+			stat.Categories = durationList(p.categories)
+		}
+		response = append(response, stat)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTopTitles обрабатывает GET /api/v1/activitywatch/top-titles.
+// Возвращает окна (приложение + заголовок) по убыванию активного времени, постранично.
+func (h *ActivityWatchHandler) GetTopTitles(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := h.parseStatsRequest(w, r)
+	if !ok {
+		return
+	}
+	limit, offset, err := parsePagination(r, 20, 500)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	spans, ok := h.loadSpans(w, r, start, end)
+	if !ok {
+		return
+	}
+
+	type titleKey struct{ app, title string }
+	index := map[titleKey]int{}
+	titles := []models_api_v1.ActivityTitleStat{}
+	for _, span := range spans {
+		key := titleKey{app: span.App, title: span.Title}
+		i, ok := index[key]
+		if !ok {
+			i = len(titles)
+			index[key] = i
+			titles = append(titles, models_api_v1.ActivityTitleStat{
+				App:      span.App,
+				Title:    span.Title,
+				Category: strings.Join(span.Category, activitywatch.CategorySeparator),
+			})
+		}
+		titles[i].Duration += span.Duration().Seconds()
+		titles[i].Spans++
+	}
+	sort.SliceStable(titles, func(i, j int) bool { return titles[i].Duration > titles[j].Duration })
+
+	response := models_api_v1.ActivityTopTitlesResponse{
+		Total:  len(titles),
+		Limit:  limit,
+		Offset: offset,
+		Titles: append([]models_api_v1.ActivityTitleStat{}, page(titles, limit, offset)...),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTopDomains обрабатывает GET /api/v1/activitywatch/top-domains.
+// Возвращает домены вкладок aw-watcher-web по убыванию времени, когда окно браузера было активным.
+// include и exclude - списки доменов через запятую, домен включает свои поддомены.
+func (h *ActivityWatchHandler) GetTopDomains(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := h.parseStatsRequest(w, r)
+	if !ok {
+		return
+	}
+	limit, offset, err := parsePagination(r, 20, 500)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	include := parseDomains(r, "include")
+	exclude := parseDomains(r, "exclude")
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+	categorizer, _, err := h.categorizer(r.Context(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get category rules from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+	spans, err := h.activeWebSpans(r.Context(), userID, start, end, parseDevices(r))
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get activity events from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	filter := parseCategory(r)
+	index := map[string]int{}
+	domains := []models_api_v1.ActivityDomainStat{}
+	for _, span := range spans {
+		if span.Domain == "" ||
+			(len(include) > 0 && !activitywatch.MatchDomain(span.Domain, include)) ||
+			activitywatch.MatchDomain(span.Domain, exclude) ||
+			!hasCategoryPrefix(categorizer.Categorize(span.App, span.Title, span.URL), filter) {
+			continue
+		}
+		i, ok := index[span.Domain]
+		if !ok {
+			i = len(domains)
+			index[span.Domain] = i
+			domains = append(domains, models_api_v1.ActivityDomainStat{Domain: span.Domain})
+		}
+		domains[i].Duration += span.Duration().Seconds()
+		domains[i].Spans++
+	}
+	sort.SliceStable(domains, func(i, j int) bool { return domains[i].Duration > domains[j].Duration })
+
+	response := models_api_v1.ActivityTopDomainsResponse{
+		Total:   len(domains),
+		Limit:   limit,
+		Offset:  offset,
+		Domains: append([]models_api_v1.ActivityDomainStat{}, page(domains, limit, offset)...),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetAppEvents обрабатывает GET /api/v1/activitywatch/apps/{app}/events.
+// Возвращает события приложения как их прислал aw-server, от новых к старым, постранично.
+func (h *ActivityWatchHandler) GetAppEvents(w http.ResponseWriter, r *http.Request) {
+	start, end, ok := h.parseStatsRequest(w, r)
+	if !ok {
+		return
+	}
+	limit, offset, err := parsePagination(r, 100, 1000)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	app := r.PathValue("app")
+	if app == "" {
+		http.Error(w, `{"error": "app is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		writeUserIDError(w, err)
+		return
+	}
+	// Без фильтра по категории страница и общее число событий считаются в БД,
+	// с фильтром категорию каждого события приходится вычислять по всем событиям периода
+	filter := parseCategory(r)
+	params := activitywatch_db.GetEventsByAppParams{
+		UserID:    userID,
+		App:       app,
+		StartTime: pgtype.Timestamptz{Time: start, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: end, Valid: true},
+		Hostnames: parseDevices(r),
+	}
+	if len(filter) == 0 {
+		params.RowLimit = pgtype.Int4{Int32: int32(limit), Valid: true}
+		params.RowOffset = int32(offset)
+	}
+	rows, err := h.store.ActivityWatch.GetEventsByApp(r.Context(), params)
+	if err != nil {
+		h.logger.Error().Err(err).Str("app", app).Msg("Failed to get app events from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+	categorizer, _, err := h.categorizer(r.Context(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get category rules from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	events := []models_api_v1.ActivityAppEvent{}
+	for _, row := range rows {
+		category := categorizer.Categorize(app, row.Title.String, row.Url.String)
+		if !hasCategoryPrefix(category, filter) {
+			continue
+		}
+		events = append(events, models_api_v1.ActivityAppEvent{
+			ID:        row.ID,
+			Timestamp: row.Timestamp.Time.Format(time.RFC3339),
+			Duration:  row.Duration,
+			Title:     row.Title.String,
+			BucketID:  row.BucketID,
+			Hostname:  row.Hostname.String,
+			Category:  strings.Join(category, activitywatch.CategorySeparator),
+		})
+	}
+
+	response := models_api_v1.ActivityAppEventsResponse{
+		Total:  len(events),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if len(filter) == 0 {
+		total, err := h.store.ActivityWatch.CountEventsByApp(r.Context(), activitywatch_db.CountEventsByAppParams{
+			UserID:    params.UserID,
+			App:       params.App,
+			StartTime: params.StartTime,
+			EndTime:   params.EndTime,
+			Hostnames: params.Hostnames,
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Str("app", app).Msg("Failed to count app events in DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		response.Total = int(total)
+		response.Events = events
+	} else {
+		response.Events = append([]models_api_v1.ActivityAppEvent{}, page(events, limit, offset)...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseStatsRequest проверяет метод и разбирает период запроса статистики
+func (h *ActivityWatchHandler) parseStatsRequest(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return time.Time{}, time.Time{}, false
+	}
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return time.Time{}, time.Time{}, false
+	}
+	if !end.After(start) {
+		writeBadRequest(w, fmt.Errorf("end must be after start"))
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// loadSpans загружает отрезки активного времени периода с категориями,
+// учитывая фильтры device и category запроса
+func (h *ActivityWatchHandler) loadSpans(w http.ResponseWriter, r *http.Request, start, end time.Time) ([]categorizedSpan, bool) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return nil, false
+	}
+
+	categorizer, _, err := h.categorizer(r.Context(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get category rules from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+	spans, err := activeSpans(r.Context(), h.store, userID, start, end, parseDevices(r))
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get activity events from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	filter := parseCategory(r)
+	result := make([]categorizedSpan, 0, len(spans))
+	for _, span := range spans {
+		category := categorizer.Categorize(span.App, span.Title, span.URL)
+		if hasCategoryPrefix(category, filter) {
+			result = append(result, categorizedSpan{deviceSpan: span, Category: category})
+		}
+	}
+	return result, true
+}
+
+// parseCategory разбирает category вида "Work > Programming": подходят категория и её подкатегории
+func parseCategory(r *http.Request) []string {
+	val := r.URL.Query().Get("category")
+	if strings.TrimSpace(val) == "" {
+		return nil
+	}
+	var path []string
+	for _, name := range strings.Split(val, ">") {
+		path = append(path, strings.TrimSpace(name))
+	}
+	return path
+}
+
+func hasCategoryPrefix(category, prefix []string) bool {
+	if len(prefix) > len(category) {
+		return false
+	}
+	for i := range prefix {
+		if !strings.EqualFold(category[i], prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDomains разбирает список доменов через запятую из параметра name
+func parseDomains(r *http.Request, name string) []string {
+	var domains []string
+	for _, domain := range strings.Split(r.URL.Query().Get(name), ",") {
+		if domain = activitywatch.NormalizeDomainName(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// parsePagination разбирает limit и offset
+func parsePagination(r *http.Request, defLimit, maxLimit int) (int, int, error) {
+	limit, offset := defLimit, 0
+	if val := r.URL.Query().Get("limit"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 || n > maxLimit {
+			return 0, 0, fmt.Errorf("invalid limit. Use a number between 1 and %d", maxLimit)
+		}
+		limit = n
+	}
+	if val := r.URL.Query().Get("offset"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid offset")
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
+
+// page возвращает страницу items
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	return items[offset:min(offset+limit, len(items))]
+}
+
+// periodStat активное время за час или день
+type periodStat struct {
+	start      time.Time
+	total      float64
+	apps       map[string]float64
+	categories map[string]float64
+}
+
+// aggregateByPeriod делит отрезки по границам периодов и суммирует время в каждом.
+// floor возвращает начало периода, в который попадает момент, next - начало следующего периода.
+// Периоды возвращаются по порядку
+func aggregateByPeriod(spans []categorizedSpan, floor, next func(time.Time) time.Time) []*periodStat {
+	periods := map[time.Time]*periodStat{}
+	for _, span := range spans {
+		for from := span.Start; from.Before(span.End); {
+			periodStart := floor(from)
+			to := next(periodStart)
+			if to.After(span.End) || !to.After(from) {
+				// Период, который не продвигает отрезок, зациклил бы запрос: остаток отрезка
+				// относится к текущему периоду
+				to = span.End
+			}
+
+			p, ok := periods[periodStart]
+			if !ok {
+				p = &periodStat{start: periodStart, apps: map[string]float64{}, categories: map[string]float64{}}
+				periods[periodStart] = p
+			}
+			seconds := to.Sub(from).Seconds()
+			p.total += seconds
+			p.apps[span.App] += seconds
+			p.categories[strings.Join(span.Category, activitywatch.CategorySeparator)] += seconds
+
+			from = to
+		}
+	}
+
+	result := make([]*periodStat, 0, len(periods))
+	for _, p := range periods {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].start.Before(result[j].start) })
+	return result
+}
+
+// durationList сортирует время по убыванию
+func durationList(durations map[string]float64) []models_api_v1.ActivityDuration {
+	list := make([]models_api_v1.ActivityDuration, 0, len(durations))
+	for name, duration := range durations {
+		list = append(list, models_api_v1.ActivityDuration{Name: name, Duration: duration})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Duration != list[j].Duration {
+			return list[i].Duration > list[j].Duration
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
@@ -0,0 +1,469 @@
+package handlers_api_v1
+
+import (
+	"DataLake/activitywatch"
+	models_api_v1 "DataLake/api/v1/models"
+	internal_db "DataLake/internal/db"
+	googlecalendar_db "DataLake/internal/db/googlecalendar"
+	wakatime_db "DataLake/internal/db/wakatime"
+	"DataLake/internal/ical"
+	"DataLake/internal/timezone"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// Окно экспорта по умолчанию: календарные приложения обновляют подписку целиком
+	exportDaysBack  = 30
+	exportDaysAhead = 90
+	exportMaxDays   = 366
+
+	// Сессия ActivityWatch: события редакторов с перерывами не длиннее sessionMaxGap,
+	// короче sessionMinLength не экспортируются
+	sessionMaxGap    = 5 * time.Minute
+	sessionMinLength = 15 * time.Minute
+)
+
+// Приложения, время в которых считается программированием, если не задан CODING_APPS
+var defaultCodingApps = []string{
+	"code", "cursor", "goland", "idea", "pycharm", "webstorm", "clion", "rider",
+	"phpstorm", "rubymine", "datagrip", "android studio", "xcode", "zed",
+	"sublime_text", "nvim", "vim", "emacs",
+}
+
+type CalendarExportHandler struct {
+	store  *internal_db.Store
+	logger *zerolog.Logger
+}
+
+func NewCalendarExportHandler(store *internal_db.Store, logger *zerolog.Logger) *CalendarExportHandler {
+	return &CalendarExportHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Feeds обрабатывает GET, POST и DELETE /api/v1/calendar/feeds.
+// POST создаёт подписку и единственный раз возвращает её секретный токен, DELETE?id= отзывает её.
+func (h *CalendarExportHandler) Feeds(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		dbResult, err := h.store.GoogleCalendar.ListFeedTokens(r.Context(), userID)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get calendar feeds from DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]models_api_v1.CalendarFeed, 0, len(dbResult))
+		for _, row := range dbResult {
+			response = append(response, calendarFeedFromRow(row))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodPost:
+		var req models_api_v1.CalendarFeedRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			http.Error(w, `{"error": "Invalid JSON format"}`, http.StatusBadRequest)
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			http.Error(w, `{"error": "name is required"}`, http.StatusBadRequest)
+			return
+		}
+		includeEvents := req.IncludeEvents == nil || *req.IncludeEvents
+		if !includeEvents && !req.IncludeWakatime && !req.IncludeActivityWatch {
+			http.Error(w, `{"error": "Feed must include events, wakatime or activitywatch"}`, http.StatusBadRequest)
+			return
+		}
+
+		token, err := newFeedToken()
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to generate feed token")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		row, err := h.store.GoogleCalendar.CreateFeedToken(r.Context(), googlecalendar_db.CreateFeedTokenParams{
+			UserID:               userID,
+			Name:                 req.Name,
+			TokenHash:            hashFeedToken(token),
+			IncludeEvents:        includeEvents,
+			IncludeWakatime:      req.IncludeWakatime,
+			IncludeActivitywatch: req.IncludeActivityWatch,
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to create calendar feed")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		response := calendarFeedFromRow(row)
+		response.Token = token
+		response.URL = "/api/v1/calendar.ics?token=" + token
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 32)
+		if err != nil {
+			http.Error(w, `{"error": "Invalid id"}`, http.StatusBadRequest)
+			return
+		}
+
+		deleted, err := h.store.GoogleCalendar.DeleteFeedToken(r.Context(), googlecalendar_db.DeleteFeedTokenParams{
+			UserID: userID,
+			ID:     int32(id),
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to delete calendar feed")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			http.Error(w, `{"error": "Feed not found"}`, http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+	}
+}
+
+// GetICS обрабатывает GET /api/v1/calendar.ics?token=.
+// Авторизация по секретному токену подписки, а не X-API-Key: календарные приложения
+// не умеют передавать заголовки. Без start_date и end_date отдаёт 30 дней назад и 90 вперёд.
+func (h *CalendarExportHandler) GetICS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	feed, err := h.store.GoogleCalendar.GetFeedTokenByHash(r.Context(), hashFeedToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get calendar feed from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	loc := timezone.Location()
+	today := timezone.Date(time.Now(), loc)
+	startDate, endDate := today.AddDate(0, 0, -exportDaysBack), today.AddDate(0, 0, exportDaysAhead)
+	if r.URL.Query().Get("start_date") != "" || r.URL.Query().Get("end_date") != "" {
+		if startDate, endDate, err = parseDateRange(r); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+	}
+	if endDate.Before(startDate) || endDate.Sub(startDate) > exportMaxDays*24*time.Hour {
+		http.Error(w, `{"error": "Invalid date range"}`, http.StatusBadRequest)
+		return
+	}
+	from, to := localRange(startDate, endDate)
+
+	cal := &ical.Calendar{Name: "DataLake: " + feed.Name, TimeZone: loc.String()}
+
+	if feed.IncludeEvents {
+		events, err := h.calendarEvents(r, feed.UserID, from, to)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get google calendar events from DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		cal.Events = append(cal.Events, events...)
+	}
+	if feed.IncludeWakatime {
+		events, err := h.wakatimeEvents(r, feed.UserID, startDate, endDate)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get wakatime projects from DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		cal.Events = append(cal.Events, events...)
+	}
+	if feed.IncludeActivitywatch {
+		events, err := h.activityWatchEvents(r, feed.UserID, from, to)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get activity events from DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		cal.Events = append(cal.Events, events...)
+	}
+
+	if err := h.store.GoogleCalendar.TouchFeedToken(r.Context(), feed.ID); err != nil {
+		h.logger.Warn().Err(err).Int32("feed_id", feed.ID).Msg("Failed to update feed last_used_at")
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := ical.Encode(w, cal, "-//DataLake//Calendar Export//EN", time.Now()); err != nil {
+		h.logger.Error().Err(err).Msg("Failed to write calendar feed")
+	}
+}
+
+// calendarEvents события включённых календарей, пересекающие [from, to)
+func (h *CalendarExportHandler) calendarEvents(r *http.Request, userID pgtype.UUID, from, to time.Time) ([]ical.Event, error) {
+	rows, err := h.store.GoogleCalendar.ListExportEventsByRange(r.Context(), googlecalendar_db.ListExportEventsByRangeParams{
+		UserID:    userID,
+		StartTime: pgtype.Timestamptz{Time: from, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]ical.Event, 0, len(rows))
+	for _, row := range rows {
+		event := ical.Event{
+			UID:          row.EventID + "@datalake",
+			Summary:      row.Summary.String,
+			Description:  row.Description.String,
+			Location:     row.Location.String,
+			Status:       row.Status.String,
+			Transparency: row.Transparency.String,
+			Start:        row.StartTime.Time,
+			End:          row.EndTime.Time,
+			AllDay:       row.IsAllDay.Bool,
+		}
+		// События на весь день хранятся полночью UTC
+		if event.AllDay {
+			event.Start, event.End = event.Start.UTC(), event.End.UTC()
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// wakatimeEvents WakaTime хранит только суммы за день, поэтому каждый день с активностью
+// экспортируется событием на весь день с разбивкой по проектам в описании
+func (h *CalendarExportHandler) wakatimeEvents(r *http.Request, userID pgtype.UUID, startDate, endDate time.Time) ([]ical.Event, error) {
+	rows, err := h.store.WakaTime.GetProjectsByDateRange(r.Context(), wakatime_db.GetProjectsByDateRangeParams{
+		UserID: userID,
+		Date:   pgtype.Date{Time: startDate, Valid: true},
+		Date_2: pgtype.Date{Time: endDate, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []ical.Event
+	for _, row := range rows {
+		date := row.Date.Time
+		if n := len(events); n == 0 || !events[n-1].Start.Equal(date) {
+			if row.DaySeconds <= 0 {
+				continue
+			}
+			events = append(events, ical.Event{
+				UID:          "wakatime-" + date.Format("20060102") + "@datalake",
+				Summary:      "Coding " + formatSeconds(row.DaySeconds),
+				Transparency: "transparent",
+				Categories:   []string{"WakaTime"},
+				Start:        date,
+				End:          date.AddDate(0, 0, 1),
+				AllDay:       true,
+			})
+		}
+
+		event := &events[len(events)-1]
+		if event.Description != "" {
+			event.Description += "\n"
+		}
+		event.Description += row.Name + ": " + formatSeconds(row.TotalSeconds)
+	}
+	return events, nil
+}
+
+// activityWatchEvents сессии работы в редакторах по активному времени ActivityWatch всех устройств:
+// без AFK и без событий watcher-ов редакторов, которые повторяют время окна редактора
+func (h *CalendarExportHandler) activityWatchEvents(r *http.Request, userID pgtype.UUID, from, to time.Time) ([]ical.Event, error) {
+	deviceSpans, err := activeSpans(r.Context(), h.store, userID, from, to, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	spans := make([]activitywatch.Span, 0, len(deviceSpans))
+	for _, span := range deviceSpans {
+		spans = append(spans, span.Span)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+
+	sessions := codingSessions(spans, codingAppsFromEnv(), sessionMaxGap, sessionMinLength)
+	events := make([]ical.Event, 0, len(sessions))
+	for _, s := range sessions {
+		description := make([]string, 0, len(s.apps))
+		for _, app := range s.topApps() {
+			description = append(description, app+": "+formatSeconds(s.apps[app]))
+		}
+		events = append(events, ical.Event{
+			UID:          fmt.Sprintf("activitywatch-%d@datalake", s.start.Unix()),
+			Summary:      "Coding session",
+			Description:  strings.Join(description, "\n"),
+			Transparency: "transparent",
+			Categories:   []string{"ActivityWatch"},
+			Start:        s.start,
+			End:          s.end,
+		})
+	}
+	return events, nil
+}
+
+// codingSession непрерывная работа в редакторах, apps - секунды по приложениям
+type codingSession struct {
+	start time.Time
+	end   time.Time
+	apps  map[string]float64
+}
+
+func (s codingSession) topApps() []string {
+	apps := make([]string, 0, len(s.apps))
+	for app := range s.apps {
+		apps = append(apps, app)
+	}
+	sort.Slice(apps, func(i, j int) bool { return s.apps[apps[i]] > s.apps[apps[j]] })
+	return apps
+}
+
+// codingSessions склеивает отрезки приложений из codingApps (отсортированные по времени)
+// с перерывами не длиннее maxGap и отбрасывает сессии короче minLength
+func codingSessions(spans []activitywatch.Span, codingApps []string, maxGap, minLength time.Duration) []codingSession {
+	var sessions []codingSession
+	var current *codingSession
+
+	flush := func() {
+		if current != nil && current.end.Sub(current.start) >= minLength {
+			sessions = append(sessions, *current)
+		}
+		current = nil
+	}
+
+	for _, span := range spans {
+		if span.Duration() <= 0 || !isCodingApp(span.App, codingApps) {
+			continue
+		}
+		start, end := span.Start, span.End
+
+		if current != nil && start.Sub(current.end) > maxGap {
+			flush()
+		}
+		if current == nil {
+			current = &codingSession{start: start, end: end, apps: map[string]float64{}}
+		}
+		if end.After(current.end) {
+			current.end = end
+		}
+		current.apps[span.App] += span.Duration().Seconds()
+	}
+	flush()
+
+	return sessions
+}
+
+// codingAppsFromEnv читает CODING_APPS (через запятую, без учёта регистра)
+func codingAppsFromEnv() []string {
+	val := os.Getenv("CODING_APPS")
+	if val == "" {
+		return defaultCodingApps
+	}
+
+	var apps []string
+	for _, app := range strings.Split(val, ",") {
+		if app = strings.ToLower(strings.TrimSpace(app)); app != "" {
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// isCodingApp сравнивает имя приложения без расширения .exe и суффикса 64 ("goland64.exe")
+func isCodingApp(app string, codingApps []string) bool {
+	name := strings.TrimSuffix(strings.ToLower(app), ".exe")
+	name = strings.TrimSuffix(name, "64")
+	for _, codingApp := range codingApps {
+		if name == codingApp || strings.HasPrefix(name, codingApp+"-") || strings.HasSuffix(name, "-"+codingApp) {
+			return true
+		}
+	}
+	return false
+}
+
+// newFeedToken генерирует секретный токен подписки
+func newFeedToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatSeconds форматирует длительность как "2h 15m"
+func formatSeconds(seconds float64) string {
+	minutes := int(seconds / 60)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+}
+
+func calendarFeedFromRow(row googlecalendar_db.CalendarFeedToken) models_api_v1.CalendarFeed {
+	feed := models_api_v1.CalendarFeed{
+		ID:                   row.ID,
+		Name:                 row.Name,
+		IncludeEvents:        row.IncludeEvents,
+		IncludeWakatime:      row.IncludeWakatime,
+		IncludeActivityWatch: row.IncludeActivitywatch,
+		CreatedAt:            row.CreatedAt.Time.Format(time.RFC3339),
+	}
+	if row.LastUsedAt.Valid {
+		feed.LastUsedAt = row.LastUsedAt.Time.Format(time.RFC3339)
+	}
+	return feed
+}
@@ -2,13 +2,16 @@ package handlers_api_v1
 
 import (
 	models_api_v1 "DataLake/api/v1/models"
+	"DataLake/googlecalendar"
 	internal_db "DataLake/internal/db"
 	googlecalendar_db "DataLake/internal/db/googlecalendar"
 	"DataLake/internal/middleware"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog"
 	uuid "github.com/satori/go.uuid"
@@ -48,6 +51,15 @@ func (h *GoogleCalendarHandler) GetEvents(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	// По умолчанию отменённые события не возвращаются
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", "all", googlecalendar.EventStatusConfirmed, googlecalendar.EventStatusTentative, googlecalendar.EventStatusCancelled:
+	default:
+		http.Error(w, `{"error": "Invalid status. Use confirmed, tentative, cancelled or all"}`, http.StatusBadRequest)
+		return
+	}
+
 	userIDStr, ok := middleware.GetUserID(r.Context())
 	if !ok || userIDStr == "" {
 		h.logger.Error().Msg("Failed to get user ID from context")
@@ -66,9 +78,10 @@ func (h *GoogleCalendarHandler) GetEvents(w http.ResponseWriter, r *http.Request
 	copy(userIDBytes[:], userID.Bytes())
 
 	dbResult, err := h.store.GoogleCalendar.GetCalendarEventsByDateRange(r.Context(), googlecalendar_db.GetCalendarEventsByDateRangeParams{
-		UserID:      pgtype.UUID{Bytes: userIDBytes, Valid: true},
-		StartTime:   pgtype.Timestamptz{Time: startDate, Valid: true},
-		StartTime_2: pgtype.Timestamptz{Time: endDate, Valid: true},
+		UserID:    pgtype.UUID{Bytes: userIDBytes, Valid: true},
+		StartTime: pgtype.Timestamptz{Time: startDate, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: endDate, Valid: true},
+		Status:    status,
 	})
 
 	if err != nil {
@@ -85,6 +98,16 @@ func (h *GoogleCalendarHandler) GetEvents(w http.ResponseWriter, r *http.Request
 			Description: row.Description.String,
 			StartTime:   row.StartTime.Time.Format(time.RFC3339),
 			EndTime:     row.EndTime.Time.Format(time.RFC3339),
+			Status:      row.Status.String,
+			CalendarID:  row.CalendarID,
+
+			IsAllDay:         row.IsAllDay.Bool,
+			Organizer:        row.OrganizerEmail.String,
+			RecurringEventID: row.RecurringEventID.String,
+			Transparency:     row.Transparency.String,
+			EventType:        row.EventType.String,
+			ResponseStatus:   row.ResponseStatus.String,
+			AttendeesCount:   int(row.AttendeesCount),
 		})
 	}
 
@@ -92,3 +115,88 @@ func (h *GoogleCalendarHandler) GetEvents(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// Calendars обрабатывает GET и PATCH /api/v1/googlecalendar/calendars.
+// GET возвращает календари пользователя, PATCH меняет sync_enabled и included для календаря.
+func (h *GoogleCalendarHandler) Calendars(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		dbResult, err := h.store.GoogleCalendar.ListCalendars(r.Context(), userID)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get google calendars from DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]models_api_v1.CalendarInfo, 0, len(dbResult))
+		for _, row := range dbResult {
+			response = append(response, calendarInfoFromRow(row))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodPatch:
+		var req models_api_v1.CalendarSettingsRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			http.Error(w, `{"error": "Invalid JSON format"}`, http.StatusBadRequest)
+			return
+		}
+		if req.CalendarID == "" {
+			http.Error(w, `{"error": "calendar_id is required"}`, http.StatusBadRequest)
+			return
+		}
+
+		params := googlecalendar_db.UpdateCalendarSettingsParams{
+			UserID:     userID,
+			CalendarID: req.CalendarID,
+		}
+		if req.SyncEnabled != nil {
+			params.SyncEnabled = pgtype.Bool{Bool: *req.SyncEnabled, Valid: true}
+		}
+		if req.Included != nil {
+			params.Included = pgtype.Bool{Bool: *req.Included, Valid: true}
+		}
+
+		row, err := h.store.GoogleCalendar.UpdateCalendarSettings(r.Context(), params)
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, `{"error": "Calendar not found"}`, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to update google calendar settings")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(calendarInfoFromRow(row))
+
+	default:
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+	}
+}
+
+func calendarInfoFromRow(row googlecalendar_db.GooglecalendarCalendar) models_api_v1.CalendarInfo {
+	return models_api_v1.CalendarInfo{
+		CalendarID:      row.CalendarID,
+		Summary:         row.Summary.String,
+		Description:     row.Description.String,
+		TimeZone:        row.TimeZone.String,
+		BackgroundColor: row.BackgroundColor.String,
+		ForegroundColor: row.ForegroundColor.String,
+		AccessRole:      row.AccessRole.String,
+		Primary:         row.IsPrimary,
+		SyncEnabled:     row.SyncEnabled,
+		Included:        row.Included,
+	}
+}
@@ -0,0 +1,378 @@
+package handlers_api_v1
+
+import (
+	models_api_v1 "DataLake/api/v1/models"
+	"DataLake/googlecalendar"
+	googlecalendar_db "DataLake/internal/db/googlecalendar"
+	"DataLake/internal/timezone"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// GetMeetingLoad обрабатывает GET /api/v1/googlecalendar/analytics/meeting-load.
+// Возвращает часы встреч по дням и по неделям; пересекающиеся встречи не суммируются.
+func (h *GoogleCalendarHandler) GetMeetingLoad(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseAnalyticsRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	meetings, ok := h.loadMeetings(w, r, startDate, endDate)
+	if !ok {
+		return
+	}
+
+	loc := timezone.Location()
+	days := googlecalendar.DailyLoad(meetings, startDate, endDate, loc, googlecalendar.DefaultWorkingHours, 0)
+
+	response := models_api_v1.CalendarMeetingLoadResponse{
+		Daily:  make([]models_api_v1.CalendarDayLoad, 0, len(days)),
+		Weekly: make([]models_api_v1.CalendarWeekLoad, 0),
+	}
+	for _, day := range days {
+		response.Daily = append(response.Daily, models_api_v1.CalendarDayLoad{
+			Date:         day.Date.Format("2006-01-02"),
+			Meetings:     day.Meetings,
+			MeetingHours: day.MeetingMinutes / 60,
+		})
+
+		weekStart := day.Date.AddDate(0, 0, -(int(day.Date.Weekday())+6)%7).Format("2006-01-02")
+		if n := len(response.Weekly); n == 0 || response.Weekly[n-1].WeekStart != weekStart {
+			response.Weekly = append(response.Weekly, models_api_v1.CalendarWeekLoad{WeekStart: weekStart})
+		}
+		week := &response.Weekly[len(response.Weekly)-1]
+		week.Meetings += day.Meetings
+		week.MeetingHours += day.MeetingMinutes / 60
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetFocusTime обрабатывает GET /api/v1/googlecalendar/analytics/focus.
+// Для рабочих дней возвращает самый длинный свободный блок, число блоков фокуса
+// и fragmentation - долю свободного времени в отрезках короче min_focus.
+func (h *GoogleCalendarHandler) GetFocusTime(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseAnalyticsRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	workingHours, err := parseWorkingHours(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	minFocus, err := parseMinutesParam(r, "min_focus", 60)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	meetings, ok := h.loadMeetings(w, r, startDate, endDate)
+	if !ok {
+		return
+	}
+
+	days := googlecalendar.DailyLoad(meetings, startDate, endDate, timezone.Location(), workingHours, minFocus)
+
+	response := make([]models_api_v1.CalendarFocusDay, 0, len(days))
+	for _, day := range days {
+		if !day.Workday {
+			continue
+		}
+		response = append(response, models_api_v1.CalendarFocusDay{
+			Date:                day.Date.Format("2006-01-02"),
+			MeetingMinutes:      day.MeetingMinutes,
+			FreeMinutes:         day.FreeMinutes,
+			LongestFocusMinutes: day.LongestFocusMinutes,
+			FocusBlocks:         day.FocusBlocks,
+			Fragmentation:       day.Fragmentation,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetAfterHours обрабатывает GET /api/v1/googlecalendar/analytics/after-hours.
+// Возвращает встречи, выходящие за рабочие часы, и время вне них.
+func (h *GoogleCalendarHandler) GetAfterHours(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseAnalyticsRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	workingHours, err := parseWorkingHours(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	meetings, ok := h.loadMeetings(w, r, startDate, endDate)
+	if !ok {
+		return
+	}
+
+	loc := timezone.Location()
+	afterHours := googlecalendar.AfterHours(meetings, loc, workingHours)
+
+	response := make([]models_api_v1.CalendarAfterHoursMeeting, 0, len(afterHours))
+	for _, m := range afterHours {
+		response = append(response, models_api_v1.CalendarAfterHoursMeeting{
+			EventID:           m.EventID,
+			Summary:           m.Summary,
+			StartTime:         m.Start.In(loc).Format(time.RFC3339),
+			EndTime:           m.End.In(loc).Format(time.RFC3339),
+			AfterHoursMinutes: m.Minutes,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetBackToBack обрабатывает GET /api/v1/googlecalendar/analytics/back-to-back.
+// Возвращает серии из двух и более встреч с перерывами не длиннее max_gap минут.
+func (h *GoogleCalendarHandler) GetBackToBack(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseAnalyticsRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	maxGap, err := parseMinutesParam(r, "max_gap", 5)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	meetings, ok := h.loadMeetings(w, r, startDate, endDate)
+	if !ok {
+		return
+	}
+
+	loc := timezone.Location()
+	streaks := googlecalendar.BackToBack(meetings, maxGap)
+
+	response := make([]models_api_v1.CalendarStreak, 0, len(streaks))
+	for _, s := range streaks {
+		summaries := make([]string, 0, len(s.Meetings))
+		for _, m := range s.Meetings {
+			summaries = append(summaries, m.Summary)
+		}
+		response = append(response, models_api_v1.CalendarStreak{
+			Date:      s.Start.In(loc).Format("2006-01-02"),
+			StartTime: s.Start.In(loc).Format(time.RFC3339),
+			EndTime:   s.End.In(loc).Format(time.RFC3339),
+			Meetings:  len(s.Meetings),
+			Minutes:   s.End.Sub(s.Start).Minutes(),
+			Summaries: summaries,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetAnalyticsSummary обрабатывает GET /api/v1/googlecalendar/analytics/summary.
+// Возвращает сводку по дням и календарям за период и самые загруженные дни за последние 30 дней.
+func (h *GoogleCalendarHandler) GetAnalyticsSummary(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseAnalyticsRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	limit := 5
+	if val := r.URL.Query().Get("limit"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 || n > 30 {
+			http.Error(w, `{"error": "Invalid limit. Use a number between 1 and 30"}`, http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	rangeStart, rangeEnd := localRange(startDate, endDate)
+	ctx := r.Context()
+
+	average, err := h.store.GoogleCalendar.GetAverageDailyEvents(ctx, googlecalendar_db.GetAverageDailyEventsParams{
+		UserID:    userID,
+		StartTime: pgtype.Timestamptz{Time: rangeStart, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: rangeEnd, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get average daily google calendar events")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	daily, err := h.store.GoogleCalendar.GetDailyEventsSummary(ctx, googlecalendar_db.GetDailyEventsSummaryParams{
+		UserID:    userID,
+		StartTime: pgtype.Timestamptz{Time: rangeStart, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: rangeEnd, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get daily google calendar summary")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	byCalendar, err := h.store.GoogleCalendar.GetEventsByCalendarSummary(ctx, googlecalendar_db.GetEventsByCalendarSummaryParams{
+		UserID:    userID,
+		StartTime: pgtype.Timestamptz{Time: rangeStart, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: rangeEnd, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get google calendar summary by calendar")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	busiest, err := h.store.GoogleCalendar.GetBusiestDays(ctx, googlecalendar_db.GetBusiestDaysParams{
+		UserID: userID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get busiest google calendar days")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	response := models_api_v1.CalendarAnalyticsSummary{
+		AvgEventsPerDay:         average.AvgEventsPerDay,
+		AvgMeetingMinutesPerDay: average.AvgDurationPerDay,
+		Daily:                   make([]models_api_v1.CalendarDailySummary, 0, len(daily)),
+		ByCalendar:              make([]models_api_v1.CalendarSummaryByCalendar, 0, len(byCalendar)),
+		BusiestDays:             make([]models_api_v1.CalendarDailySummary, 0, len(busiest)),
+	}
+	for _, row := range daily {
+		response.Daily = append(response.Daily, models_api_v1.CalendarDailySummary{
+			Date:           row.EventDate.Time.Format("2006-01-02"),
+			Events:         row.TotalEvents,
+			MeetingMinutes: row.TotalDurationMinutes,
+			AllDayEvents:   row.AllDayEvents,
+		})
+	}
+	for _, row := range byCalendar {
+		response.ByCalendar = append(response.ByCalendar, models_api_v1.CalendarSummaryByCalendar{
+			CalendarID:     row.CalendarID,
+			Events:         row.TotalEvents,
+			MeetingMinutes: row.TotalDurationMinutes,
+		})
+	}
+	for _, row := range busiest {
+		response.BusiestDays = append(response.BusiestDays, models_api_v1.CalendarDailySummary{
+			Date:           row.EventDate.Time.Format("2006-01-02"),
+			Events:         row.EventCount,
+			MeetingMinutes: row.TotalDurationMinutes,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseAnalyticsRange разбирает период аналитики, не длиннее maxRangeDays
+func parseAnalyticsRange(r *http.Request) (time.Time, time.Time, error) {
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if endDate.Sub(startDate) > maxRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, errRangeTooLong
+	}
+	return startDate, endDate, nil
+}
+
+// loadMeetings загружает занятые встречи за период. При ошибке сам пишет ответ и возвращает false
+func (h *GoogleCalendarHandler) loadMeetings(w http.ResponseWriter, r *http.Request, startDate, endDate time.Time) ([]googlecalendar.Meeting, bool) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return nil, false
+	}
+
+	rangeStart, rangeEnd := localRange(startDate, endDate)
+	rows, err := h.store.GoogleCalendar.ListBusyEventsByRange(r.Context(), googlecalendar_db.ListBusyEventsByRangeParams{
+		UserID:    userID,
+		StartTime: pgtype.Timestamptz{Time: rangeStart, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: rangeEnd, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get busy google calendar events from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	meetings := make([]googlecalendar.Meeting, 0, len(rows))
+	for _, row := range rows {
+		meetings = append(meetings, googlecalendar.Meeting{
+			EventID: row.EventID,
+			Summary: row.Summary.String,
+			Start:   row.StartTime.Time,
+			End:     row.EndTime.Time,
+		})
+	}
+	return meetings, true
+}
+
+// parseWorkingHours разбирает work_start и work_end (HH:MM), по умолчанию 09:00-18:00
+func parseWorkingHours(r *http.Request) (googlecalendar.WorkingHours, error) {
+	wh := googlecalendar.DefaultWorkingHours
+
+	for _, p := range []struct {
+		name   string
+		target *time.Duration
+	}{
+		{"work_start", &wh.Start},
+		{"work_end", &wh.End},
+	} {
+		val := r.URL.Query().Get(p.name)
+		if val == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", val)
+		if err != nil {
+			return wh, fmt.Errorf("invalid %s format. Use HH:MM", p.name)
+		}
+		*p.target = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+
+	if wh.End <= wh.Start {
+		return wh, fmt.Errorf("work_end must be after work_start")
+	}
+	return wh, nil
+}
+
+// parseMinutesParam разбирает неотрицательное число минут из query-параметра
+func parseMinutesParam(r *http.Request, name string, def int) (time.Duration, error) {
+	minutes := def
+	if val := r.URL.Query().Get(name); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 || n > 24*60 {
+			return 0, fmt.Errorf("invalid %s. Use a number of minutes between 0 and 1440", name)
+		}
+		minutes = n
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
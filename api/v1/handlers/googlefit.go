@@ -2,13 +2,18 @@ package handlers_api_v1
 
 import (
 	models_api_v1 "DataLake/api/v1/models"
+	"DataLake/googlefit"
 	internal_db "DataLake/internal/db"
 	googlefit_db "DataLake/internal/db/googlefit"
 	"DataLake/internal/middleware"
+	"DataLake/internal/timezone"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog"
 	uuid "github.com/satori/go.uuid"
@@ -29,7 +34,7 @@ func NewGoogleFitHandler(store *internal_db.Store, logger *zerolog.Logger) *Goog
 // GetStats обрабатывает GET /api/v1/googlefit/stats.
 // Возвращает ежедневную статистику (количество шагов, расстояние пройденное) за указанный диапазон дат.
 func (h *GoogleFitHandler) GetStats(w http.ResponseWriter, r *http.Request) {
-	endDate := time.Now()
+	endDate := timezone.Date(time.Now(), timezone.Location())
 	startDate := endDate.AddDate(0, 0, -6)
 
 	if val := r.URL.Query().Get("start_date"); val != "" {
@@ -89,3 +94,461 @@ func (h *GoogleFitHandler) GetStats(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// GetHeartRate обрабатывает GET /api/v1/googlefit/heart-rate.
+// Возвращает минимальный, средний и максимальный пульс по дням.
+func (h *GoogleFitHandler) GetHeartRate(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	dbResult, err := h.store.GoogleFit.ListDailyHeartRateByDateRange(r.Context(), googlefit_db.ListDailyHeartRateByDateRangeParams{
+		UserID: userID,
+		Date:   pgtype.Date{Time: startDate, Valid: true},
+		Date_2: pgtype.Date{Time: endDate, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get google fit heart rate from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]models_api_v1.DailyHeartRate, 0, len(dbResult))
+	for _, row := range dbResult {
+		response = append(response, models_api_v1.DailyHeartRate{
+			Date:   row.Date.Time.Format("2006-01-02"),
+			MinBpm: row.MinBpm,
+			AvgBpm: row.AvgBpm,
+			MaxBpm: row.MaxBpm,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetActivity обрабатывает GET /api/v1/googlefit/activity.
+// Возвращает калории, минуты активности и баллы кардиотренировок по дням.
+func (h *GoogleFitHandler) GetActivity(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	dbResult, err := h.store.GoogleFit.ListDailyActivityByDateRange(r.Context(), googlefit_db.ListDailyActivityByDateRangeParams{
+		UserID: userID,
+		Date:   pgtype.Date{Time: startDate, Valid: true},
+		Date_2: pgtype.Date{Time: endDate, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get google fit activity from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]models_api_v1.DailyFitActivity, 0, len(dbResult))
+	for _, row := range dbResult {
+		response = append(response, models_api_v1.DailyFitActivity{
+			Date:        row.Date.Time.Format("2006-01-02"),
+			Calories:    row.Calories.Float64,
+			MoveMinutes: int(row.MoveMinutes.Int32),
+			HeartPoints: row.HeartPoints.Float64,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSleep обрабатывает GET /api/v1/googlefit/sleep.
+// Возвращает сводку сна по ночам с разбивкой по стадиям.
+func (h *GoogleFitHandler) GetSleep(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	rangeStart, rangeEnd := localRange(startDate, endDate)
+	dbResult, err := h.store.GoogleFit.GetSleepSummaryByDateRange(r.Context(), googlefit_db.GetSleepSummaryByDateRangeParams{
+		Tz:        timezone.Location().String(),
+		UserID:    userID,
+		StartTime: pgtype.Timestamptz{Time: rangeStart, Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: rangeEnd, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get google fit sleep from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]models_api_v1.SleepNight, 0, len(dbResult))
+	for _, row := range dbResult {
+		response = append(response, models_api_v1.SleepNight{
+			Date:          row.Night.Time.Format("2006-01-02"),
+			SleepStart:    row.SleepStart.Time.Format(time.RFC3339),
+			SleepEnd:      row.SleepEnd.Time.Format(time.RFC3339),
+			AsleepMinutes: row.AsleepMinutes,
+			LightMinutes:  row.LightMinutes,
+			DeepMinutes:   row.DeepMinutes,
+			RemMinutes:    row.RemMinutes,
+			AwakeMinutes:  row.AwakeMinutes,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetBody обрабатывает GET /api/v1/googlefit/body.
+// Возвращает измерения веса, процента жира или роста со скользящим средним.
+func (h *GoogleFitHandler) GetBody(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		metric = googlefit.BodyMetricWeight
+	}
+	if _, ok := googlefit.BodyDataSources[metric]; !ok {
+		http.Error(w, `{"error": "Invalid metric. Use weight, body_fat or height"}`, http.StatusBadRequest)
+		return
+	}
+
+	windowDays := 7
+	if val := r.URL.Query().Get("window"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 || n > 365 {
+			http.Error(w, `{"error": "Invalid window. Use a number of days between 1 and 365"}`, http.StatusBadRequest)
+			return
+		}
+		windowDays = n
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	// Берём точки и до начала диапазона, чтобы среднее в первые дни было полным
+	rangeStart, rangeEnd := localRange(startDate, endDate)
+	window := time.Duration(windowDays) * 24 * time.Hour
+
+	dbResult, err := h.store.GoogleFit.ListBodyMeasurementsByRange(r.Context(), googlefit_db.ListBodyMeasurementsByRangeParams{
+		UserID:       userID,
+		Metric:       metric,
+		MeasuredAt:   pgtype.Timestamptz{Time: rangeStart.Add(-window), Valid: true},
+		MeasuredAt_2: pgtype.Timestamptz{Time: rangeEnd, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get google fit body measurements from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	points := make([]models_api_v1.BodyMeasurementPoint, 0, len(dbResult))
+	var sum float64
+	first := 0
+	for i, row := range dbResult {
+		sum += row.Value
+		for dbResult[first].MeasuredAt.Time.Before(row.MeasuredAt.Time.Add(-window)) ||
+			dbResult[first].MeasuredAt.Time.Equal(row.MeasuredAt.Time.Add(-window)) {
+			sum -= dbResult[first].Value
+			first++
+		}
+
+		if row.MeasuredAt.Time.Before(rangeStart) {
+			continue
+		}
+		points = append(points, models_api_v1.BodyMeasurementPoint{
+			MeasuredAt: row.MeasuredAt.Time.Format(time.RFC3339),
+			Value:      row.Value,
+			MovingAvg:  sum / float64(i-first+1),
+		})
+	}
+
+	response := models_api_v1.BodyMeasurementsResponse{
+		Metric:     metric,
+		WindowDays: windowDays,
+		Points:     points,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSessions обрабатывает GET /api/v1/googlefit/sessions.
+// Возвращает тренировки за указанный диапазон дат, опционально отфильтрованные по activity_type.
+func (h *GoogleFitHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	activityType := -1
+	if val := r.URL.Query().Get("activity_type"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			http.Error(w, `{"error": "Invalid activity_type"}`, http.StatusBadRequest)
+			return
+		}
+		activityType = n
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	rangeStart, rangeEnd := localRange(startDate, endDate)
+
+	dbResult, err := h.store.GoogleFit.ListSessionsByRange(r.Context(), googlefit_db.ListSessionsByRangeParams{
+		UserID:      userID,
+		StartTime:   pgtype.Timestamptz{Time: rangeStart, Valid: true},
+		StartTime_2: pgtype.Timestamptz{Time: rangeEnd, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get google fit sessions from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]models_api_v1.FitSession, 0, len(dbResult))
+	for _, row := range dbResult {
+		if activityType >= 0 && int(row.ActivityType) != activityType {
+			continue
+		}
+
+		session := models_api_v1.FitSession{
+			SessionID:       row.SessionID,
+			Name:            row.Name.String,
+			Description:     row.Description.String,
+			ActivityType:    int(row.ActivityType),
+			ActivityName:    googlefit.ActivityTypeName(int(row.ActivityType)),
+			Application:     row.Application.String,
+			StartTime:       row.StartTime.Time.Format(time.RFC3339),
+			EndTime:         row.EndTime.Time.Format(time.RFC3339),
+			DurationMinutes: row.EndTime.Time.Sub(row.StartTime.Time).Minutes(),
+			Steps:           int(row.Steps.Int32),
+			Distance:        row.Distance.Float64,
+			Calories:        row.Calories.Float64,
+		}
+		if row.AvgBpm.Valid {
+			session.AvgBpm = &row.AvgBpm.Float64
+		}
+		if row.MaxBpm.Valid {
+			session.MaxBpm = &row.MaxBpm.Float64
+		}
+		response = append(response, session)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetHourlyStats обрабатывает GET /api/v1/googlefit/stats/hourly.
+// Возвращает почасовую статистику за указанный диапазон дат в часовом поясе пользователя.
+func (h *GoogleFitHandler) GetHourlyStats(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	loc := timezone.Location()
+	rangeStart, rangeEnd := localRange(startDate, endDate)
+
+	dbResult, err := h.store.GoogleFit.ListHourlyStatsByRange(r.Context(), googlefit_db.ListHourlyStatsByRangeParams{
+		UserID:      userID,
+		HourStart:   pgtype.Timestamptz{Time: rangeStart, Valid: true},
+		HourStart_2: pgtype.Timestamptz{Time: rangeEnd, Valid: true},
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get google fit hourly stats from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]models_api_v1.HourlyFitStat, 0, len(dbResult))
+	for _, row := range dbResult {
+		response = append(response, models_api_v1.HourlyFitStat{
+			Hour:        row.HourStart.Time.In(loc).Format(time.RFC3339),
+			Steps:       int(row.Steps.Int32),
+			Distance:    row.Distance.Float64,
+			Calories:    row.Calories.Float64,
+			MoveMinutes: int(row.MoveMinutes.Int32),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSources обрабатывает GET /api/v1/googlefit/sources.
+// Возвращает устройства и приложения, передававшие данные, с признаком предпочитаемого источника.
+func (h *GoogleFitHandler) GetSources(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	dbResult, err := h.store.GoogleFit.ListDataSourcesWithStats(r.Context(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get google fit data sources from DB")
+		http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]models_api_v1.FitDataSource, 0, len(dbResult))
+	for _, row := range dbResult {
+		source := models_api_v1.FitDataSource{
+			DataSourceID:       row.DataSourceID,
+			DataType:           row.DataType,
+			Type:               row.Type,
+			DeviceManufacturer: row.DeviceManufacturer.String,
+			DeviceModel:        row.DeviceModel.String,
+			DeviceType:         row.DeviceType.String,
+			Application:        row.Application.String,
+			DaysWithData:       row.DaysWithData,
+			TotalValue:         row.TotalValue,
+			Preferred:          row.Preferred,
+		}
+		if row.LastDate.Valid {
+			source.LastDate = row.LastDate.Time.Format("2006-01-02")
+		}
+		response = append(response, source)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetPreferredSource обрабатывает PUT и DELETE /api/v1/googlefit/sources/preferred.
+// PUT выбирает источник, значения которого используются вместо объединённого агрегата,
+// DELETE (?data_type=...) возвращает объединённый агрегат Google Fit.
+func (h *GoogleFitHandler) SetPreferredSource(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to get user ID")
+		writeUserIDError(w, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var req models_api_v1.PreferredSourceRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			http.Error(w, `{"error": "Invalid JSON format"}`, http.StatusBadRequest)
+			return
+		}
+		if req.DataType == "" || req.DataSourceID == "" {
+			http.Error(w, `{"error": "data_type and data_source_id are required"}`, http.StatusBadRequest)
+			return
+		}
+
+		source, err := h.store.GoogleFit.GetDataSource(r.Context(), googlefit_db.GetDataSourceParams{
+			UserID:       userID,
+			DataSourceID: req.DataSourceID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, `{"error": "Unknown data source"}`, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to get google fit data source from DB")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		if source.DataType != req.DataType {
+			http.Error(w, `{"error": "Data source does not provide this data_type"}`, http.StatusBadRequest)
+			return
+		}
+
+		err = h.store.GoogleFit.SetPreferredSource(r.Context(), googlefit_db.SetPreferredSourceParams{
+			UserID:       userID,
+			DataType:     req.DataType,
+			DataSourceID: req.DataSourceID,
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to set preferred google fit source")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+	case http.MethodDelete:
+		dataType := r.URL.Query().Get("data_type")
+		if dataType == "" {
+			http.Error(w, `{"error": "data_type is required"}`, http.StatusBadRequest)
+			return
+		}
+
+		err := h.store.GoogleFit.DeletePreferredSource(r.Context(), googlefit_db.DeletePreferredSourceParams{
+			UserID:   userID,
+			DataType: dataType,
+		})
+		if err != nil {
+			h.logger.Error().Err(err).Msg("Failed to delete preferred google fit source")
+			http.Error(w, `{"error": "Internal Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+
+	default:
+		http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,84 @@
+package handlers_api_v1
+
+import (
+	"DataLake/internal/middleware"
+	"DataLake/internal/timezone"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	uuid "github.com/satori/go.uuid"
+)
+
+var errNoUserID = errors.New("user ID not found in context")
+
+// maxRangeDays предельная длина периода статистики ActivityWatch и аналитики календаря,
+// как exportMaxDays у экспорта календаря: события за период загружаются в память целиком
+const maxRangeDays = 366
+
+var errRangeTooLong = fmt.Errorf("range must not exceed %d days", maxRangeDays)
+
+// parseDateRange разбирает start_date и end_date (YYYY-MM-DD).
+// По умолчанию возвращает последние 7 дней в часовом поясе пользователя
+func parseDateRange(r *http.Request) (time.Time, time.Time, error) {
+	endDate := timezone.Date(time.Now(), timezone.Location())
+	startDate := endDate.AddDate(0, 0, -6)
+
+	if val := r.URL.Query().Get("start_date"); val != "" {
+		t, err := time.Parse("2006-01-02", val)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start_date format. Use YYYY-MM-DD")
+		}
+		startDate = t
+	}
+	if val := r.URL.Query().Get("end_date"); val != "" {
+		t, err := time.Parse("2006-01-02", val)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end_date format. Use YYYY-MM-DD")
+		}
+		endDate = t
+	}
+
+	return startDate, endDate, nil
+}
+
+// localRange переводит даты из parseDateRange в границы дней в часовом поясе пользователя
+func localRange(startDate, endDate time.Time) (time.Time, time.Time) {
+	loc := timezone.Location()
+	rangeStart := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, loc)
+	rangeEnd := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	return rangeStart, rangeEnd
+}
+
+// userIDFromRequest достаёт userID, добавленный middleware.APIKeyAuth
+func userIDFromRequest(r *http.Request) (pgtype.UUID, error) {
+	userIDStr, ok := middleware.GetUserID(r.Context())
+	if !ok || userIDStr == "" {
+		return pgtype.UUID{}, errNoUserID
+	}
+
+	userID, err := uuid.FromString(userIDStr)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+
+	var userIDBytes [16]byte
+	copy(userIDBytes[:], userID.Bytes())
+	return pgtype.UUID{Bytes: userIDBytes, Valid: true}, nil
+}
+
+// writeUserIDError отвечает ошибкой, полученной из userIDFromRequest
+func writeUserIDError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errNoUserID) {
+		http.Error(w, `{"error": "Unauthorized: User ID not found in context"}`, http.StatusUnauthorized)
+		return
+	}
+	http.Error(w, `{"error": "Internal Server Error: Invalid user ID format"}`, http.StatusInternalServerError)
+}
+
+// writeBadRequest отвечает 400 с сообщением в формате {"error": "..."}
+func writeBadRequest(w http.ResponseWriter, err error) {
+	http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusBadRequest)
+}
@@ -47,4 +47,415 @@ type CalendarEvent struct {
 	Description string `json:"description"`
 	StartTime   string `json:"start_time"`
 	EndTime     string `json:"end_time"`
+	Status      string `json:"status"`
+	CalendarID  string `json:"calendar_id"`
+
+	IsAllDay         bool   `json:"is_all_day"`
+	Organizer        string `json:"organizer,omitempty"`
+	RecurringEventID string `json:"recurring_event_id,omitempty"`
+	Transparency     string `json:"transparency,omitempty"`
+	EventType        string `json:"event_type,omitempty"`
+	ResponseStatus   string `json:"response_status,omitempty"`
+	AttendeesCount   int    `json:"attendees_count"`
+}
+
+type DailyHeartRate struct {
+	Date   string  `json:"date"`
+	MinBpm float64 `json:"min_bpm"`
+	AvgBpm float64 `json:"avg_bpm"`
+	MaxBpm float64 `json:"max_bpm"`
+}
+
+type DailyFitActivity struct {
+	Date        string  `json:"date"`
+	Calories    float64 `json:"calories"`
+	MoveMinutes int     `json:"move_minutes"`
+	HeartPoints float64 `json:"heart_points"`
+}
+
+// SleepNight сводка сна за ночь (по дате пробуждения)
+type SleepNight struct {
+	Date          string  `json:"date"`
+	SleepStart    string  `json:"sleep_start"`
+	SleepEnd      string  `json:"sleep_end"`
+	AsleepMinutes float64 `json:"asleep_minutes"`
+	LightMinutes  float64 `json:"light_minutes"`
+	DeepMinutes   float64 `json:"deep_minutes"`
+	RemMinutes    float64 `json:"rem_minutes"`
+	AwakeMinutes  float64 `json:"awake_minutes"`
+}
+
+type BodyMeasurementPoint struct {
+	MeasuredAt string  `json:"measured_at"`
+	Value      float64 `json:"value"`
+	MovingAvg  float64 `json:"moving_avg"`
+}
+
+// BodyMeasurementsResponse ряд измерений тела со скользящим средним за window_days дней
+type BodyMeasurementsResponse struct {
+	Metric     string                 `json:"metric"`
+	WindowDays int                    `json:"window_days"`
+	Points     []BodyMeasurementPoint `json:"points"`
+}
+
+// FitSession тренировка из Google Fit с агрегатами за сессию
+type FitSession struct {
+	SessionID       string   `json:"session_id"`
+	Name            string   `json:"name"`
+	Description     string   `json:"description,omitempty"`
+	ActivityType    int      `json:"activity_type"`
+	ActivityName    string   `json:"activity_name"`
+	Application     string   `json:"application,omitempty"`
+	StartTime       string   `json:"start_time"`
+	EndTime         string   `json:"end_time"`
+	DurationMinutes float64  `json:"duration_minutes"`
+	Steps           int      `json:"steps"`
+	Distance        float64  `json:"distance_meters"`
+	Calories        float64  `json:"calories"`
+	AvgBpm          *float64 `json:"avg_bpm"`
+	MaxBpm          *float64 `json:"max_bpm"`
+}
+
+// HourlyFitStat статистика Google Fit за час, hour в часовом поясе пользователя
+type HourlyFitStat struct {
+	Hour        string  `json:"hour"`
+	Steps       int     `json:"steps"`
+	Distance    float64 `json:"distance"`
+	Calories    float64 `json:"calories"`
+	MoveMinutes int     `json:"move_minutes"`
+}
+
+// FitDataSource устройство или приложение, передавшее данные в Google Fit
+type FitDataSource struct {
+	DataSourceID       string  `json:"data_source_id"`
+	DataType           string  `json:"data_type"`
+	Type               string  `json:"type"`
+	DeviceManufacturer string  `json:"device_manufacturer,omitempty"`
+	DeviceModel        string  `json:"device_model,omitempty"`
+	DeviceType         string  `json:"device_type,omitempty"`
+	Application        string  `json:"application,omitempty"`
+	DaysWithData       int64   `json:"days_with_data"`
+	TotalValue         float64 `json:"total_value"`
+	LastDate           string  `json:"last_date,omitempty"`
+	Preferred          bool    `json:"preferred"`
+}
+
+type PreferredSourceRequest struct {
+	DataType     string `json:"data_type"`
+	DataSourceID string `json:"data_source_id"`
+}
+
+// CalendarInfo календарь Google Calendar и его настройки синхронизации
+type CalendarInfo struct {
+	CalendarID      string `json:"calendar_id"`
+	Summary         string `json:"summary"`
+	Description     string `json:"description,omitempty"`
+	TimeZone        string `json:"time_zone,omitempty"`
+	BackgroundColor string `json:"background_color,omitempty"`
+	ForegroundColor string `json:"foreground_color,omitempty"`
+	AccessRole      string `json:"access_role,omitempty"`
+	Primary         bool   `json:"primary"`
+	SyncEnabled     bool   `json:"sync_enabled"`
+	Included        bool   `json:"included"`
+}
+
+type CalendarSettingsRequest struct {
+	CalendarID  string `json:"calendar_id"`
+	SyncEnabled *bool  `json:"sync_enabled"`
+	Included    *bool  `json:"included"`
+}
+
+// CalendarDayLoad нагрузка встречами за день в часовом поясе пользователя
+type CalendarDayLoad struct {
+	Date         string  `json:"date"`
+	Meetings     int     `json:"meetings"`
+	MeetingHours float64 `json:"meeting_hours"`
+}
+
+// CalendarWeekLoad нагрузка встречами за неделю, week_start - понедельник
+type CalendarWeekLoad struct {
+	WeekStart    string  `json:"week_start"`
+	Meetings     int     `json:"meetings"`
+	MeetingHours float64 `json:"meeting_hours"`
+}
+
+type CalendarMeetingLoadResponse struct {
+	Daily  []CalendarDayLoad  `json:"daily"`
+	Weekly []CalendarWeekLoad `json:"weekly"`
+}
+
+// CalendarFocusDay свободное время рабочего дня между встречами
+type CalendarFocusDay struct {
+	Date                string  `json:"date"`
+	MeetingMinutes      float64 `json:"meeting_minutes"`
+	FreeMinutes         float64 `json:"free_minutes"`
+	LongestFocusMinutes float64 `json:"longest_focus_minutes"`
+	FocusBlocks         int     `json:"focus_blocks"`
+	Fragmentation       float64 `json:"fragmentation"`
+}
+
+// CalendarAfterHoursMeeting встреча вне рабочих часов
+type CalendarAfterHoursMeeting struct {
+	EventID           string  `json:"event_id"`
+	Summary           string  `json:"summary"`
+	StartTime         string  `json:"start_time"`
+	EndTime           string  `json:"end_time"`
+	AfterHoursMinutes float64 `json:"after_hours_minutes"`
+}
+
+// CalendarStreak серия встреч подряд без перерыва
+type CalendarStreak struct {
+	Date      string   `json:"date"`
+	StartTime string   `json:"start_time"`
+	EndTime   string   `json:"end_time"`
+	Meetings  int      `json:"meetings"`
+	Minutes   float64  `json:"minutes"`
+	Summaries []string `json:"summaries"`
+}
+
+type CalendarDailySummary struct {
+	Date           string `json:"date"`
+	Events         int64  `json:"events"`
+	MeetingMinutes int64  `json:"meeting_minutes"`
+	AllDayEvents   int64  `json:"all_day_events"`
+}
+
+type CalendarSummaryByCalendar struct {
+	CalendarID     string `json:"calendar_id"`
+	Events         int64  `json:"events"`
+	MeetingMinutes int64  `json:"meeting_minutes"`
+}
+
+// CalendarAnalyticsSummary сводка по занятому времени за период
+type CalendarAnalyticsSummary struct {
+	AvgEventsPerDay         float64                     `json:"avg_events_per_day"`
+	AvgMeetingMinutesPerDay float64                     `json:"avg_meeting_minutes_per_day"`
+	Daily                   []CalendarDailySummary      `json:"daily"`
+	ByCalendar              []CalendarSummaryByCalendar `json:"by_calendar"`
+	BusiestDays             []CalendarDailySummary      `json:"busiest_days"`
+}
+
+// CalendarFeed подписка на /api/v1/calendar.ics. Token и URL возвращаются только при создании
+type CalendarFeed struct {
+	ID                   int32  `json:"id"`
+	Name                 string `json:"name"`
+	IncludeEvents        bool   `json:"include_events"`
+	IncludeWakatime      bool   `json:"include_wakatime"`
+	IncludeActivityWatch bool   `json:"include_activitywatch"`
+	CreatedAt            string `json:"created_at"`
+	LastUsedAt           string `json:"last_used_at,omitempty"`
+	Token                string `json:"token,omitempty"`
+	URL                  string `json:"url,omitempty"`
+}
+
+type CalendarFeedRequest struct {
+	Name                 string `json:"name"`
+	IncludeEvents        *bool  `json:"include_events"`
+	IncludeWakatime      bool   `json:"include_wakatime"`
+	IncludeActivityWatch bool   `json:"include_activitywatch"`
+}
+
+// ActivityDevice устройство ActivityWatch, определённое по hostname бакетов
+type ActivityDevice struct {
+	ID        int64  `json:"id"`
+	Hostname  string `json:"hostname"`
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+}
+
+// ActivityAppStat активное время в приложении за период (без AFK).
+// Имена полей совпадают с прежним ответом /activitywatch/stats, который читает дашборд
+type ActivityAppStat struct {
+	Hostname      string  `json:"Hostname,omitempty"`
+	App           string  `json:"App"`
+	TotalDuration float64 `json:"TotalDuration"` // секунды
+	EventCount    int64   `json:"EventCount"`    // отрезки активности, событие может разбиться на несколько
+}
+
+// ActivityCategoryRule правило категоризации ActivityWatch
+type ActivityCategoryRule struct {
+	ID         int32    `json:"id"`
+	Category   []string `json:"category"`
+	Field      string   `json:"field"`
+	Pattern    string   `json:"pattern"`
+	IgnoreCase bool     `json:"ignore_case"`
+	Color      string   `json:"color,omitempty"`
+}
+
+type ActivityCategoryRuleRequest struct {
+	Category   []string `json:"category"`
+	Field      string   `json:"field"`       // app, title, url или any (по умолчанию)
+	Pattern    string   `json:"pattern"`     // регулярное выражение RE2
+	IgnoreCase *bool    `json:"ignore_case"` // по умолчанию true
+	Color      string   `json:"color"`
+}
+
+// ActivityCategoryImportResponse результат импорта категорий aw-webui
+type ActivityCategoryImportResponse struct {
+	Imported int      `json:"imported"`
+	Skipped  []string `json:"skipped"`
+}
+
+// ActivityIngestResponse результат приёма событий ActivityWatch
+type ActivityIngestResponse struct {
+	Message   string                `json:"message"`
+	Accepted  int                   `json:"accepted"`
+	Inserted  int                   `json:"inserted"`
+	Updated   int                   `json:"updated"`
+	Unchanged int                   `json:"unchanged"`
+	Rejected  int                   `json:"rejected"`
+	Errors    []ActivityIngestError `json:"errors"` // первые 100 отклонённых событий
+}
+
+// ActivityIngestError причина отклонения события, index - позиция в массиве или строка NDJSON с нуля
+type ActivityIngestError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ActivityImportBucket результат импорта одного бакета экспорта aw-server
+type ActivityImportBucket struct {
+	BucketID  string                `json:"bucket_id"`
+	Type      string                `json:"type"`
+	Hostname  string                `json:"hostname,omitempty"`
+	Events    int                   `json:"events"`
+	Accepted  int                   `json:"accepted"`
+	Inserted  int                   `json:"inserted"`
+	Updated   int                   `json:"updated"`
+	Unchanged int                   `json:"unchanged"`
+	Rejected  int                   `json:"rejected"`
+	Skipped   string                `json:"skipped,omitempty"` // причина, по которой бакет не загружен
+	Errors    []ActivityIngestError `json:"errors,omitempty"`  // первые 100 отклонённых событий бакета
+}
+
+// ActivityImportResponse результат импорта экспорта aw-server: итоги и счётчики по бакетам
+type ActivityImportResponse struct {
+	Message   string                 `json:"message"`
+	Events    int                    `json:"events"`
+	Accepted  int                    `json:"accepted"`
+	Inserted  int                    `json:"inserted"`
+	Updated   int                    `json:"updated"`
+	Unchanged int                    `json:"unchanged"`
+	Rejected  int                    `json:"rejected"`
+	Buckets   []ActivityImportBucket `json:"buckets"`
+}
+
+// ActivityRedactionRule правило редактирования заголовков и адресов ActivityWatch
+type ActivityRedactionRule struct {
+	ID          int32  `json:"id"`
+	App         string `json:"app,omitempty"`
+	Field       string `json:"field"`
+	Pattern     string `json:"pattern,omitempty"`
+	Action      string `json:"action"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+type ActivityRedactionRuleRequest struct {
+	App         string `json:"app"`         // регулярное выражение RE2 по приложению
+	Field       string `json:"field"`       // title (по умолчанию) или url
+	Pattern     string `json:"pattern"`     // регулярное выражение RE2 по полю
+	Action      string `json:"action"`      // drop, hash или replace
+	Replacement string `json:"replacement"` // для replace, поддерживает $1
+}
+
+// ActivityRedactionResponse результат редактирования загруженных событий
+type ActivityRedactionResponse struct {
+	Scanned int `json:"scanned"`
+	Updated int `json:"updated"`
+}
+
+// ActivityCategoryStat активное время категории вместе с подкатегориями
+type ActivityCategoryStat struct {
+	Category      string   `json:"category"`
+	Path          []string `json:"path"`
+	Color         string   `json:"color,omitempty"`
+	TotalDuration float64  `json:"total_duration"` // секунды
+}
+
+// ActivitySpan отрезок активного времени в приложении (без AFK)
+type ActivitySpan struct {
+	Start    string  `json:"start"`
+	End      string  `json:"end"`
+	Duration float64 `json:"duration"` // секунды
+	App      string  `json:"app"`
+	Title    string  `json:"title,omitempty"`
+	Hostname string  `json:"hostname,omitempty"`
+	Category string  `json:"category"`
+}
+
+type ActivityTimelineResponse struct {
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+	Spans  []ActivitySpan `json:"spans"`
+}
+
+// ActivityDuration время приложения или категории, секунды
+type ActivityDuration struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration"`
+}
+
+// ActivityHourStat активное время за час по локальному времени
+type ActivityHourStat struct {
+	Hour          string             `json:"hour"`
+	TotalDuration float64            `json:"total_duration"`
+	Apps          []ActivityDuration `json:"apps"`
+	Categories    []ActivityDuration `json:"categories"`
+}
+
+// ActivityDayStat активное время за локальный день
+type ActivityDayStat struct {
+	Date          string             `json:"date"`
+	TotalDuration float64            `json:"total_duration"`
+	Apps          []ActivityDuration `json:"apps"`
+	Categories    []ActivityDuration `json:"categories"`
+}
+
+// ActivityTitleStat активное время окна приложения
+type ActivityTitleStat struct {
+	App      string  `json:"app"`
+	Title    string  `json:"title"`
+	Category string  `json:"category"`
+	Duration float64 `json:"duration"` // секунды
+	Spans    int     `json:"spans"`
+}
+
+type ActivityTopTitlesResponse struct {
+	Total  int                 `json:"total"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+	Titles []ActivityTitleStat `json:"titles"`
+}
+
+// ActivityDomainStat активное время на вкладках домена
+type ActivityDomainStat struct {
+	Domain   string  `json:"domain"`
+	Duration float64 `json:"duration"` // секунды
+	Spans    int     `json:"spans"`
+}
+
+type ActivityTopDomainsResponse struct {
+	Total   int                  `json:"total"`
+	Limit   int                  `json:"limit"`
+	Offset  int                  `json:"offset"`
+	Domains []ActivityDomainStat `json:"domains"`
+}
+
+// ActivityAppEvent событие приложения в том виде, как его прислал aw-server (без учёта AFK)
+type ActivityAppEvent struct {
+	ID        int64   `json:"id"`
+	Timestamp string  `json:"timestamp"`
+	Duration  float64 `json:"duration"` // секунды
+	Title     string  `json:"title,omitempty"`
+	BucketID  string  `json:"bucket_id"`
+	Hostname  string  `json:"hostname,omitempty"`
+	Category  string  `json:"category"`
+}
+
+type ActivityAppEventsResponse struct {
+	Total  int                `json:"total"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+	Events []ActivityAppEvent `json:"events"`
 }
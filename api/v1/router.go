@@ -16,6 +16,7 @@ func NewRouter(store *internal_db.Store, logger *zerolog.Logger) http.Handler {
 	googleFitHandler := handlers_api_v1.NewGoogleFitHandler(store, logger)
 	googleCalendar := handlers_api_v1.NewGoogleCalendarHandler(store, logger)
 	activityWatchHandler := handlers_api_v1.NewActivityWatchHandler(store, logger)
+	calendarExportHandler := handlers_api_v1.NewCalendarExportHandler(store, logger)
 
 	// wakatime endpoints
 	mux.Handle("/wakatime/stats", middleware.APIKeyAuth(http.HandlerFunc(wakaTimeHandler.GetStats)))
@@ -24,12 +25,44 @@ func NewRouter(store *internal_db.Store, logger *zerolog.Logger) http.Handler {
 
 	// googlefit endpoints
 	mux.Handle("/googlefit/stats", middleware.APIKeyAuth(http.HandlerFunc(googleFitHandler.GetStats)))
+	mux.Handle("/googlefit/stats/hourly", middleware.APIKeyAuth(http.HandlerFunc(googleFitHandler.GetHourlyStats)))
+	mux.Handle("/googlefit/heart-rate", middleware.APIKeyAuth(http.HandlerFunc(googleFitHandler.GetHeartRate)))
+	mux.Handle("/googlefit/activity", middleware.APIKeyAuth(http.HandlerFunc(googleFitHandler.GetActivity)))
+	mux.Handle("/googlefit/sleep", middleware.APIKeyAuth(http.HandlerFunc(googleFitHandler.GetSleep)))
+	mux.Handle("/googlefit/body", middleware.APIKeyAuth(http.HandlerFunc(googleFitHandler.GetBody)))
+	mux.Handle("/googlefit/sessions", middleware.APIKeyAuth(http.HandlerFunc(googleFitHandler.GetSessions)))
+	mux.Handle("/googlefit/sources", middleware.APIKeyAuth(http.HandlerFunc(googleFitHandler.GetSources)))
+	mux.Handle("/googlefit/sources/preferred", middleware.APIKeyAuth(http.HandlerFunc(googleFitHandler.SetPreferredSource)))
 	// googlecalendar endpoints
 	mux.Handle("/googlecalendar/events", middleware.APIKeyAuth(http.HandlerFunc(googleCalendar.GetEvents)))
+	mux.Handle("/googlecalendar/calendars", middleware.APIKeyAuth(http.HandlerFunc(googleCalendar.Calendars)))
+	mux.Handle("/googlecalendar/analytics/summary", middleware.APIKeyAuth(http.HandlerFunc(googleCalendar.GetAnalyticsSummary)))
+	mux.Handle("/googlecalendar/analytics/meeting-load", middleware.APIKeyAuth(http.HandlerFunc(googleCalendar.GetMeetingLoad)))
+	mux.Handle("/googlecalendar/analytics/focus", middleware.APIKeyAuth(http.HandlerFunc(googleCalendar.GetFocusTime)))
+	mux.Handle("/googlecalendar/analytics/after-hours", middleware.APIKeyAuth(http.HandlerFunc(googleCalendar.GetAfterHours)))
+	mux.Handle("/googlecalendar/analytics/back-to-back", middleware.APIKeyAuth(http.HandlerFunc(googleCalendar.GetBackToBack)))
+
+	// calendar export endpoints (calendar.ics авторизуется токеном подписки)
+	mux.Handle("/calendar.ics", http.HandlerFunc(calendarExportHandler.GetICS))
+	mux.Handle("/calendar/feeds", middleware.APIKeyAuth(http.HandlerFunc(calendarExportHandler.Feeds)))
 
 	// activitywatch endpoints
 	mux.Handle("/activitywatch/events", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.HandleEvents)))
+	mux.Handle("/activitywatch/import", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.ImportExport)))
 	mux.Handle("/activitywatch/stats", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.GetStats)))
+	mux.Handle("/activitywatch/devices", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.GetDevices)))
+	mux.Handle("/activitywatch/categories", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.Categories)))
+	mux.Handle("/activitywatch/categories/import", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.ImportCategories)))
+	mux.Handle("/activitywatch/categories/stats", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.GetCategoryStats)))
+	mux.Handle("/activitywatch/redaction-rules", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.RedactionRules)))
+	mux.Handle("/activitywatch/redaction/apply", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.ApplyRedaction)))
+	mux.Handle("/activitywatch/private-apps", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.PrivateApps)))
+	mux.Handle("/activitywatch/timeline", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.GetTimeline)))
+	mux.Handle("/activitywatch/hourly", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.GetHourly)))
+	mux.Handle("/activitywatch/daily", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.GetDaily)))
+	mux.Handle("/activitywatch/top-titles", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.GetTopTitles)))
+	mux.Handle("/activitywatch/top-domains", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.GetTopDomains)))
+	mux.Handle("/activitywatch/apps/{app}/events", middleware.APIKeyAuth(http.HandlerFunc(activityWatchHandler.GetAppEvents)))
 
 	return middleware.Logging(mux)
 }
@@ -0,0 +1,91 @@
+package caldavauth
+
+import (
+	"DataLake/auth"
+	"DataLake/internal/logger"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ProviderName имя, под которым пароль приложения хранится в TokenStorage
+const ProviderName = "caldav"
+
+// Provider учётные данные CalDAV сервера (Nextcloud, Radicale и т.п.).
+// Вместо OAuth используется пароль приложения с Basic авторизацией
+type Provider struct {
+	serverURL string
+	username  string
+	password  string
+}
+
+// NewProviderFromEnv создаёт провайдер из CALDAV_URL, CALDAV_USERNAME и CALDAV_PASSWORD
+func NewProviderFromEnv() *Provider {
+	return &Provider{
+		serverURL: strings.TrimRight(os.Getenv("CALDAV_URL"), "/") + "/",
+		username:  os.Getenv("CALDAV_USERNAME"),
+		password:  os.Getenv("CALDAV_PASSWORD"),
+	}
+}
+
+// ServerURL возвращает корневой URL CalDAV сервера
+func (p *Provider) ServerURL() string {
+	return p.serverURL
+}
+
+// Configured сообщает, задан ли адрес CalDAV сервера
+func (p *Provider) Configured() bool {
+	return p.serverURL != "/"
+}
+
+// HasAppPassword сообщает, заданы ли логин и пароль приложения в окружении
+func (p *Provider) HasAppPassword() bool {
+	return p.username != "" && p.password != ""
+}
+
+// AppPasswordToken упаковывает логин и пароль из окружения в токен для TokenStorage
+func (p *Provider) AppPasswordToken() auth.TokenResponse {
+	return NewAppPasswordToken(p.username, p.password)
+}
+
+// NewAppPasswordToken создаёт токен для пароля приложения: пароль хранится в AccessToken,
+// логин - в UID. Как и API ключ, такой токен не истекает
+func NewAppPasswordToken(username, password string) auth.TokenResponse {
+	return auth.TokenResponse{
+		AccessToken: password,
+		TokenType:   auth.TokenTypeAPIKey,
+		UID:         username,
+	}
+}
+
+// VerifyAppPassword проверяет логин и пароль запросом PROPFIND к корню сервера
+func (p *Provider) VerifyAppPassword(ctx context.Context, username, password string) error {
+	log := logger.Get()
+
+	body := `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:"><d:prop><d:current-user-principal/></d:prop></d:propfind>`
+	req, err := http.NewRequestWithContext(ctx, "PROPFIND", p.serverURL, strings.NewReader(body))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create request")
+		return err
+	}
+	req.SetBasicAuth(username, password)
+	req.Header.Set("Depth", "0")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	client := http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to execute request")
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		log.Warn().Int("status_code", resp.StatusCode).Str("url", p.serverURL).Msg("caldav app password rejected")
+		return fmt.Errorf("app password rejected: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
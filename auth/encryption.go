@@ -3,10 +3,14 @@ package auth
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 )
 
 // Encryption предоставляет методы для шифрования и дешифрования данных
@@ -83,3 +87,19 @@ func (e *Encryption) Decrypt(ciphertext string) ([]byte, error) {
 
 	return plaintext, nil
 }
+
+// DeriveKeyFromEnv возвращает 32-байтовый ключ для purpose, производный от ENCRYPTION_KEY (HMAC-SHA256).
+// Разные purpose дают независимые ключи, сам ключ шифрования токенов наружу не передаётся
+func DeriveKeyFromEnv(purpose string) ([]byte, error) {
+	encryptionKey := os.Getenv("ENCRYPTION_KEY")
+	if encryptionKey == "" {
+		return nil, fmt.Errorf("ENCRYPTION_KEY environment variable is required")
+	}
+	if len(encryptionKey) != 32 {
+		return nil, fmt.Errorf("ENCRYPTION_KEY must be exactly 32 bytes for AES-256, current length: %d", len(encryptionKey))
+	}
+
+	mac := hmac.New(sha256.New, []byte(encryptionKey))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil), nil
+}
@@ -16,6 +16,10 @@ type Provider interface {
 	RefreshToken(ctx context.Context, refreshToken string) (TokenResponse, error)
 }
 
+// TokenTypeAPIKey помечает токен, который является постоянным API ключом,
+// а не OAuth токеном: такие токены не истекают и не обновляются
+const TokenTypeAPIKey = "api_key"
+
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
@@ -59,6 +59,10 @@ func (tm *TokenManager) GetValidToken(ctx context.Context, providerName string)
 
 // isTokenExpired проверяет истёк ли токен
 func (tm *TokenManager) isTokenExpired(token TokenResponse) bool {
+	if token.TokenType == TokenTypeAPIKey {
+		return false
+	}
+
 	if token.ExpiresAt == "" {
 		return true
 	}
@@ -4,6 +4,8 @@ import (
 	"DataLake/auth"
 	"DataLake/internal/logger"
 	"context"
+	"encoding/base64"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -11,11 +13,17 @@ import (
 	"strings"
 )
 
+// DefaultAPIURL базовый URL WakaTime API. Для Wakapi и других совместимых
+// серверов задаётся через WAKATIME_API_URL, например https://wakapi.dev/api/compat/wakatime/v1
+const DefaultAPIURL = "https://wakatime.com/api/v1"
+
 // Provider реализация OAuth2 провайдера для WakaTime
 type Provider struct {
 	clientID     string
 	clientSecret string
 	redirectURI  string
+	apiKey       string
+	apiURL       string
 }
 
 // NewProvider создает новый провайдер WakaTime
@@ -24,18 +32,59 @@ func NewProvider(clientID, clientSecret, redirectURI string) *Provider {
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		redirectURI:  redirectURI,
+		apiURL:       DefaultAPIURL,
 	}
 }
 
 // NewProviderFromEnv создаёт провайдер из переменных окружения
 func NewProviderFromEnv() *Provider {
+	apiURL := strings.TrimRight(os.Getenv("WAKATIME_API_URL"), "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
 	return &Provider{
 		clientID:     os.Getenv("CLIENT_ID"),
 		clientSecret: os.Getenv("CLIENT_SECRET"),
 		redirectURI:  os.Getenv("REDIRECT_URI"),
+		apiKey:       os.Getenv("WAKATIME_API_KEY"),
+		apiURL:       apiURL,
+	}
+}
+
+// APIURL возвращает базовый URL API (WakaTime или совместимый сервер)
+func (p *Provider) APIURL() string {
+	return p.apiURL
+}
+
+// HasAPIKey сообщает, задан ли персональный API ключ в окружении
+func (p *Provider) HasAPIKey() bool {
+	return p.apiKey != ""
+}
+
+// APIKeyToken упаковывает API ключ из окружения в токен для TokenStorage
+func (p *Provider) APIKeyToken() auth.TokenResponse {
+	return NewAPIKeyToken(p.apiKey)
+}
+
+// NewAPIKeyToken создаёт токен для персонального API ключа WakaTime.
+// Такой токен хранится зашифрованным вместе с OAuth токенами, но не обновляется
+func NewAPIKeyToken(apiKey string) auth.TokenResponse {
+	return auth.TokenResponse{
+		AccessToken: apiKey,
+		TokenType:   auth.TokenTypeAPIKey,
 	}
 }
 
+// AuthorizationHeader возвращает значение заголовка Authorization для токена:
+// Basic для API ключа и Bearer для OAuth токена
+func AuthorizationHeader(token auth.TokenResponse) string {
+	if token.TokenType == auth.TokenTypeAPIKey {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(token.AccessToken))
+	}
+	return "Bearer " + token.AccessToken
+}
+
 // GetAuthURL возвращает URL для авторизации пользователя
 func (p *Provider) GetAuthURL(state string) string {
 	baseURL := "https://wakatime.com/oauth/authorize"
@@ -161,3 +210,30 @@ func (p *Provider) RefreshToken(ctx context.Context, refreshToken string) (auth.
 
 	return token, nil
 }
+
+// VerifyAPIKey проверяет API ключ запросом текущего пользователя
+func (p *Provider) VerifyAPIKey(ctx context.Context, apiKey string) error {
+	log := logger.Get()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.apiURL+"/users/current", nil)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create request")
+		return err
+	}
+	req.Header.Set("Authorization", AuthorizationHeader(NewAPIKeyToken(apiKey)))
+
+	client := http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to execute request")
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Warn().Int("status_code", resp.StatusCode).Str("url", p.apiURL).Msg("wakatime api key rejected")
+		return fmt.Errorf("api key rejected: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"text/tabwriter"
+)
+
+// importResult ответ POST /api/v1/activitywatch/import
+type importResult struct {
+	Events   int `json:"events"`
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Rejected int `json:"rejected"`
+	Buckets  []struct {
+		BucketID  string `json:"bucket_id"`
+		Type      string `json:"type"`
+		Events    int    `json:"events"`
+		Inserted  int    `json:"inserted"`
+		Updated   int    `json:"updated"`
+		Unchanged int    `json:"unchanged"`
+		Rejected  int    `json:"rejected"`
+		Skipped   string `json:"skipped"`
+		Errors    []struct {
+			Index int    `json:"index"`
+			Error string `json:"error"`
+		} `json:"errors"`
+	} `json:"buckets"`
+}
+
+// Import отправляет экспорт aw-server в lake, сжимая его на лету: выгрузка за годы не держится в памяти.
+// Импорт долгий, поэтому ограничен только ctx, а не таймаутом клиента
+func (u *Uploader) Import(ctx context.Context, export io.Reader) (*importResult, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, export)
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u.importEndpoint, pr)
+	if err != nil {
+		pr.Close()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	if u.apiKey != "" {
+		req.Header.Set("X-API-Key", u.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("lake returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result importResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &result, nil
+}
+
+// runImport загружает экспорт из файла (- для stdin) или, с fromAW, всю историю из aw-server
+// и печатает счётчики по бакетам
+func (a *Agent) runImport(ctx context.Context, path string, fromAW bool) error {
+	var export io.ReadCloser
+	switch {
+	case fromAW:
+		body, err := a.aw.Export(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to export aw-server buckets: %w", err)
+		}
+		export = body
+	case path == "-":
+		export = io.NopCloser(os.Stdin)
+	default:
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		export = file
+	}
+	defer export.Close()
+
+	result, err := a.uploader.Import(ctx, export)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BUCKET\tTYPE\tEVENTS\tINSERTED\tUPDATED\tUNCHANGED\tREJECTED")
+	for _, bucket := range result.Buckets {
+		if bucket.Skipped != "" {
+			fmt.Fprintf(tw, "%s\t%s\t%d\tskipped: %s\t\t\t\n", bucket.BucketID, bucket.Type, bucket.Events, bucket.Skipped)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n",
+			bucket.BucketID, bucket.Type, bucket.Events, bucket.Inserted, bucket.Updated, bucket.Unchanged, bucket.Rejected)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, bucket := range result.Buckets {
+		for _, e := range bucket.Errors {
+			log.Warn().Str("bucket_id", bucket.BucketID).Int("index", e.Index).Str("error", e.Error).Msg("lake rejected event")
+		}
+	}
+	log.Info().Int("events", result.Events).Int("inserted", result.Inserted).Int("updated", result.Updated).Int("rejected", result.Rejected).Msg("import completed")
+	return nil
+}
@@ -0,0 +1,248 @@
+// aw-client забирает события из локального aw-server и отправляет их в lake.
+//
+// Для каждого бакета хранится водяной знак - время начала последнего отправленного события,
+// поэтому повторные запуски отправляют только новые события и само последнее событие:
+// aw-server продлевает его heartbeat-ами, а lake обновляет duration по (bucket_id, timestamp).
+// Если lake недоступен, пачки сохраняются на диск и отправляются при следующем запуске.
+//
+// Без -interval выполняет одну синхронизацию (для systemd timer и launchd),
+// с -interval работает как демон. -import загружает файл экспорта aw-server,
+// -import-aw - всю историю из aw-server; повторный импорт безопасен.
+package main
+
+import (
+	"DataLake/activitywatch"
+	"DataLake/internal/logger"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog"
+)
+
+var log zerolog.Logger
+
+type config struct {
+	awHost    string
+	server    string
+	apiKey    string
+	minutes   int
+	interval  time.Duration
+	batchSize int
+	stateDir  string
+}
+
+func main() {
+	// .env из рабочего каталога проекта, как у make run-aw и сервисов из install_service.sh
+	_ = godotenv.Load()
+
+	environment := os.Getenv("ENVIRONMENT")
+	if environment == "" {
+		environment = "development"
+	}
+	logger.Init(environment)
+	log = logger.Get().With().Str("component", "aw-client").Logger()
+
+	cfg := config{}
+	flag.StringVar(&cfg.awHost, "aw-host", envOr("AW_HOST", activitywatch.DefaultHost), "адрес aw-server")
+	flag.StringVar(&cfg.server, "server", envOr("DATALAKE_URL", "http://localhost:8080"), "адрес lake")
+	flag.StringVar(&cfg.apiKey, "api-key", os.Getenv("API_KEY"), "API ключ lake (X-API-Key)")
+	flag.IntVar(&cfg.minutes, "minutes", 60, "за сколько минут забирать события бакета при первом запуске")
+	flag.DurationVar(&cfg.interval, "interval", 0, "интервал синхронизации в режиме демона, 0 - один запуск")
+	flag.IntVar(&cfg.batchSize, "batch", 500, "событий в одном запросе к lake")
+	flag.StringVar(&cfg.stateDir, "state-dir", defaultStateDir(), "каталог водяных знаков и буфера")
+	importFile := flag.String("import", "", "загрузить файл экспорта aw-server (- для stdin) и выйти")
+	importAW := flag.Bool("import-aw", false, "загрузить всю историю из aw-server (/api/0/export) и выйти")
+	flag.Parse()
+
+	if cfg.minutes <= 0 || cfg.batchSize <= 0 {
+		fmt.Fprintln(os.Stderr, "-minutes and -batch must be positive")
+		os.Exit(2)
+	}
+	if err := os.MkdirAll(cfg.stateDir, 0o700); err != nil {
+		log.Fatal().Err(err).Str("state_dir", cfg.stateDir).Msg("failed to create state directory")
+	}
+
+	agent, err := newAgent(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize aw-client")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *importFile != "" || *importAW {
+		if err := agent.runImport(ctx, *importFile, *importAW); err != nil {
+			log.Error().Err(err).Msg("import failed")
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.interval <= 0 {
+		if err := agent.Sync(ctx); err != nil {
+			log.Error().Err(err).Msg("sync failed")
+			os.Exit(1)
+		}
+		return
+	}
+
+	log.Info().Dur("interval", cfg.interval).Str("aw_host", cfg.awHost).Str("server", cfg.server).Msg("aw-client started")
+	ticker := time.NewTicker(cfg.interval)
+	defer ticker.Stop()
+	for {
+		if err := agent.Sync(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Error().Err(err).Msg("sync failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("aw-client stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Agent одна синхронизация: отправка буфера, затем новых событий всех бакетов
+type Agent struct {
+	cfg      config
+	aw       *activitywatch.Client
+	uploader *Uploader
+	buffer   *Buffer
+}
+
+func newAgent(cfg config) (*Agent, error) {
+	buffer, err := newBuffer(cfg.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Agent{
+		cfg:      cfg,
+		aw:       activitywatch.NewClient(cfg.awHost),
+		uploader: newUploader(cfg.server, cfg.apiKey),
+		buffer:   buffer,
+	}, nil
+}
+
+func (a *Agent) Sync(ctx context.Context) error {
+	send := func(events []activitywatch.IngestEvent) error {
+		return a.uploader.Send(ctx, events)
+	}
+
+	// Сначала буфер: события должны попасть в lake в порядке появления
+	flushed, err := a.buffer.Flush(send)
+	online := err == nil
+	if err != nil {
+		log.Warn().Err(err).Msg("lake unavailable, buffering events")
+	} else if flushed > 0 {
+		log.Info().Int("events", flushed).Msg("sent buffered events")
+	}
+
+	state, err := loadState(a.cfg.stateDir)
+	if err != nil {
+		return err
+	}
+
+	buckets, err := a.aw.Buckets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list aw-server buckets: %w", err)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].ID < buckets[j].ID })
+
+	now := time.Now()
+	sent, buffered := 0, 0
+	for _, bucket := range buckets {
+		if !bucket.Supported() {
+			log.Debug().Str("bucket_id", bucket.ID).Str("type", bucket.Type).Msg("skipping unsupported bucket")
+			continue
+		}
+
+		watermark, hasWatermark := state.Watermarks[bucket.ID]
+		since := watermark
+		if !hasWatermark {
+			since = now.Add(-time.Duration(a.cfg.minutes) * time.Minute)
+		}
+
+		events, err := a.aw.Events(ctx, bucket.ID, since, now)
+		if err != nil {
+			log.Error().Err(err).Str("bucket_id", bucket.ID).Msg("failed to get bucket events")
+			continue
+		}
+
+		// Событие с водяным знаком отправляется повторно: оно могло продлиться heartbeat-ами
+		pending := make([]activitywatch.IngestEvent, 0, len(events))
+		var last time.Time
+		for _, event := range events {
+			if event.Timestamp.Before(since) {
+				continue
+			}
+			last = event.Timestamp
+			if ingest, ok := activitywatch.ToIngest(bucket, event); ok {
+				pending = append(pending, ingest)
+			}
+		}
+		if last.IsZero() {
+			continue
+		}
+
+		for start := 0; start < len(pending); start += a.cfg.batchSize {
+			end := min(start+a.cfg.batchSize, len(pending))
+			batch := pending[start:end]
+
+			if online {
+				err := send(batch)
+				var rejected *rejectedError
+				switch {
+				case err == nil:
+					sent += len(batch)
+					continue
+				case errors.As(err, &rejected):
+					log.Error().Err(err).Str("bucket_id", bucket.ID).Int("events", len(batch)).Msg("lake rejected batch")
+					continue
+				default:
+					log.Warn().Err(err).Msg("lake unavailable, buffering events")
+					online = false
+				}
+			}
+
+			if err := a.buffer.Add(batch); err != nil {
+				return fmt.Errorf("failed to buffer events: %w", err)
+			}
+			buffered += len(batch)
+		}
+
+		// События отправлены или лежат в буфере, водяной знак можно сдвигать
+		state.Watermarks[bucket.ID] = last
+		if err := state.save(a.cfg.stateDir); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+	}
+
+	log.Info().Int("sent", sent).Int("buffered", buffered).Int("buckets", len(buckets)).Msg("sync completed")
+	return nil
+}
+
+func envOr(key, def string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return def
+}
+
+// defaultStateDir ~/.config/datalake/aw-client (или аналог для ОС)
+func defaultStateDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".aw-client"
+	}
+	return filepath.Join(dir, "datalake", "aw-client")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"DataLake/activitywatch"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// State водяные знаки по бакетам: время начала последнего отправленного события
+type State struct {
+	Watermarks map[string]time.Time `json:"watermarks"`
+}
+
+// loadState читает состояние из dir/state.json, отсутствие файла - пустое состояние
+func loadState(dir string) (*State, error) {
+	state := &State{Watermarks: map[string]time.Time{}}
+
+	data, err := os.ReadFile(filepath.Join(dir, "state.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to decode state: %w", err)
+	}
+	if state.Watermarks == nil {
+		state.Watermarks = map[string]time.Time{}
+	}
+	return state, nil
+}
+
+// save атомарно записывает состояние, чтобы прерванный запуск не испортил водяные знаки
+func (s *State) save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, "state.json"), data)
+}
+
+// Buffer очередь пачек на диске на время недоступности lake.
+// Каждая пачка - отдельный файл, имена упорядочены по времени добавления
+type Buffer struct {
+	dir string
+	seq int
+}
+
+func newBuffer(dir string) (*Buffer, error) {
+	dir = filepath.Join(dir, "buffer")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create buffer directory: %w", err)
+	}
+	return &Buffer{dir: dir}, nil
+}
+
+// Add сохраняет пачку в конец очереди
+func (b *Buffer) Add(events []activitywatch.IngestEvent) error {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	b.seq++
+	name := fmt.Sprintf("%020d-%04d.json", time.Now().UnixNano(), b.seq)
+	return writeFileAtomic(filepath.Join(b.dir, name), data)
+}
+
+// Files возвращает пачки очереди в порядке добавления
+func (b *Buffer) Files() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read buffer directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, filepath.Join(b.dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Flush отправляет пачки из очереди по порядку и останавливается на первой временной ошибке.
+// Пачки, которые lake отверг окончательно, удаляются, чтобы не блокировать очередь
+func (b *Buffer) Flush(send func([]activitywatch.IngestEvent) error) (int, error) {
+	files, err := b.Files()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return sent, fmt.Errorf("failed to read buffered batch: %w", err)
+		}
+
+		var events []activitywatch.IngestEvent
+		if err := json.Unmarshal(data, &events); err != nil {
+			log.Error().Err(err).Str("file", file).Msg("dropping corrupted buffered batch")
+			_ = os.Remove(file)
+			continue
+		}
+
+		err = send(events)
+		var rejected *rejectedError
+		if errors.As(err, &rejected) {
+			log.Error().Err(err).Str("file", file).Int("events", len(events)).Msg("dropping buffered batch rejected by lake")
+		} else if err != nil {
+			return sent, err
+		} else {
+			sent += len(events)
+		}
+
+		if err := os.Remove(file); err != nil {
+			return sent, fmt.Errorf("failed to remove buffered batch: %w", err)
+		}
+	}
+	return sent, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
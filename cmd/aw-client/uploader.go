@@ -0,0 +1,101 @@
+package main
+
+import (
+	"DataLake/activitywatch"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// rejectedError lake принял запрос, но отверг пачку (400, 413, 422): повтор не поможет
+type rejectedError struct {
+	status int
+	body   string
+}
+
+func (e *rejectedError) Error() string {
+	return fmt.Sprintf("lake rejected batch with status %d: %s", e.status, e.body)
+}
+
+// Uploader отправляет пачки событий в POST /api/v1/activitywatch/events
+// и экспорт aw-server в POST /api/v1/activitywatch/import
+type Uploader struct {
+	endpoint       string
+	importEndpoint string
+	apiKey         string
+	client         *http.Client
+}
+
+func newUploader(server, apiKey string) *Uploader {
+	base := strings.TrimRight(server, "/") + "/api/v1/activitywatch"
+	return &Uploader{
+		endpoint:       base + "/events",
+		importEndpoint: base + "/import",
+		apiKey:         apiKey,
+		client:         &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Send отправляет пачку, сжатую gzip. Сетевые ошибки, 401, 429 и 5xx считаются временными:
+// пачка остаётся в буфере до следующей попытки. События, которые lake отклонил при проверке,
+// только логируются: повторная отправка их не исправит
+func (u *Uploader) Send(ctx context.Context, events []activitywatch.IngestEvent) error {
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	if err := json.NewEncoder(zw).Encode(events); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u.endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	if u.apiKey != "" {
+		req.Header.Set("X-API-Key", u.apiKey)
+	}
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		var result struct {
+			Rejected int `json:"rejected"`
+			Errors   []struct {
+				Index int    `json:"index"`
+				Error string `json:"error"`
+			} `json:"errors"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Rejected > 0 {
+			for _, e := range result.Errors {
+				if e.Index < 0 || e.Index >= len(events) {
+					continue
+				}
+				log.Warn().Str("bucket_id", events[e.Index].BucketID).Time("timestamp", events[e.Index].Timestamp).Str("error", e.Error).Msg("lake rejected event")
+			}
+			log.Warn().Int("rejected", result.Rejected).Int("events", len(events)).Msg("lake rejected some events")
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return &rejectedError{status: resp.StatusCode, body: string(respBody)}
+	}
+	return fmt.Errorf("lake returned status %d: %s", resp.StatusCode, string(respBody))
+}
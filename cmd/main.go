@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"DataLake/auth"
+	caldavauth "DataLake/auth/caldav"
 	googlecalendarauth "DataLake/auth/googlecalendar"
 	googlefitauth "DataLake/auth/googlefit"
 	wakatimeauth "DataLake/auth/wakatime"
@@ -66,11 +68,33 @@ func main() {
 		}
 	}
 
-	wakatimeProvider := wakatimeauth.NewProvider(
-		os.Getenv("CLIENT_ID"),
-		os.Getenv("CLIENT_SECRET"),
-		os.Getenv("REDIRECT_URI"),
-	)
+	wakatimeProvider := wakatimeauth.NewProviderFromEnv()
+
+	// API ключ WakaTime из окружения сохраняем в зашифрованное хранилище,
+	// чтобы сборщик работал без OAuth приложения
+	if wakatimeProvider.HasAPIKey() {
+		storage, err := auth.NewFileTokenStorageFromEnv("tokens.json")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize token storage")
+		}
+		if err := storage.SaveToken("wakatime", wakatimeProvider.APIKeyToken()); err != nil {
+			log.Fatal().Err(err).Msg("failed to save wakatime api key")
+		}
+		log.Info().Str("api_url", wakatimeProvider.APIURL()).Msg("using wakatime api key authentication")
+	}
+
+	// Пароль приложения CalDAV из окружения тоже переносим в зашифрованное хранилище
+	caldavProvider := caldavauth.NewProviderFromEnv()
+	if caldavProvider.Configured() && caldavProvider.HasAppPassword() {
+		storage, err := auth.NewFileTokenStorageFromEnv("tokens.json")
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to initialize token storage")
+		}
+		if err := storage.SaveToken(caldavauth.ProviderName, caldavProvider.AppPasswordToken()); err != nil {
+			log.Fatal().Err(err).Msg("failed to save caldav app password")
+		}
+		log.Info().Str("server_url", caldavProvider.ServerURL()).Msg("using caldav app password authentication")
+	}
 
 	err := db.Connect()
 	if err != nil {
@@ -0,0 +1,29 @@
+-- Инкрементальное сохранение WakaTime: хеш содержимого дня и уникальность (day_id, name)
+
+ALTER TABLE wakatime_days ADD COLUMN IF NOT EXISTS content_hash TEXT;
+
+-- Удаляем дубликаты, оставляя самую свежую запись
+DELETE FROM wakatime_projects a USING wakatime_projects b
+WHERE a.day_id = b.day_id AND a.name = b.name AND a.id < b.id;
+
+DELETE FROM wakatime_languages a USING wakatime_languages b
+WHERE a.day_id = b.day_id AND a.name = b.name AND a.id < b.id;
+
+DELETE FROM wakatime_editors a USING wakatime_editors b
+WHERE a.day_id = b.day_id AND a.name = b.name AND a.id < b.id;
+
+DELETE FROM wakatime_os a USING wakatime_os b
+WHERE a.day_id = b.day_id AND a.name = b.name AND a.id < b.id;
+
+DELETE FROM wakatime_dependencies a USING wakatime_dependencies b
+WHERE a.day_id = b.day_id AND a.name = b.name AND a.id < b.id;
+
+DELETE FROM wakatime_machines a USING wakatime_machines b
+WHERE a.day_id = b.day_id AND a.name = b.name AND a.id < b.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS wakatime_projects_day_name_unique ON wakatime_projects(day_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS wakatime_languages_day_name_unique ON wakatime_languages(day_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS wakatime_editors_day_name_unique ON wakatime_editors(day_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS wakatime_os_day_name_unique ON wakatime_os(day_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS wakatime_dependencies_day_name_unique ON wakatime_dependencies(day_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS wakatime_machines_day_name_unique ON wakatime_machines(day_id, name);
@@ -0,0 +1,43 @@
+-- Google Fit: пульс, калории, минуты активности и сон
+
+-- Google Fit: пульс по дням
+CREATE TABLE IF NOT EXISTS googlefit_daily_heart_rate (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    min_bpm FLOAT NOT NULL,
+    avg_bpm FLOAT NOT NULL,
+    max_bpm FLOAT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_daily_heart_rate_unique UNIQUE(user_id, date)
+);
+
+-- Google Fit: калории, минуты активности и баллы кардиотренировок по дням
+CREATE TABLE IF NOT EXISTS googlefit_daily_activity (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    calories FLOAT DEFAULT 0, -- в ккал
+    move_minutes INT DEFAULT 0,
+    heart_points FLOAT DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_daily_activity_unique UNIQUE(user_id, date)
+);
+
+-- Google Fit: сегменты сна со стадиями
+-- stage: 1 - бодрствование, 2 - сон, 3 - вне кровати, 4 - лёгкий, 5 - глубокий, 6 - REM
+CREATE TABLE IF NOT EXISTS googlefit_sleep_segments (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    stage INT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_sleep_segments_unique UNIQUE(user_id, start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_daily_heart_rate_user_date ON googlefit_daily_heart_rate(user_id, date DESC);
+CREATE INDEX IF NOT EXISTS idx_googlefit_daily_activity_user_date ON googlefit_daily_activity(user_id, date DESC);
+CREATE INDEX IF NOT EXISTS idx_googlefit_sleep_segments_user_end ON googlefit_sleep_segments(user_id, end_time DESC);
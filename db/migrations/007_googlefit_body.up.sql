@@ -0,0 +1,13 @@
+-- Google Fit: измерения тела (вес, процент жира, рост) на момент времени
+-- metric: weight (кг), body_fat (%), height (м)
+CREATE TABLE IF NOT EXISTS googlefit_body_measurements (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    metric TEXT NOT NULL,
+    measured_at TIMESTAMPTZ NOT NULL,
+    value FLOAT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_body_measurements_unique UNIQUE(user_id, metric, measured_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_body_measurements_user_metric ON googlefit_body_measurements(user_id, metric, measured_at DESC);
@@ -0,0 +1,23 @@
+-- Google Fit: сессии (тренировки) с агрегатами за сессию
+-- activity_type: https://developers.google.com/fit/rest/v1/reference/activity-types
+CREATE TABLE IF NOT EXISTS googlefit_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id TEXT NOT NULL,
+    name TEXT,
+    description TEXT,
+    activity_type INT NOT NULL,
+    application TEXT,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    steps INT DEFAULT 0,
+    distance FLOAT DEFAULT 0, -- в метрах
+    calories FLOAT DEFAULT 0, -- в ккал
+    avg_bpm FLOAT,
+    max_bpm FLOAT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_sessions_unique UNIQUE(user_id, session_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_sessions_user_start ON googlefit_sessions(user_id, start_time DESC);
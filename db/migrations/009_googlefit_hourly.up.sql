@@ -0,0 +1,15 @@
+-- Google Fit: почасовая статистика (начало часа хранится в UTC)
+CREATE TABLE IF NOT EXISTS googlefit_hourly_stats (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hour_start TIMESTAMPTZ NOT NULL,
+    steps INT DEFAULT 0,
+    distance FLOAT DEFAULT 0, -- в метрах
+    calories FLOAT DEFAULT 0, -- в ккал
+    move_minutes INT DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_hourly_stats_unique UNIQUE(user_id, hour_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_hourly_stats_user_hour ON googlefit_hourly_stats(user_id, hour_start DESC);
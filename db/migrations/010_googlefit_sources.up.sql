@@ -0,0 +1,38 @@
+-- Google Fit: источники данных (устройства и приложения)
+CREATE TABLE IF NOT EXISTS googlefit_data_sources (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    data_source_id TEXT NOT NULL,
+    data_type TEXT NOT NULL,
+    type TEXT NOT NULL, -- raw или derived
+    device_manufacturer TEXT,
+    device_model TEXT,
+    device_type TEXT,
+    application TEXT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_data_sources_unique UNIQUE(user_id, data_source_id)
+);
+
+-- Google Fit: дневные суммы по каждому источнику (по originDataSourceId)
+CREATE TABLE IF NOT EXISTS googlefit_source_daily_stats (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    data_source_id TEXT NOT NULL,
+    data_type TEXT NOT NULL,
+    date DATE NOT NULL,
+    value FLOAT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_source_daily_stats_unique UNIQUE(user_id, data_source_id, date)
+);
+
+-- Google Fit: предпочитаемый источник для типа данных
+CREATE TABLE IF NOT EXISTS googlefit_preferred_sources (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    data_type TEXT NOT NULL,
+    data_source_id TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (user_id, data_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_source_daily_stats_user_date ON googlefit_source_daily_stats(user_id, date DESC);
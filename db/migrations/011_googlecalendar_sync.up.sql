@@ -0,0 +1,9 @@
+-- Google Calendar: состояние инкрементальной синхронизации по календарю
+CREATE TABLE IF NOT EXISTS googlecalendar_sync_state (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    calendar_id VARCHAR(255) NOT NULL,
+    sync_token TEXT,
+    last_full_sync_at TIMESTAMPTZ,
+    last_sync_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, calendar_id)
+);
@@ -0,0 +1,19 @@
+-- Google Calendar: календари пользователя и настройки синхронизации
+-- sync_enabled - забирать ли события календаря, included - учитывать ли их в API и аналитике
+CREATE TABLE IF NOT EXISTS googlecalendar_calendars (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    calendar_id VARCHAR(255) NOT NULL,
+    summary TEXT,
+    description TEXT,
+    time_zone VARCHAR(64),
+    background_color VARCHAR(16),
+    foreground_color VARCHAR(16),
+    access_role VARCHAR(32),
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    sync_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    included BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlecalendar_calendars_unique UNIQUE(user_id, calendar_id)
+);
@@ -0,0 +1,29 @@
+-- Google Calendar: организатор, повторения, прозрачность, тип события и участники
+
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS organizer_email TEXT;
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS organizer_name TEXT;
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS recurring_event_id VARCHAR(255);
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS recurrence TEXT[];
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS transparency VARCHAR(20) DEFAULT 'opaque';
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS event_type VARCHAR(32) DEFAULT 'default';
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS response_status VARCHAR(20);
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS attendees_count INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS googlecalendar_event_attendees (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    email TEXT NOT NULL,
+    display_name TEXT,
+    response_status VARCHAR(20), -- needsAction, declined, tentative, accepted
+    is_organizer BOOLEAN NOT NULL DEFAULT FALSE,
+    is_self BOOLEAN NOT NULL DEFAULT FALSE,
+    is_optional BOOLEAN NOT NULL DEFAULT FALSE,
+    is_resource BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT googlecalendar_event_attendees_unique UNIQUE(user_id, event_id, email),
+    CONSTRAINT googlecalendar_event_attendees_event_fk FOREIGN KEY (user_id, event_id)
+        REFERENCES googlecalendar_events(user_id, event_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlecalendar_events_recurring ON googlecalendar_events(user_id, recurring_event_id);
+CREATE INDEX IF NOT EXISTS idx_googlecalendar_event_attendees_email ON googlecalendar_event_attendees(user_id, email);
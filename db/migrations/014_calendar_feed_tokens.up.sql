@@ -0,0 +1,14 @@
+-- Экспорт календаря: секретные токены подписок на /api/v1/calendar.ics
+-- Хранится только sha256 токена, сам токен показывается один раз при создании
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    include_events BOOLEAN NOT NULL DEFAULT TRUE,
+    include_wakatime BOOLEAN NOT NULL DEFAULT FALSE,
+    include_activitywatch BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    CONSTRAINT calendar_feed_tokens_hash_unique UNIQUE(token_hash)
+);
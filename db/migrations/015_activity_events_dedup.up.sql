@@ -0,0 +1,17 @@
+-- Идемпотентная загрузка ActivityWatch: естественный ключ (bucket_id, timestamp).
+-- aw-server продлевает последнее событие heartbeat-ами, не меняя его timestamp,
+-- поэтому повторная отправка события обновляет duration существующей строки
+
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS aw_event_id BIGINT;
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT NOW();
+
+-- Удаляем дубликаты, оставляя самую длинную (последнюю) версию события
+DELETE FROM activity_events a USING activity_events b
+WHERE a.bucket_id = b.bucket_id
+  AND a.timestamp = b.timestamp
+  AND (a.duration < b.duration OR (a.duration = b.duration AND a.id < b.id));
+
+CREATE UNIQUE INDEX IF NOT EXISTS activity_events_bucket_timestamp_unique ON activity_events(bucket_id, timestamp);
+
+-- Покрывается уникальным индексом
+DROP INDEX IF EXISTS idx_activity_bucket;
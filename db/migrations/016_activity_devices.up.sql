@@ -0,0 +1,57 @@
+-- ActivityWatch: события принадлежат пользователю и устройству.
+-- Устройство определяется hostname бакета aw-server (aw-watcher-window_<hostname>)
+
+CREATE TABLE IF NOT EXISTS activity_devices (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hostname TEXT NOT NULL,
+    first_seen TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT activity_devices_unique UNIQUE(user_id, hostname)
+);
+
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS device_id BIGINT REFERENCES activity_devices(id) ON DELETE SET NULL;
+
+-- Раньше события не были привязаны к пользователю. Владелец - пользователь API_USER_ID, его передают
+-- настройкой datalake.api_user_id (PGOPTIONS="-c datalake.api_user_id=$API_USER_ID" psql -f ...);
+-- без неё события отдаются единственному пользователю. Если владельца определить нельзя,
+-- миграция прерывается, а не удаляет события
+DO $$
+DECLARE
+    owner_id UUID := NULLIF(current_setting('datalake.api_user_id', true), '')::uuid;
+BEGIN
+    IF owner_id IS NULL AND (SELECT COUNT(*) FROM users) = 1 THEN
+        SELECT id INTO owner_id FROM users;
+    END IF;
+    IF owner_id IS NOT NULL THEN
+        UPDATE activity_events SET user_id = owner_id WHERE user_id IS NULL;
+    END IF;
+    IF EXISTS (SELECT 1 FROM activity_events WHERE user_id IS NULL) THEN
+        RAISE EXCEPTION 'activity_events has events without user_id'
+            USING HINT = 'Create the API_USER_ID user and run the migration with PGOPTIONS="-c datalake.api_user_id=<API_USER_ID>"';
+    END IF;
+END $$;
+ALTER TABLE activity_events ALTER COLUMN user_id SET NOT NULL;
+
+INSERT INTO activity_devices (user_id, hostname, first_seen, last_seen)
+SELECT user_id, substring(bucket_id from '^[^_]+_(.+)$'), MIN(timestamp), MAX(timestamp)
+FROM activity_events
+WHERE bucket_id ~ '^[^_]+_.+$'
+GROUP BY 1, 2
+ON CONFLICT (user_id, hostname) DO NOTHING;
+
+UPDATE activity_events e SET device_id = d.id
+FROM activity_devices d
+WHERE e.device_id IS NULL
+  AND d.user_id = e.user_id
+  AND d.hostname = substring(e.bucket_id from '^[^_]+_(.+)$');
+
+-- Естественный ключ события теперь в пределах пользователя
+DROP INDEX IF EXISTS activity_events_bucket_timestamp_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS activity_events_user_bucket_timestamp_unique ON activity_events(user_id, bucket_id, timestamp);
+
+DROP INDEX IF EXISTS idx_activity_timestamp;
+CREATE INDEX IF NOT EXISTS idx_activity_events_user_time ON activity_events(user_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_activity_events_device_time ON activity_events(device_id, timestamp DESC);
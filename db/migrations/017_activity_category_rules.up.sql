@@ -0,0 +1,15 @@
+-- Правила категоризации ActivityWatch: регулярное выражение по app, title или url
+-- и путь категории, например {Work, Programming, Go}. Применяются при запросе статистики
+CREATE TABLE IF NOT EXISTS activity_category_rules (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category TEXT[] NOT NULL,
+    field VARCHAR(10) NOT NULL DEFAULT 'any', -- app, title, url или any
+    pattern TEXT NOT NULL,
+    ignore_case BOOLEAN NOT NULL DEFAULT TRUE,
+    color VARCHAR(7), -- #rrggbb
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_category_rules_user ON activity_category_rules(user_id);
@@ -0,0 +1,6 @@
+-- События aw-watcher-web: адрес вкладки, нормализованный домен (нижний регистр, без www и порта),
+-- звук и режим инкогнито. title таких событий - заголовок вкладки, app - браузер
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS url TEXT;
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS domain TEXT;
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS audible BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE activity_events ADD COLUMN IF NOT EXISTS incognito BOOLEAN NOT NULL DEFAULT FALSE;
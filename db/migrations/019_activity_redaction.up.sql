@@ -0,0 +1,24 @@
+-- Правила редактирования заголовков и адресов ActivityWatch, применяются при приёме событий
+-- и задним числом через POST /activitywatch/redaction/apply.
+-- app - регулярное выражение по приложению, pattern - по полю field (пустые подходят всем)
+CREATE TABLE IF NOT EXISTS activity_redaction_rules (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    app TEXT NOT NULL DEFAULT '',
+    field VARCHAR(10) NOT NULL DEFAULT 'title', -- title или url
+    pattern TEXT NOT NULL DEFAULT '',
+    action VARCHAR(10) NOT NULL, -- drop, hash или replace
+    replacement TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_redaction_rules_user ON activity_redaction_rules(user_id);
+
+-- Приватные приложения: от их событий остаются только приложение и длительность
+CREATE TABLE IF NOT EXISTS activity_private_apps (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    app TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (user_id, app)
+);
@@ -0,0 +1,6 @@
+-- Google Calendar: исходные UID и href событий iCalendar и CalDAV.
+-- event_id экземпляров CalDAV - хеши фиксированной длины (href и UID бывают длиннее VARCHAR(255)),
+-- поэтому читаемые значения хранятся отдельно
+
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS ical_uid TEXT;
+ALTER TABLE googlecalendar_events ADD COLUMN IF NOT EXISTS source_href TEXT;
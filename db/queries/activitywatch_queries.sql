@@ -1,32 +1,190 @@
--- name: BulkInsertEvents :copyfrom
+-- Повторная отправка события обновляет его: heartbeat-ы aw-server продлевают duration.
+-- inserted = false для обновлённых строк, для неизменных строка не возвращается
+-- name: UpsertEvents :batchone
 INSERT INTO activity_events (
+    user_id,
     timestamp,
     duration,
     app,
     title,
-    bucket_id
+    bucket_id,
+    aw_event_id,
+    device_id,
+    url,
+    domain,
+    audible,
+    incognito
 ) VALUES (
-    $1, $2, $3, $4, $5
-);
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+ON CONFLICT (user_id, bucket_id, timestamp)
+DO UPDATE SET
+    duration = GREATEST(activity_events.duration, EXCLUDED.duration),
+    app = EXCLUDED.app,
+    title = EXCLUDED.title,
+    aw_event_id = COALESCE(EXCLUDED.aw_event_id, activity_events.aw_event_id),
+    device_id = COALESCE(EXCLUDED.device_id, activity_events.device_id),
+    url = EXCLUDED.url,
+    domain = EXCLUDED.domain,
+    audible = EXCLUDED.audible,
+    incognito = EXCLUDED.incognito,
+    updated_at = now()
+WHERE (activity_events.duration, activity_events.app, activity_events.title, activity_events.aw_event_id, activity_events.device_id,
+       activity_events.url, activity_events.audible, activity_events.incognito)
+    IS DISTINCT FROM (GREATEST(activity_events.duration, EXCLUDED.duration), EXCLUDED.app, EXCLUDED.title, COALESCE(EXCLUDED.aw_event_id, activity_events.aw_event_id), COALESCE(EXCLUDED.device_id, activity_events.device_id),
+       EXCLUDED.url, EXCLUDED.audible, EXCLUDED.incognito)
+RETURNING (xmax = 0) AS inserted;
 
--- name: GetAppStats :many
+-- name: UpsertDevice :one
+INSERT INTO activity_devices (
+    user_id,
+    hostname,
+    first_seen,
+    last_seen
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (user_id, hostname)
+DO UPDATE SET
+    first_seen = LEAST(activity_devices.first_seen, EXCLUDED.first_seen),
+    last_seen = GREATEST(activity_devices.last_seen, EXCLUDED.last_seen)
+RETURNING id;
+
+-- name: ListDevices :many
+SELECT * FROM activity_devices
+WHERE user_id = $1
+ORDER BY last_seen DESC;
+
+-- События, пересекающие [start_time, end_time), с hostname устройства.
+-- Периоды not-afk длятся часами, поэтому начало события ищется с запасом в сутки
+-- name: ListEventSpans :many
 SELECT
-    app,
-    SUM(duration)::float as total_duration,
-    COUNT(*) as event_count
-FROM activity_events
-WHERE timestamp >= $1 AND timestamp < $2
-GROUP BY app
-ORDER BY total_duration DESC;
-
--- name: GetRecentEvents :many
-SELECT * FROM activity_events
-WHERE timestamp >= $1
-ORDER BY timestamp DESC
-LIMIT $2;
+    e.device_id,
+    d.hostname,
+    e.app,
+    e.title,
+    e.timestamp,
+    e.duration,
+    e.bucket_id,
+    e.url,
+    e.domain
+FROM activity_events e
+LEFT JOIN activity_devices d ON d.id = e.device_id
+WHERE e.user_id = sqlc.arg(user_id)
+  AND e.timestamp >= sqlc.arg(start_time)::timestamptz - interval '1 day'
+  AND e.timestamp < sqlc.arg(end_time)
+  AND e.timestamp + make_interval(secs => e.duration) > sqlc.arg(start_time)
+  AND (cardinality(sqlc.arg(hostnames)::text[]) = 0 OR d.hostname = ANY(sqlc.arg(hostnames)::text[]))
+ORDER BY e.timestamp;
 
 -- name: GetEventsByApp :many
-SELECT * FROM activity_events
-WHERE app = $1 AND timestamp >= $2 AND timestamp < $3
-ORDER BY timestamp DESC;
+SELECT
+    e.id,
+    e.timestamp,
+    e.duration,
+    e.title,
+    e.url,
+    e.bucket_id,
+    d.hostname
+FROM activity_events e
+LEFT JOIN activity_devices d ON d.id = e.device_id
+WHERE e.user_id = sqlc.arg(user_id) AND e.app = sqlc.arg(app)
+  AND e.timestamp >= sqlc.arg(start_time) AND e.timestamp < sqlc.arg(end_time)
+  AND (cardinality(sqlc.arg(hostnames)::text[]) = 0 OR d.hostname = ANY(sqlc.arg(hostnames)::text[]))
+ORDER BY e.timestamp DESC, e.id DESC
+LIMIT sqlc.narg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountEventsByApp :one
+SELECT COUNT(*) FROM activity_events e
+LEFT JOIN activity_devices d ON d.id = e.device_id
+WHERE e.user_id = sqlc.arg(user_id) AND e.app = sqlc.arg(app)
+  AND e.timestamp >= sqlc.arg(start_time) AND e.timestamp < sqlc.arg(end_time)
+  AND (cardinality(sqlc.arg(hostnames)::text[]) = 0 OR d.hostname = ANY(sqlc.arg(hostnames)::text[]));
+
+-- name: ListCategoryRules :many
+SELECT * FROM activity_category_rules
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: CreateCategoryRule :one
+INSERT INTO activity_category_rules (
+    user_id, category, field, pattern, ignore_case, color
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: UpdateCategoryRule :one
+UPDATE activity_category_rules SET
+    category = sqlc.arg(category),
+    field = sqlc.arg(field),
+    pattern = sqlc.arg(pattern),
+    ignore_case = sqlc.arg(ignore_case),
+    color = sqlc.arg(color),
+    updated_at = now()
+WHERE user_id = sqlc.arg(user_id) AND id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteCategoryRule :execrows
+DELETE FROM activity_category_rules
+WHERE user_id = $1 AND id = $2;
+
+-- name: DeleteAllCategoryRules :exec
+DELETE FROM activity_category_rules
+WHERE user_id = $1;
+
+-- name: ListRedactionRules :many
+SELECT * FROM activity_redaction_rules
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: CreateRedactionRule :one
+INSERT INTO activity_redaction_rules (
+    user_id, app, field, pattern, action, replacement
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: UpdateRedactionRule :one
+UPDATE activity_redaction_rules SET
+    app = sqlc.arg(app),
+    field = sqlc.arg(field),
+    pattern = sqlc.arg(pattern),
+    action = sqlc.arg(action),
+    replacement = sqlc.arg(replacement),
+    updated_at = now()
+WHERE user_id = sqlc.arg(user_id) AND id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteRedactionRule :execrows
+DELETE FROM activity_redaction_rules
+WHERE user_id = $1 AND id = $2;
+
+-- name: ListPrivateApps :many
+SELECT app FROM activity_private_apps
+WHERE user_id = $1
+ORDER BY app;
+
+-- name: AddPrivateApp :exec
+INSERT INTO activity_private_apps (user_id, app)
+VALUES ($1, $2)
+ON CONFLICT (user_id, app) DO NOTHING;
+
+-- name: DeleteAllPrivateApps :exec
+DELETE FROM activity_private_apps
+WHERE user_id = $1;
+
+-- name: ListEventsForRedaction :many
+SELECT id, app, title, url, domain FROM activity_events
+WHERE user_id = sqlc.arg(user_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
 
+-- name: RedactEvent :exec
+UPDATE activity_events SET
+    title = sqlc.arg(title),
+    url = sqlc.arg(url),
+    domain = sqlc.arg(domain),
+    updated_at = now()
+WHERE user_id = sqlc.arg(user_id) AND id = sqlc.arg(id);
@@ -47,9 +47,11 @@ DELETE FROM googlecalendar_events WHERE id = $1;
 -- name: UpsertEvent :one
 INSERT INTO googlecalendar_events (
     user_id, event_id, calendar_id, summary, description, location,
-    start_time, end_time, is_all_day, duration, status
+    start_time, end_time, is_all_day, duration, status,
+    organizer_email, organizer_name, recurring_event_id, recurrence,
+    transparency, event_type, response_status, attendees_count, ical_uid, source_href
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 ON CONFLICT (user_id, event_id)
 DO UPDATE SET
     calendar_id = EXCLUDED.calendar_id,
@@ -61,21 +63,72 @@ DO UPDATE SET
     is_all_day = EXCLUDED.is_all_day,
     duration = EXCLUDED.duration,
     status = EXCLUDED.status,
+    organizer_email = EXCLUDED.organizer_email,
+    organizer_name = EXCLUDED.organizer_name,
+    recurring_event_id = EXCLUDED.recurring_event_id,
+    recurrence = EXCLUDED.recurrence,
+    transparency = EXCLUDED.transparency,
+    event_type = EXCLUDED.event_type,
+    response_status = EXCLUDED.response_status,
+    attendees_count = EXCLUDED.attendees_count,
+    ical_uid = EXCLUDED.ical_uid,
+    source_href = EXCLUDED.source_href,
     updated_at = now()
 RETURNING *;
 
+-- name: CancelEvent :execrows
+UPDATE googlecalendar_events
+SET status = 'cancelled', updated_at = now()
+WHERE user_id = $1 AND event_id = $2;
+
+-- Фиды iCalendar приходят полным снимком: события окна, которых нет в снимке, отменены.
+-- События с префиксами из keep_prefixes не трогаются (ресурсы CalDAV, которые не удалось загрузить)
+-- name: CancelMissingEvents :execrows
+UPDATE googlecalendar_events
+SET status = 'cancelled', updated_at = now()
+WHERE user_id = sqlc.arg(user_id)
+  AND calendar_id = sqlc.arg(calendar_id)
+  AND end_time > sqlc.arg(start_time)
+  AND start_time < sqlc.arg(end_time)
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND event_id <> ALL(sqlc.arg(event_ids)::text[])
+  AND NOT EXISTS (
+      SELECT 1 FROM unnest(sqlc.arg(keep_prefixes)::text[]) AS p(prefix)
+      WHERE starts_with(event_id, p.prefix)
+  );
+
+-- Отменяет экземпляры одного ресурса CalDAV (event_id с префиксом prefix), кроме перечисленных
+-- name: CancelEventsByPrefix :execrows
+UPDATE googlecalendar_events
+SET status = 'cancelled', updated_at = now()
+WHERE user_id = sqlc.arg(user_id)
+  AND calendar_id = sqlc.arg(calendar_id)
+  AND starts_with(event_id, sqlc.arg(prefix)::text)
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND event_id <> ALL(sqlc.arg(event_ids)::text[]);
+
 -- Analytics Queries -------------------------------------------------------------------
 
+-- Аналитика учитывает только занятое время: без отменённых, свободных (transparent)
+-- и отклонённых событий, а также без событий из исключённых календарей.
+-- События на весь день не входят в длительность
 -- name: GetDailyEventsSummary :many
 SELECT
     DATE(start_time) as event_date,
-    COUNT(*) as total_events,
-    SUM(duration) as total_duration_minutes,
-    COUNT(CASE WHEN is_all_day THEN 1 END) as all_day_events
+    COUNT(*) FILTER (WHERE NOT COALESCE(is_all_day, false)) as total_events,
+    COALESCE(SUM(duration) FILTER (WHERE NOT COALESCE(is_all_day, false)), 0)::bigint as total_duration_minutes,
+    COUNT(*) FILTER (WHERE is_all_day) as all_day_events
 FROM googlecalendar_events
 WHERE user_id = $1
   AND start_time >= $2
   AND end_time <= $3
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND COALESCE(transparency, 'opaque') <> 'transparent'
+  AND COALESCE(response_status, '') <> 'declined'
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = $1 AND NOT c.included
+  )
 GROUP BY DATE(start_time)
 ORDER BY event_date DESC;
 
@@ -83,11 +136,15 @@ ORDER BY event_date DESC;
 SELECT
     calendar_id,
     COUNT(*) as total_events,
-    SUM(duration) as total_duration_minutes
+    COALESCE(SUM(duration), 0)::bigint as total_duration_minutes
 FROM googlecalendar_events
 WHERE user_id = $1
   AND start_time >= $2
   AND end_time <= $3
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND NOT COALESCE(is_all_day, false)
+  AND COALESCE(transparency, 'opaque') <> 'transparent'
+  AND COALESCE(response_status, '') <> 'declined'
 GROUP BY calendar_id
 ORDER BY total_events DESC;
 
@@ -95,18 +152,26 @@ ORDER BY total_events DESC;
 SELECT
     DATE(start_time) as event_date,
     COUNT(*) as event_count,
-    SUM(duration) as total_duration_minutes
+    COALESCE(SUM(duration), 0)::bigint as total_duration_minutes
 FROM googlecalendar_events
 WHERE user_id = $1
   AND start_time >= CURRENT_DATE - INTERVAL '30 days'
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND NOT COALESCE(is_all_day, false)
+  AND COALESCE(transparency, 'opaque') <> 'transparent'
+  AND COALESCE(response_status, '') <> 'declined'
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = $1 AND NOT c.included
+  )
 GROUP BY DATE(start_time)
 ORDER BY event_count DESC, total_duration_minutes DESC
 LIMIT $2;
 
 -- name: GetAverageDailyEvents :one
 SELECT
-    AVG(event_count)::FLOAT as avg_events_per_day,
-    AVG(total_duration)::FLOAT as avg_duration_per_day
+    COALESCE(AVG(event_count), 0)::FLOAT as avg_events_per_day,
+    COALESCE(AVG(total_duration), 0)::FLOAT as avg_duration_per_day
 FROM (
     SELECT
         DATE(start_time) as event_date,
@@ -116,22 +181,201 @@ FROM (
     WHERE user_id = $1
       AND start_time >= $2
       AND end_time <= $3
+      AND COALESCE(status, 'confirmed') <> 'cancelled'
+      AND NOT COALESCE(is_all_day, false)
+      AND COALESCE(transparency, 'opaque') <> 'transparent'
+      AND COALESCE(response_status, '') <> 'declined'
+      AND calendar_id NOT IN (
+          SELECT c.calendar_id FROM googlecalendar_calendars c
+          WHERE c.user_id = $1 AND NOT c.included
+      )
     GROUP BY DATE(start_time)
 ) AS daily_stats;
 
+-- Встречи, занимающие время: без отменённых, свободных, отклонённых, на весь день,
+-- служебных типов (отсутствие, время фокуса, место работы) и из исключённых календарей
+-- name: ListBusyEventsByRange :many
+SELECT
+    event_id,
+    summary,
+    start_time,
+    end_time,
+    attendees_count
+FROM googlecalendar_events
+WHERE user_id = sqlc.arg(user_id)
+  AND end_time > sqlc.arg(start_time)
+  AND start_time < sqlc.arg(end_time)
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND NOT COALESCE(is_all_day, false)
+  AND COALESCE(transparency, 'opaque') <> 'transparent'
+  AND COALESCE(response_status, '') <> 'declined'
+  AND COALESCE(event_type, 'default') NOT IN ('outOfOffice', 'focusTime', 'workingLocation')
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = sqlc.arg(user_id) AND NOT c.included
+  )
+ORDER BY start_time ASC;
 
+-- status: пустая строка - все кроме отменённых, 'all' - все, иначе точное совпадение
 -- name: GetCalendarEventsByDateRange :many
 SELECT
     event_id,
     summary,
     description,
     start_time,
-    end_time
+    end_time,
+    status,
+    calendar_id,
+    is_all_day,
+    organizer_email,
+    recurring_event_id,
+    transparency,
+    event_type,
+    response_status,
+    attendees_count
 FROM
     googlecalendar_events
 WHERE
-    user_id = $1
-  AND start_time >= $2
-  AND start_time <= $3
+    user_id = sqlc.arg(user_id)
+  AND start_time >= sqlc.arg(start_time)
+  AND start_time <= sqlc.arg(end_time)
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = sqlc.arg(user_id) AND NOT c.included
+  )
+  AND CASE sqlc.arg(status)::text
+        WHEN 'all' THEN TRUE
+        WHEN '' THEN COALESCE(status, 'confirmed') <> 'cancelled'
+        ELSE COALESCE(status, 'confirmed') = sqlc.arg(status)::text
+      END
 ORDER BY
     start_time ASC;
+
+-- Экспорт в iCalendar: события включённых календарей, пересекающие диапазон
+-- name: ListExportEventsByRange :many
+SELECT
+    event_id,
+    summary,
+    description,
+    location,
+    start_time,
+    end_time,
+    is_all_day,
+    status,
+    transparency,
+    recurring_event_id,
+    updated_at
+FROM googlecalendar_events
+WHERE user_id = sqlc.arg(user_id)
+  AND start_time < sqlc.arg(end_time)
+  AND end_time > sqlc.arg(start_time)
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = sqlc.arg(user_id) AND NOT c.included
+  )
+ORDER BY start_time ASC;
+
+-- Sync State Queries -------------------------------------------------------------------
+
+-- name: GetSyncState :one
+SELECT * FROM googlecalendar_sync_state
+WHERE user_id = $1 AND calendar_id = $2;
+
+-- name: UpsertSyncState :exec
+INSERT INTO googlecalendar_sync_state (user_id, calendar_id, sync_token, last_full_sync_at, last_sync_at)
+VALUES (sqlc.arg(user_id), sqlc.arg(calendar_id), sqlc.arg(sync_token), CASE WHEN sqlc.arg(full_sync)::bool THEN now() END, now())
+ON CONFLICT (user_id, calendar_id)
+DO UPDATE SET
+    sync_token = EXCLUDED.sync_token,
+    last_full_sync_at = COALESCE(EXCLUDED.last_full_sync_at, googlecalendar_sync_state.last_full_sync_at),
+    last_sync_at = now();
+
+-- Calendars Queries -------------------------------------------------------------------
+
+-- Настройки sync_enabled и included задаются только при первой вставке
+-- name: UpsertCalendar :one
+INSERT INTO googlecalendar_calendars (
+    user_id, calendar_id, summary, description, time_zone,
+    background_color, foreground_color, access_role, is_primary,
+    sync_enabled, included
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (user_id, calendar_id)
+DO UPDATE SET
+    summary = EXCLUDED.summary,
+    description = EXCLUDED.description,
+    time_zone = EXCLUDED.time_zone,
+    background_color = EXCLUDED.background_color,
+    foreground_color = EXCLUDED.foreground_color,
+    access_role = EXCLUDED.access_role,
+    is_primary = EXCLUDED.is_primary,
+    updated_at = now()
+RETURNING *;
+
+-- name: ListCalendars :many
+SELECT * FROM googlecalendar_calendars
+WHERE user_id = $1
+ORDER BY is_primary DESC, summary ASC;
+
+-- name: UpdateCalendarSettings :one
+UPDATE googlecalendar_calendars
+SET
+    sync_enabled = COALESCE(sqlc.narg(sync_enabled), sync_enabled),
+    included = COALESCE(sqlc.narg(included), included),
+    updated_at = now()
+WHERE user_id = sqlc.arg(user_id) AND calendar_id = sqlc.arg(calendar_id)
+RETURNING *;
+
+-- Attendees Queries -------------------------------------------------------------------
+
+-- name: DeleteEventAttendees :exec
+DELETE FROM googlecalendar_event_attendees
+WHERE user_id = $1 AND event_id = $2;
+
+-- name: UpsertEventAttendee :exec
+INSERT INTO googlecalendar_event_attendees (
+    user_id, event_id, email, display_name, response_status,
+    is_organizer, is_self, is_optional, is_resource
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (user_id, event_id, email)
+DO UPDATE SET
+    display_name = EXCLUDED.display_name,
+    response_status = EXCLUDED.response_status,
+    is_organizer = EXCLUDED.is_organizer,
+    is_self = EXCLUDED.is_self,
+    is_optional = EXCLUDED.is_optional,
+    is_resource = EXCLUDED.is_resource;
+
+-- name: ListEventAttendees :many
+SELECT * FROM googlecalendar_event_attendees
+WHERE user_id = $1 AND event_id = $2
+ORDER BY is_organizer DESC, email ASC;
+
+-- Feed Tokens Queries -------------------------------------------------------------------
+
+-- name: CreateFeedToken :one
+INSERT INTO calendar_feed_tokens (
+    user_id, name, token_hash, include_events, include_wakatime, include_activitywatch
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListFeedTokens :many
+SELECT * FROM calendar_feed_tokens
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetFeedTokenByHash :one
+SELECT * FROM calendar_feed_tokens
+WHERE token_hash = $1;
+
+-- name: TouchFeedToken :exec
+UPDATE calendar_feed_tokens
+SET last_used_at = now()
+WHERE id = $1;
+
+-- name: DeleteFeedToken :execrows
+DELETE FROM calendar_feed_tokens
+WHERE user_id = $1 AND id = $2;
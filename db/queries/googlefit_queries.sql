@@ -62,16 +62,223 @@ WHERE user_id = $1
   AND date >= CURRENT_DATE - INTERVAL '30 days';
 
 
+-- Если для типа данных выбран предпочитаемый источник и у него есть данные за день,
+-- берётся его значение вместо объединённого агрегата Google Fit
 -- name: GetGoogleFitDailyStatsByDateRange :many
 SELECT
-    date,
-    steps,
-    distance
+    d.date,
+    COALESCE(ps.value::int, d.steps) AS steps,
+    COALESCE(pd.value, d.distance) AS distance
 FROM
-    googlefit_daily_stats
+    googlefit_daily_stats d
+    LEFT JOIN googlefit_preferred_sources sps
+        ON sps.user_id = d.user_id AND sps.data_type = 'com.google.step_count.delta'
+    LEFT JOIN googlefit_source_daily_stats ps
+        ON ps.user_id = d.user_id AND ps.data_source_id = sps.data_source_id AND ps.date = d.date
+    LEFT JOIN googlefit_preferred_sources spd
+        ON spd.user_id = d.user_id AND spd.data_type = 'com.google.distance.delta'
+    LEFT JOIN googlefit_source_daily_stats pd
+        ON pd.user_id = d.user_id AND pd.data_source_id = spd.data_source_id AND pd.date = d.date
 WHERE
-    user_id = $1
-  AND date >= $2
-  AND date <= $3
+    d.user_id = $1
+  AND d.date >= $2
+  AND d.date <= $3
 ORDER BY
-    date DESC;
\ No newline at end of file
+    d.date DESC;
+
+-- Heart Rate Queries -------------------------------------------------------------------
+
+-- name: UpsertDailyHeartRate :one
+INSERT INTO googlefit_daily_heart_rate (user_id, date, min_bpm, avg_bpm, max_bpm)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, date)
+DO UPDATE SET
+    min_bpm = EXCLUDED.min_bpm,
+    avg_bpm = EXCLUDED.avg_bpm,
+    max_bpm = EXCLUDED.max_bpm,
+    updated_at = now()
+RETURNING *;
+
+-- name: ListDailyHeartRateByDateRange :many
+SELECT * FROM googlefit_daily_heart_rate
+WHERE user_id = $1 AND date BETWEEN $2 AND $3
+ORDER BY date DESC;
+
+-- Activity Queries -------------------------------------------------------------------
+
+-- name: UpsertDailyActivity :one
+INSERT INTO googlefit_daily_activity (user_id, date, calories, move_minutes, heart_points)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, date)
+DO UPDATE SET
+    calories = EXCLUDED.calories,
+    move_minutes = EXCLUDED.move_minutes,
+    heart_points = EXCLUDED.heart_points,
+    updated_at = now()
+RETURNING *;
+
+-- name: ListDailyActivityByDateRange :many
+SELECT * FROM googlefit_daily_activity
+WHERE user_id = $1 AND date BETWEEN $2 AND $3
+ORDER BY date DESC;
+
+-- Sleep Queries -------------------------------------------------------------------
+
+-- name: UpsertSleepSegment :exec
+INSERT INTO googlefit_sleep_segments (user_id, start_time, end_time, stage)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, start_time)
+DO UPDATE SET
+    end_time = EXCLUDED.end_time,
+    stage = EXCLUDED.stage;
+
+-- name: ListSleepSegmentsByRange :many
+SELECT * FROM googlefit_sleep_segments
+WHERE user_id = $1 AND end_time >= $2 AND start_time < $3
+ORDER BY start_time ASC;
+
+-- Ночь относится к локальному дню пробуждения в часовом поясе tz,
+-- start_time и end_time - границы локальных дней из localRange
+-- name: GetSleepSummaryByDateRange :many
+SELECT
+    (end_time AT TIME ZONE sqlc.arg(tz)::text)::date AS night,
+    MIN(start_time)::timestamptz AS sleep_start,
+    MAX(end_time)::timestamptz AS sleep_end,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage IN (2, 4, 5, 6)), 0)::float AS asleep_minutes,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage = 4), 0)::float AS light_minutes,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage = 5), 0)::float AS deep_minutes,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage = 6), 0)::float AS rem_minutes,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage IN (1, 3)), 0)::float AS awake_minutes
+FROM googlefit_sleep_segments
+WHERE user_id = sqlc.arg(user_id)
+  AND end_time >= sqlc.arg(start_time) AND end_time < sqlc.arg(end_time)
+GROUP BY night
+ORDER BY night DESC;
+
+-- Body Measurements Queries -------------------------------------------------------------------
+
+-- name: UpsertBodyMeasurement :exec
+INSERT INTO googlefit_body_measurements (user_id, metric, measured_at, value)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, metric, measured_at)
+DO UPDATE SET
+    value = EXCLUDED.value;
+
+-- name: ListBodyMeasurementsByRange :many
+SELECT * FROM googlefit_body_measurements
+WHERE user_id = $1 AND metric = $2 AND measured_at >= $3 AND measured_at < $4
+ORDER BY measured_at ASC;
+
+-- Sessions Queries -------------------------------------------------------------------
+
+-- name: UpsertSession :exec
+INSERT INTO googlefit_sessions (
+    user_id, session_id, name, description, activity_type, application,
+    start_time, end_time, steps, distance, calories, avg_bpm, max_bpm
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+ON CONFLICT (user_id, session_id)
+DO UPDATE SET
+    name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    activity_type = EXCLUDED.activity_type,
+    application = EXCLUDED.application,
+    start_time = EXCLUDED.start_time,
+    end_time = EXCLUDED.end_time,
+    steps = EXCLUDED.steps,
+    distance = EXCLUDED.distance,
+    calories = EXCLUDED.calories,
+    avg_bpm = EXCLUDED.avg_bpm,
+    max_bpm = EXCLUDED.max_bpm,
+    updated_at = now();
+
+-- name: ListSessionsByRange :many
+SELECT * FROM googlefit_sessions
+WHERE user_id = $1 AND start_time >= $2 AND start_time < $3
+ORDER BY start_time DESC;
+
+-- name: DeleteSessions :exec
+DELETE FROM googlefit_sessions
+WHERE user_id = sqlc.arg(user_id) AND session_id = ANY(sqlc.arg(session_ids)::text[]);
+
+-- Hourly Stats Queries -------------------------------------------------------------------
+
+-- name: UpsertHourlyStat :exec
+INSERT INTO googlefit_hourly_stats (user_id, hour_start, steps, distance, calories, move_minutes)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, hour_start)
+DO UPDATE SET
+    steps = EXCLUDED.steps,
+    distance = EXCLUDED.distance,
+    calories = EXCLUDED.calories,
+    move_minutes = EXCLUDED.move_minutes,
+    updated_at = now();
+
+-- name: ListHourlyStatsByRange :many
+SELECT * FROM googlefit_hourly_stats
+WHERE user_id = $1 AND hour_start >= $2 AND hour_start < $3
+ORDER BY hour_start ASC;
+
+-- Data Sources Queries -------------------------------------------------------------------
+
+-- name: UpsertDataSource :exec
+INSERT INTO googlefit_data_sources (
+    user_id, data_source_id, data_type, type,
+    device_manufacturer, device_model, device_type, application
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (user_id, data_source_id)
+DO UPDATE SET
+    data_type = EXCLUDED.data_type,
+    type = EXCLUDED.type,
+    device_manufacturer = EXCLUDED.device_manufacturer,
+    device_model = EXCLUDED.device_model,
+    device_type = EXCLUDED.device_type,
+    application = EXCLUDED.application,
+    updated_at = now();
+
+-- name: GetDataSource :one
+SELECT * FROM googlefit_data_sources
+WHERE user_id = $1 AND data_source_id = $2;
+
+-- name: ListDataSourcesWithStats :many
+SELECT
+    s.data_source_id,
+    s.data_type,
+    s.type,
+    s.device_manufacturer,
+    s.device_model,
+    s.device_type,
+    s.application,
+    COUNT(d.id) AS days_with_data,
+    COALESCE(SUM(d.value), 0)::float AS total_value,
+    MAX(d.date)::date AS last_date,
+    (p.data_source_id IS NOT NULL)::bool AS preferred
+FROM googlefit_data_sources s
+    LEFT JOIN googlefit_source_daily_stats d
+        ON d.user_id = s.user_id AND d.data_source_id = s.data_source_id
+    LEFT JOIN googlefit_preferred_sources p
+        ON p.user_id = s.user_id AND p.data_type = s.data_type AND p.data_source_id = s.data_source_id
+WHERE s.user_id = $1
+GROUP BY s.id, p.data_source_id
+ORDER BY s.data_type, days_with_data DESC;
+
+-- name: UpsertSourceDailyStat :exec
+INSERT INTO googlefit_source_daily_stats (user_id, data_source_id, data_type, date, value)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, data_source_id, date)
+DO UPDATE SET
+    value = EXCLUDED.value,
+    updated_at = now();
+
+-- name: SetPreferredSource :exec
+INSERT INTO googlefit_preferred_sources (user_id, data_type, data_source_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, data_type)
+DO UPDATE SET
+    data_source_id = EXCLUDED.data_source_id,
+    updated_at = now();
+
+-- name: DeletePreferredSource :exec
+DELETE FROM googlefit_preferred_sources
+WHERE user_id = $1 AND data_type = $2;
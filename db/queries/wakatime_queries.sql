@@ -1,8 +1,8 @@
 -- Дни -------------------------------------------------------------------
 
 -- name: CreateDay :one
-INSERT INTO wakatime_days (user_id, date, total_seconds, text)
-VALUES ($1, $2, $3, $4)
+INSERT INTO wakatime_days (user_id, date, total_seconds, text, content_hash)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetDayByID :one
@@ -16,7 +16,7 @@ SELECT * FROM wakatime_days WHERE user_id = $1 ORDER BY date DESC;
 
 -- name: UpdateDay :one
 UPDATE wakatime_days
-SET total_seconds = $2, text = $3, updated_at = now()
+SET total_seconds = $2, text = $3, content_hash = $4, updated_at = now()
 WHERE id = $1
 RETURNING *;
 
@@ -48,6 +48,21 @@ DELETE FROM wakatime_projects WHERE id = $1;
 -- name: DeleteProjectsByDay :exec
 DELETE FROM wakatime_projects WHERE day_id = $1;
 
+-- name: UpsertProjects :batchexec
+INSERT INTO wakatime_projects (day_id, name, total_seconds, percent, text)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (day_id, name)
+DO UPDATE SET
+    total_seconds = EXCLUDED.total_seconds,
+    percent = EXCLUDED.percent,
+    text = EXCLUDED.text
+WHERE (wakatime_projects.total_seconds, wakatime_projects.percent, wakatime_projects.text)
+    IS DISTINCT FROM (EXCLUDED.total_seconds, EXCLUDED.percent, EXCLUDED.text);
+
+-- name: DeleteStaleProjects :exec
+DELETE FROM wakatime_projects
+WHERE day_id = sqlc.arg(day_id) AND name <> ALL(sqlc.arg(names)::text[]);
+
 -- Языки -------------------------------------------------------------------
 
 -- name: CreateLanguage :one
@@ -64,6 +79,21 @@ DELETE FROM wakatime_languages WHERE id = $1;
 -- name: DeleteLanguagesByDay :exec
 DELETE FROM wakatime_languages WHERE day_id = $1;
 
+-- name: UpsertLanguages :batchexec
+INSERT INTO wakatime_languages (day_id, name, total_seconds, percent, text)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (day_id, name)
+DO UPDATE SET
+    total_seconds = EXCLUDED.total_seconds,
+    percent = EXCLUDED.percent,
+    text = EXCLUDED.text
+WHERE (wakatime_languages.total_seconds, wakatime_languages.percent, wakatime_languages.text)
+    IS DISTINCT FROM (EXCLUDED.total_seconds, EXCLUDED.percent, EXCLUDED.text);
+
+-- name: DeleteStaleLanguages :exec
+DELETE FROM wakatime_languages
+WHERE day_id = sqlc.arg(day_id) AND name <> ALL(sqlc.arg(names)::text[]);
+
 -- Редакторы -------------------------------------------------------------------
 
 -- name: CreateEditor :one
@@ -80,6 +110,21 @@ DELETE FROM wakatime_editors WHERE id = $1;
 -- name: DeleteEditorsByDay :exec
 DELETE FROM wakatime_editors WHERE day_id = $1;
 
+-- name: UpsertEditors :batchexec
+INSERT INTO wakatime_editors (day_id, name, total_seconds, percent, text)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (day_id, name)
+DO UPDATE SET
+    total_seconds = EXCLUDED.total_seconds,
+    percent = EXCLUDED.percent,
+    text = EXCLUDED.text
+WHERE (wakatime_editors.total_seconds, wakatime_editors.percent, wakatime_editors.text)
+    IS DISTINCT FROM (EXCLUDED.total_seconds, EXCLUDED.percent, EXCLUDED.text);
+
+-- name: DeleteStaleEditors :exec
+DELETE FROM wakatime_editors
+WHERE day_id = sqlc.arg(day_id) AND name <> ALL(sqlc.arg(names)::text[]);
+
 -- ОС -------------------------------------------------------------------
 
 -- name: CreateOS :one
@@ -96,6 +141,21 @@ DELETE FROM wakatime_os WHERE id = $1;
 -- name: DeleteOSByDay :exec
 DELETE FROM wakatime_os WHERE day_id = $1;
 
+-- name: UpsertOS :batchexec
+INSERT INTO wakatime_os (day_id, name, total_seconds, percent, text)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (day_id, name)
+DO UPDATE SET
+    total_seconds = EXCLUDED.total_seconds,
+    percent = EXCLUDED.percent,
+    text = EXCLUDED.text
+WHERE (wakatime_os.total_seconds, wakatime_os.percent, wakatime_os.text)
+    IS DISTINCT FROM (EXCLUDED.total_seconds, EXCLUDED.percent, EXCLUDED.text);
+
+-- name: DeleteStaleOS :exec
+DELETE FROM wakatime_os
+WHERE day_id = sqlc.arg(day_id) AND name <> ALL(sqlc.arg(names)::text[]);
+
 -- Зависимости -------------------------------------------------------------------
 
 -- name: CreateDependency :one
@@ -112,6 +172,21 @@ DELETE FROM wakatime_dependencies WHERE id = $1;
 -- name: DeleteDependenciesByDay :exec
 DELETE FROM wakatime_dependencies WHERE day_id = $1;
 
+-- name: UpsertDependencies :batchexec
+INSERT INTO wakatime_dependencies (day_id, name, total_seconds, percent, text)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (day_id, name)
+DO UPDATE SET
+    total_seconds = EXCLUDED.total_seconds,
+    percent = EXCLUDED.percent,
+    text = EXCLUDED.text
+WHERE (wakatime_dependencies.total_seconds, wakatime_dependencies.percent, wakatime_dependencies.text)
+    IS DISTINCT FROM (EXCLUDED.total_seconds, EXCLUDED.percent, EXCLUDED.text);
+
+-- name: DeleteStaleDependencies :exec
+DELETE FROM wakatime_dependencies
+WHERE day_id = sqlc.arg(day_id) AND name <> ALL(sqlc.arg(names)::text[]);
+
 -- Машины -------------------------------------------------------------------
 
 -- name: CreateMachine :one
@@ -128,6 +203,21 @@ DELETE FROM wakatime_machines WHERE id = $1;
 -- name: DeleteMachinesByDay :exec
 DELETE FROM wakatime_machines WHERE day_id = $1;
 
+-- name: UpsertMachines :batchexec
+INSERT INTO wakatime_machines (day_id, name, total_seconds, percent, text)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (day_id, name)
+DO UPDATE SET
+    total_seconds = EXCLUDED.total_seconds,
+    percent = EXCLUDED.percent,
+    text = EXCLUDED.text
+WHERE (wakatime_machines.total_seconds, wakatime_machines.percent, wakatime_machines.text)
+    IS DISTINCT FROM (EXCLUDED.total_seconds, EXCLUDED.percent, EXCLUDED.text);
+
+-- name: DeleteStaleMachines :exec
+DELETE FROM wakatime_machines
+WHERE day_id = sqlc.arg(day_id) AND name <> ALL(sqlc.arg(names)::text[]);
+
 -- Сводная статистика -------------------------------------------------------------------
 
 -- name: CreateSummary :one
@@ -206,4 +296,21 @@ GROUP BY
     p.name
 ORDER BY
     SUM(p.total_seconds) DESC
-LIMIT $4;
\ No newline at end of file
+LIMIT $4;
+
+-- name: GetProjectsByDateRange :many
+SELECT
+    d.date,
+    d.total_seconds as day_seconds,
+    p.name,
+    p.total_seconds
+FROM
+    wakatime_days d
+    INNER JOIN
+    wakatime_projects p ON d.id = p.day_id
+WHERE
+    d.user_id = $1
+  AND d.date >= $2
+  AND d.date <= $3
+ORDER BY
+    d.date ASC, p.total_seconds DESC;
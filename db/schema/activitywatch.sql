@@ -1,3 +1,13 @@
+CREATE TABLE IF NOT EXISTS activity_devices (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hostname TEXT NOT NULL,
+    first_seen TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT activity_devices_unique UNIQUE(user_id, hostname)
+);
+
 CREATE TABLE IF NOT EXISTS activity_events (
     id BIGSERIAL PRIMARY KEY,
     timestamp TIMESTAMPTZ NOT NULL,
@@ -5,10 +15,55 @@ CREATE TABLE IF NOT EXISTS activity_events (
     app TEXT NOT NULL,
     title TEXT,
     bucket_id TEXT NOT NULL,
-    created_at TIMESTAMPTZ DEFAULT NOW()
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    aw_event_id BIGINT,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_id BIGINT REFERENCES activity_devices(id) ON DELETE SET NULL,
+    url TEXT, -- aw-watcher-web
+    domain TEXT,
+    audible BOOLEAN NOT NULL DEFAULT FALSE,
+    incognito BOOLEAN NOT NULL DEFAULT FALSE
 );
 
-CREATE INDEX IF NOT EXISTS idx_activity_timestamp ON activity_events(timestamp DESC);
 CREATE INDEX IF NOT EXISTS idx_activity_app ON activity_events(app);
-CREATE INDEX IF NOT EXISTS idx_activity_bucket ON activity_events(bucket_id);
+CREATE UNIQUE INDEX IF NOT EXISTS activity_events_user_bucket_timestamp_unique ON activity_events(user_id, bucket_id, timestamp);
+CREATE INDEX IF NOT EXISTS idx_activity_events_user_time ON activity_events(user_id, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_activity_events_device_time ON activity_events(device_id, timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS activity_category_rules (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category TEXT[] NOT NULL,
+    field VARCHAR(10) NOT NULL DEFAULT 'any', -- app, title, url или any
+    pattern TEXT NOT NULL,
+    ignore_case BOOLEAN NOT NULL DEFAULT TRUE,
+    color VARCHAR(7), -- #rrggbb
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_category_rules_user ON activity_category_rules(user_id);
 
+-- Правила редактирования заголовков и адресов: app - выражение по приложению, pattern - по полю field
+CREATE TABLE IF NOT EXISTS activity_redaction_rules (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    app TEXT NOT NULL DEFAULT '',
+    field VARCHAR(10) NOT NULL DEFAULT 'title', -- title или url
+    pattern TEXT NOT NULL DEFAULT '',
+    action VARCHAR(10) NOT NULL, -- drop, hash или replace
+    replacement TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_redaction_rules_user ON activity_redaction_rules(user_id);
+
+-- Приватные приложения: от их событий остаются только приложение и длительность
+CREATE TABLE IF NOT EXISTS activity_private_apps (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    app TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (user_id, app)
+);
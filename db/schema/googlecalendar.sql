@@ -14,6 +14,16 @@ CREATE TABLE IF NOT EXISTS googlecalendar_events (
     status VARCHAR(50) DEFAULT 'confirmed',
     created_at TIMESTAMPTZ DEFAULT now(),
     updated_at TIMESTAMPTZ DEFAULT now(),
+    organizer_email TEXT,
+    organizer_name TEXT,
+    recurring_event_id VARCHAR(255), -- id серии для экземпляров повторяющихся событий
+    recurrence TEXT[], -- RRULE/EXRULE/RDATE/EXDATE строки серии
+    transparency VARCHAR(20) DEFAULT 'opaque', -- transparent - событие не занимает время
+    event_type VARCHAR(32) DEFAULT 'default', -- default, outOfOffice, focusTime, workingLocation
+    response_status VARCHAR(20), -- ответ самого пользователя на приглашение
+    attendees_count INT NOT NULL DEFAULT 0,
+    ical_uid TEXT, -- UID события iCalendar (фиды и CalDAV)
+    source_href TEXT, -- href ресурса CalDAV
     CONSTRAINT googlecalendar_events_unique UNIQUE(user_id, event_id)
 );
 
@@ -22,3 +32,68 @@ CREATE INDEX IF NOT EXISTS idx_googlecalendar_events_user_time ON googlecalendar
 CREATE INDEX IF NOT EXISTS idx_googlecalendar_events_calendar ON googlecalendar_events(calendar_id);
 CREATE INDEX IF NOT EXISTS idx_googlecalendar_events_start_time ON googlecalendar_events(start_time);
 
+
+-- Google Calendar: состояние инкрементальной синхронизации по календарю
+CREATE TABLE IF NOT EXISTS googlecalendar_sync_state (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    calendar_id VARCHAR(255) NOT NULL,
+    sync_token TEXT,
+    last_full_sync_at TIMESTAMPTZ,
+    last_sync_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, calendar_id)
+);
+
+-- Google Calendar: календари пользователя и настройки синхронизации
+-- sync_enabled - забирать ли события календаря, included - учитывать ли их в API и аналитике
+CREATE TABLE IF NOT EXISTS googlecalendar_calendars (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    calendar_id VARCHAR(255) NOT NULL,
+    summary TEXT,
+    description TEXT,
+    time_zone VARCHAR(64),
+    background_color VARCHAR(16),
+    foreground_color VARCHAR(16),
+    access_role VARCHAR(32),
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    sync_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    included BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlecalendar_calendars_unique UNIQUE(user_id, calendar_id)
+);
+
+-- Google Calendar: участники событий
+CREATE TABLE IF NOT EXISTS googlecalendar_event_attendees (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    email TEXT NOT NULL,
+    display_name TEXT,
+    response_status VARCHAR(20), -- needsAction, declined, tentative, accepted
+    is_organizer BOOLEAN NOT NULL DEFAULT FALSE,
+    is_self BOOLEAN NOT NULL DEFAULT FALSE,
+    is_optional BOOLEAN NOT NULL DEFAULT FALSE,
+    is_resource BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT googlecalendar_event_attendees_unique UNIQUE(user_id, event_id, email),
+    CONSTRAINT googlecalendar_event_attendees_event_fk FOREIGN KEY (user_id, event_id)
+        REFERENCES googlecalendar_events(user_id, event_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlecalendar_events_recurring ON googlecalendar_events(user_id, recurring_event_id);
+CREATE INDEX IF NOT EXISTS idx_googlecalendar_event_attendees_email ON googlecalendar_event_attendees(user_id, email);
+
+-- Экспорт календаря: секретные токены подписок на /api/v1/calendar.ics
+-- Хранится только sha256 токена, сам токен показывается один раз при создании
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    include_events BOOLEAN NOT NULL DEFAULT TRUE,
+    include_wakatime BOOLEAN NOT NULL DEFAULT FALSE,
+    include_activitywatch BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    last_used_at TIMESTAMPTZ,
+    CONSTRAINT calendar_feed_tokens_hash_unique UNIQUE(token_hash)
+);
@@ -13,3 +13,138 @@ CREATE TABLE IF NOT EXISTS googlefit_daily_stats (
 -- Индекс для производительности
 CREATE INDEX IF NOT EXISTS idx_googlefit_daily_stats_user_date ON googlefit_daily_stats(user_id, date DESC);
 
+
+-- Google Fit: пульс по дням
+CREATE TABLE IF NOT EXISTS googlefit_daily_heart_rate (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    min_bpm FLOAT NOT NULL,
+    avg_bpm FLOAT NOT NULL,
+    max_bpm FLOAT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_daily_heart_rate_unique UNIQUE(user_id, date)
+);
+
+-- Google Fit: калории, минуты активности и баллы кардиотренировок по дням
+CREATE TABLE IF NOT EXISTS googlefit_daily_activity (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    calories FLOAT DEFAULT 0, -- в ккал
+    move_minutes INT DEFAULT 0,
+    heart_points FLOAT DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_daily_activity_unique UNIQUE(user_id, date)
+);
+
+-- Google Fit: сегменты сна со стадиями
+-- stage: 1 - бодрствование, 2 - сон, 3 - вне кровати, 4 - лёгкий, 5 - глубокий, 6 - REM
+CREATE TABLE IF NOT EXISTS googlefit_sleep_segments (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    stage INT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_sleep_segments_unique UNIQUE(user_id, start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_daily_heart_rate_user_date ON googlefit_daily_heart_rate(user_id, date DESC);
+CREATE INDEX IF NOT EXISTS idx_googlefit_daily_activity_user_date ON googlefit_daily_activity(user_id, date DESC);
+CREATE INDEX IF NOT EXISTS idx_googlefit_sleep_segments_user_end ON googlefit_sleep_segments(user_id, end_time DESC);
+
+-- Google Fit: измерения тела (вес, процент жира, рост) на момент времени
+-- metric: weight (кг), body_fat (%), height (м)
+CREATE TABLE IF NOT EXISTS googlefit_body_measurements (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    metric TEXT NOT NULL,
+    measured_at TIMESTAMPTZ NOT NULL,
+    value FLOAT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_body_measurements_unique UNIQUE(user_id, metric, measured_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_body_measurements_user_metric ON googlefit_body_measurements(user_id, metric, measured_at DESC);
+
+-- Google Fit: сессии (тренировки) с агрегатами за сессию
+-- activity_type: https://developers.google.com/fit/rest/v1/reference/activity-types
+CREATE TABLE IF NOT EXISTS googlefit_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id TEXT NOT NULL,
+    name TEXT,
+    description TEXT,
+    activity_type INT NOT NULL,
+    application TEXT,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    steps INT DEFAULT 0,
+    distance FLOAT DEFAULT 0, -- в метрах
+    calories FLOAT DEFAULT 0, -- в ккал
+    avg_bpm FLOAT,
+    max_bpm FLOAT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_sessions_unique UNIQUE(user_id, session_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_sessions_user_start ON googlefit_sessions(user_id, start_time DESC);
+
+-- Google Fit: почасовая статистика (начало часа хранится в UTC)
+CREATE TABLE IF NOT EXISTS googlefit_hourly_stats (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hour_start TIMESTAMPTZ NOT NULL,
+    steps INT DEFAULT 0,
+    distance FLOAT DEFAULT 0, -- в метрах
+    calories FLOAT DEFAULT 0, -- в ккал
+    move_minutes INT DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_hourly_stats_unique UNIQUE(user_id, hour_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_hourly_stats_user_hour ON googlefit_hourly_stats(user_id, hour_start DESC);
+
+-- Google Fit: источники данных (устройства и приложения)
+CREATE TABLE IF NOT EXISTS googlefit_data_sources (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    data_source_id TEXT NOT NULL,
+    data_type TEXT NOT NULL,
+    type TEXT NOT NULL, -- raw или derived
+    device_manufacturer TEXT,
+    device_model TEXT,
+    device_type TEXT,
+    application TEXT,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_data_sources_unique UNIQUE(user_id, data_source_id)
+);
+
+-- Google Fit: дневные суммы по каждому источнику (по originDataSourceId)
+CREATE TABLE IF NOT EXISTS googlefit_source_daily_stats (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    data_source_id TEXT NOT NULL,
+    data_type TEXT NOT NULL,
+    date DATE NOT NULL,
+    value FLOAT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT googlefit_source_daily_stats_unique UNIQUE(user_id, data_source_id, date)
+);
+
+-- Google Fit: предпочитаемый источник для типа данных
+CREATE TABLE IF NOT EXISTS googlefit_preferred_sources (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    data_type TEXT NOT NULL,
+    data_source_id TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (user_id, data_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_googlefit_source_daily_stats_user_date ON googlefit_source_daily_stats(user_id, date DESC);
@@ -15,6 +15,7 @@ CREATE TABLE IF NOT EXISTS wakatime_days (
     text TEXT,
     created_at TIMESTAMPTZ DEFAULT now(),
     updated_at TIMESTAMPTZ DEFAULT now(),
+    content_hash TEXT, -- sha256 ответа API за день, позволяет пропускать неизменные дни
     CONSTRAINT wakatime_days_unique UNIQUE(user_id, date)
 );
 
@@ -26,7 +27,8 @@ CREATE TABLE IF NOT EXISTS wakatime_projects (
     total_seconds FLOAT NOT NULL,
     percent FLOAT,
     text TEXT,
-    created_at TIMESTAMPTZ DEFAULT now()
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT wakatime_projects_day_name_unique UNIQUE(day_id, name)
 );
 
 -- Языки
@@ -37,7 +39,8 @@ CREATE TABLE IF NOT EXISTS wakatime_languages (
     total_seconds FLOAT NOT NULL,
     percent FLOAT,
     text TEXT,
-    created_at TIMESTAMPTZ DEFAULT now()
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT wakatime_languages_day_name_unique UNIQUE(day_id, name)
 );
 
 -- Редакторы
@@ -48,7 +51,8 @@ CREATE TABLE IF NOT EXISTS wakatime_editors (
     total_seconds FLOAT NOT NULL,
     percent FLOAT,
     text TEXT,
-    created_at TIMESTAMPTZ DEFAULT now()
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT wakatime_editors_day_name_unique UNIQUE(day_id, name)
 );
 
 -- Операционные системы
@@ -59,7 +63,8 @@ CREATE TABLE IF NOT EXISTS wakatime_os (
     total_seconds FLOAT NOT NULL,
     percent FLOAT,
     text TEXT,
-    created_at TIMESTAMPTZ DEFAULT now()
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT wakatime_os_day_name_unique UNIQUE(day_id, name)
 );
 
 -- Зависимости
@@ -70,7 +75,8 @@ CREATE TABLE IF NOT EXISTS wakatime_dependencies (
     total_seconds FLOAT NOT NULL,
     percent FLOAT,
     text TEXT,
-    created_at TIMESTAMPTZ DEFAULT now()
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT wakatime_dependencies_day_name_unique UNIQUE(day_id, name)
 );
 
 -- Машины
@@ -81,7 +87,8 @@ CREATE TABLE IF NOT EXISTS wakatime_machines (
     total_seconds FLOAT NOT NULL,
     percent FLOAT,
     text TEXT,
-    created_at TIMESTAMPTZ DEFAULT now()
+    created_at TIMESTAMPTZ DEFAULT now(),
+    CONSTRAINT wakatime_machines_day_name_unique UNIQUE(day_id, name)
 );
 
 -- Сводная статистика
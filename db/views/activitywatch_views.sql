@@ -1,5 +1,10 @@
--- Представление для удобного анализа активности с человекочитаемым временем
+-- Представление для удобного анализа активности с человекочитаемым временем.
+-- События aw-watcher-afk (app = 'afk') не являются приложением и исключены, но время окон
+-- здесь не очищено от AFK: активное время считает GET /api/v1/activitywatch/stats.
+-- Категории задаются правилами пользователя (activity_category_rules) и применяются
+-- в GET /api/v1/activitywatch/categories/stats
 
+DROP VIEW IF EXISTS activity_summary;
 CREATE OR REPLACE VIEW activity_summary AS
 SELECT
     app,
@@ -7,15 +12,9 @@ SELECT
     ROUND((SUM(duration) / 60)::numeric, 2) as total_minutes,
     ROUND((SUM(duration) / 3600)::numeric, 2) as total_hours,
     MIN(timestamp) as first_seen,
-    MAX(timestamp) as last_seen,
-    CASE
-        WHEN app = 'afk' THEN '🛌 AFK'
-        WHEN app = 'chrome' OR app LIKE '%browser%' THEN '🌐 Browser'
-        WHEN app LIKE '%goland%' THEN '💻 IDE'
-        WHEN app = 'system' THEN '⚡ System'
-        ELSE '📱 ' || app
-    END as category
+    MAX(timestamp) as last_seen
 FROM activity_events
+WHERE app <> 'afk'
 GROUP BY app;
 
 -- Представление для дневной статистики
@@ -27,6 +26,7 @@ SELECT
     ROUND((SUM(duration) / 60)::numeric, 2) as total_minutes,
     ROUND((SUM(duration) / 3600)::numeric, 2) as total_hours
 FROM activity_events
+WHERE app <> 'afk'
 GROUP BY day, app
 ORDER BY day DESC, total_minutes DESC;
 
@@ -38,6 +38,7 @@ SELECT
     COUNT(*) as events,
     ROUND((SUM(duration) / 60)::numeric, 2) as minutes
 FROM activity_events
+WHERE app <> 'afk'
 GROUP BY hour, app
 ORDER BY hour DESC;
 
@@ -50,12 +51,12 @@ SELECT
     ROUND((SUM(duration) / 60)::numeric, 2) as total_minutes,
     MAX(timestamp) as last_used
 FROM activity_events
-WHERE title IS NOT NULL AND title != '' AND title NOT LIKE '%auto-classified%'
+WHERE app <> 'afk' AND title IS NOT NULL AND title != '' AND title NOT LIKE '%auto-classified%'
 GROUP BY app, title
 ORDER BY total_minutes DESC
 LIMIT 100;
 
-COMMENT ON VIEW activity_summary IS 'Общая статистика по приложениям с категориями';
+COMMENT ON VIEW activity_summary IS 'Общая статистика по приложениям';
 COMMENT ON VIEW daily_activity_summary IS 'Статистика активности по дням';
 COMMENT ON VIEW hourly_activity IS 'Почасовая статистика активности';
 COMMENT ON VIEW top_windows IS 'Топ 100 окон/файлов по времени использования';
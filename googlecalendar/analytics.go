@@ -0,0 +1,232 @@
+package googlecalendar
+
+import (
+	"DataLake/internal/timezone"
+	"sort"
+	"time"
+)
+
+// Meeting занятый встречей интервал времени
+type Meeting struct {
+	EventID string
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// WorkingHours рабочее время как смещение от начала локального дня.
+// Суббота и воскресенье считаются нерабочими днями
+type WorkingHours struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// DefaultWorkingHours рабочий день с 09:00 до 18:00
+var DefaultWorkingHours = WorkingHours{Start: 9 * time.Hour, End: 18 * time.Hour}
+
+// DayLoad нагрузка встречами за один локальный день
+type DayLoad struct {
+	Date           time.Time
+	Workday        bool
+	Meetings       int
+	MeetingMinutes float64
+
+	// Свободное время внутри рабочих часов
+	FreeMinutes         float64
+	LongestFocusMinutes float64
+	FocusBlocks         int
+	Fragmentation       float64
+}
+
+// Streak серия встреч подряд с перерывами не длиннее допустимого
+type Streak struct {
+	Start    time.Time
+	End      time.Time
+	Meetings []Meeting
+}
+
+// AfterHoursMeeting встреча, частично или полностью вне рабочих часов
+type AfterHoursMeeting struct {
+	Meeting
+	Minutes float64
+}
+
+type interval struct {
+	start, end time.Time
+}
+
+// Workday сообщает, является ли день рабочим
+func (wh WorkingHours) Workday(day time.Time) bool {
+	wd := day.Weekday()
+	return wd != time.Saturday && wd != time.Sunday
+}
+
+// window возвращает рабочий интервал дня по локальному времени, с учётом перехода на летнее время
+func (wh WorkingHours) window(day time.Time) interval {
+	y, m, d := day.Date()
+	return interval{
+		start: time.Date(y, m, d, 0, 0, 0, int(wh.Start), day.Location()),
+		end:   time.Date(y, m, d, 0, 0, 0, int(wh.End), day.Location()),
+	}
+}
+
+// DailyLoad считает нагрузку по дням с from по to включительно.
+// Берутся только календарные даты from и to, границы дней считаются в loc.
+// Пересекающиеся встречи не учитываются дважды. Блок фокуса - свободный отрезок рабочего
+// времени не короче minFocus; fragmentation - доля свободного рабочего времени,
+// приходящаяся на более короткие отрезки (0 - всё свободное время пригодно для работы)
+func DailyLoad(meetings []Meeting, from, to time.Time, loc *time.Location, wh WorkingHours, minFocus time.Duration) []DayLoad {
+	busy := mergeMeetings(meetings)
+
+	var days []DayLoad
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); !day.After(last); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		load := DayLoad{
+			Date:           day,
+			Workday:        wh.Workday(day),
+			MeetingMinutes: overlapMinutes(busy, interval{start: day, end: next}),
+		}
+		for _, m := range meetings {
+			if !m.Start.Before(day) && m.Start.Before(next) {
+				load.Meetings++
+			}
+		}
+
+		if load.Workday {
+			var shortMinutes float64
+			for _, gap := range freeGaps(busy, wh.window(day)) {
+				minutes := gap.end.Sub(gap.start).Minutes()
+				load.FreeMinutes += minutes
+				if minutes > load.LongestFocusMinutes {
+					load.LongestFocusMinutes = minutes
+				}
+				if gap.end.Sub(gap.start) >= minFocus {
+					load.FocusBlocks++
+				} else {
+					shortMinutes += minutes
+				}
+			}
+			if load.FreeMinutes > 0 {
+				load.Fragmentation = shortMinutes / load.FreeMinutes
+			}
+		}
+
+		days = append(days, load)
+	}
+
+	return days
+}
+
+// AfterHours возвращает встречи, выходящие за рабочие часы дня своего начала.
+// Встречи в выходные целиком считаются нерабочими
+func AfterHours(meetings []Meeting, loc *time.Location, wh WorkingHours) []AfterHoursMeeting {
+	var result []AfterHoursMeeting
+	for _, m := range meetings {
+		day := timezone.StartOfDay(m.Start, loc)
+		total := m.End.Sub(m.Start).Minutes()
+
+		outside := total
+		if wh.Workday(day) {
+			outside -= overlapMinutes([]interval{{start: m.Start, end: m.End}}, wh.window(day))
+		}
+		if outside > 0 {
+			result = append(result, AfterHoursMeeting{Meeting: m, Minutes: outside})
+		}
+	}
+	return result
+}
+
+// BackToBack находит серии из двух и более встреч, между которыми не больше maxGap.
+// Пересекающиеся встречи также попадают в одну серию
+func BackToBack(meetings []Meeting, maxGap time.Duration) []Streak {
+	sorted := make([]Meeting, len(meetings))
+	copy(sorted, meetings)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	var streaks []Streak
+	var current Streak
+	flush := func() {
+		if len(current.Meetings) > 1 {
+			streaks = append(streaks, current)
+		}
+	}
+
+	for _, m := range sorted {
+		if len(current.Meetings) > 0 && m.Start.Sub(current.End) <= maxGap {
+			current.Meetings = append(current.Meetings, m)
+			if m.End.After(current.End) {
+				current.End = m.End
+			}
+			continue
+		}
+		flush()
+		current = Streak{Start: m.Start, End: m.End, Meetings: []Meeting{m}}
+	}
+	flush()
+
+	return streaks
+}
+
+// mergeMeetings объединяет пересекающиеся встречи в непрерывные занятые интервалы
+func mergeMeetings(meetings []Meeting) []interval {
+	busy := make([]interval, 0, len(meetings))
+	for _, m := range meetings {
+		if m.End.After(m.Start) {
+			busy = append(busy, interval{start: m.Start, end: m.End})
+		}
+	}
+	sort.Slice(busy, func(i, j int) bool { return busy[i].start.Before(busy[j].start) })
+
+	merged := busy[:0]
+	for _, iv := range busy {
+		if n := len(merged); n > 0 && !iv.start.After(merged[n-1].end) {
+			if iv.end.After(merged[n-1].end) {
+				merged[n-1].end = iv.end
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// overlapMinutes суммирует пересечение отсортированных интервалов с window
+func overlapMinutes(busy []interval, window interval) float64 {
+	var total time.Duration
+	for _, iv := range busy {
+		start, end := iv.start, iv.end
+		if start.Before(window.start) {
+			start = window.start
+		}
+		if end.After(window.end) {
+			end = window.end
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total.Minutes()
+}
+
+// freeGaps возвращает свободные отрезки window, не занятые объединёнными интервалами busy
+func freeGaps(busy []interval, window interval) []interval {
+	var gaps []interval
+	cursor := window.start
+	for _, iv := range busy {
+		if !iv.end.After(cursor) {
+			continue
+		}
+		if !iv.start.Before(window.end) {
+			break
+		}
+		if iv.start.After(cursor) {
+			gaps = append(gaps, interval{start: cursor, end: iv.start})
+		}
+		cursor = iv.end
+	}
+	if window.end.After(cursor) {
+		gaps = append(gaps, interval{start: cursor, end: window.end})
+	}
+	return gaps
+}
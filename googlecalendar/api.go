@@ -8,29 +8,37 @@ import (
 	"DataLake/internal/logger"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	uuid "github.com/satori/go.uuid"
 )
 
 const (
 	calendarAPIBaseURL = "https://www.googleapis.com/calendar/v3"
+
+	// Размер страницы для calendarList и events
+	pageSize = "250"
 )
 
-// FetchCalendars получает список календарей пользователя
-func FetchCalendars() (*CalendarListResponse, error) {
+// ErrSyncTokenExpired возвращается, когда Google отвечает 410 Gone на запрос с syncToken.
+// В этом случае нужна полная синхронизация календаря
+var ErrSyncTokenExpired = errors.New("sync token expired")
+
+// getAccessToken возвращает действующий access token Google Calendar
+func getAccessToken(ctx context.Context) (string, error) {
 	log := logger.Get()
-	ctx := context.Background()
 
 	storage, err := auth.NewFileTokenStorageFromEnv("tokens.json")
 	if err != nil {
 		log.Error().Err(err).Msg("failed to initialize token storage")
-		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+		return "", fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	provider := googlecalendarauth.NewProviderFromEnv()
 	tokenManager := auth.NewTokenManager(storage, provider)
@@ -38,82 +46,148 @@ func FetchCalendars() (*CalendarListResponse, error) {
 	token, err := tokenManager.GetValidToken(ctx, "googlecalendar")
 	if err != nil {
 		log.Error().Err(err).Msg("failed to get valid token")
-		return nil, fmt.Errorf("failed to get valid token: %w", err)
+		return "", fmt.Errorf("failed to get valid token: %w", err)
 	}
 
-	apiURL := fmt.Sprintf("%s/users/me/calendarList", calendarAPIBaseURL)
+	return token.AccessToken, nil
+}
 
-	log.Info().Msg("fetching google calendar list")
+// getJSON выполняет GET запрос к Calendar API и декодирует ответ в out
+func getJSON(ctx context.Context, token, apiURL string, out interface{}) error {
+	log := logger.Get()
 
-	req, err := http.NewRequest("GET", apiURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create request")
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Authorization", "Bearer "+token)
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to execute request")
-		return nil, fmt.Errorf("request failed: %w", err)
+		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusGone {
+		return ErrSyncTokenExpired
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		log.Error().
 			Int("status_code", resp.StatusCode).
 			Str("response", string(body)).
 			Msg("unexpected status code")
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	var response CalendarListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
 		log.Error().Err(err).Msg("failed to decode JSON")
-		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+		return fmt.Errorf("failed to decode JSON: %w", err)
 	}
 
-	log.Info().Int("calendar_count", len(response.Items)).Msg("successfully fetched calendars")
-
-	return &response, nil
+	return nil
 }
 
-// FetchEvents получает события из календаря за указанный период
-func FetchEvents(calendarID string, startTime, endTime time.Time) (*EventsResponse, error) {
+// FetchCalendars получает список календарей пользователя со всех страниц
+func FetchCalendars() (*CalendarListResponse, error) {
 	log := logger.Get()
 	ctx := context.Background()
 
-	storage, err := auth.NewFileTokenStorageFromEnv("tokens.json")
+	token, err := getAccessToken(ctx)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to initialize token storage")
-		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+		return nil, err
 	}
-	provider := googlecalendarauth.NewProviderFromEnv()
-	tokenManager := auth.NewTokenManager(storage, provider)
 
-	token, err := tokenManager.GetValidToken(ctx, "googlecalendar")
-	if err != nil {
-		log.Error().Err(err).Msg("failed to get valid token")
-		return nil, fmt.Errorf("failed to get valid token: %w", err)
+	log.Info().Msg("fetching google calendar list")
+
+	result := &CalendarListResponse{}
+	pageToken := ""
+	for {
+		q := url.Values{}
+		q.Set("maxResults", pageSize)
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+
+		var page CalendarListResponse
+		if err := getJSON(ctx, token, calendarAPIBaseURL+"/users/me/calendarList?"+q.Encode(), &page); err != nil {
+			return nil, err
+		}
+
+		result.Kind = page.Kind
+		result.Etag = page.Etag
+		result.Items = append(result.Items, page.Items...)
+
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
 	}
 
+	log.Info().Int("calendar_count", len(result.Items)).Msg("successfully fetched calendars")
+
+	return result, nil
+}
+
+// listEvents проходит по всем страницам events.list с заданными параметрами.
+// Возвращает все события и nextSyncToken с последней страницы
+func listEvents(ctx context.Context, token, calendarID string, params url.Values) (*EventsResponse, error) {
 	baseURL := fmt.Sprintf("%s/calendars/%s/events", calendarAPIBaseURL, url.PathEscape(calendarID))
 
-	u, err := url.Parse(baseURL)
+	result := &EventsResponse{}
+	pageToken := ""
+	for {
+		q := url.Values{}
+		for key, values := range params {
+			q[key] = values
+		}
+		q.Set("maxResults", pageSize)
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+
+		var page EventsResponse
+		if err := getJSON(ctx, token, baseURL+"?"+q.Encode(), &page); err != nil {
+			return nil, err
+		}
+
+		result.Kind = page.Kind
+		result.Etag = page.Etag
+		result.Summary = page.Summary
+		result.Updated = page.Updated
+		result.TimeZone = page.TimeZone
+		result.AccessRole = page.AccessRole
+		result.Items = append(result.Items, page.Items...)
+
+		if page.NextPageToken == "" {
+			result.NextSyncToken = page.NextSyncToken
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	return result, nil
+}
+
+// FetchEvents получает события из календаря за указанный период
+func FetchEvents(calendarID string, startTime, endTime time.Time) (*EventsResponse, error) {
+	log := logger.Get()
+	ctx := context.Background()
+
+	token, err := getAccessToken(ctx)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to parse URL")
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
+		return nil, err
 	}
 
-	q := u.Query()
+	q := url.Values{}
 	q.Set("timeMin", startTime.Format(time.RFC3339))
 	q.Set("timeMax", endTime.Format(time.RFC3339))
 	q.Set("singleEvents", "true")
 	q.Set("orderBy", "startTime")
-	q.Set("maxResults", "2500")
-	u.RawQuery = q.Encode()
 
 	log.Info().
 		Str("calendar_id", calendarID).
@@ -121,45 +195,58 @@ func FetchEvents(calendarID string, startTime, endTime time.Time) (*EventsRespon
 		Str("end_time", endTime.Format("2006-01-02")).
 		Msg("fetching google calendar events")
 
-	req, err := http.NewRequest("GET", u.String(), nil)
+	response, err := listEvents(ctx, token, calendarID, q)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to create request")
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to execute request")
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
+	log.Info().
+		Str("calendar_id", calendarID).
+		Int("event_count", len(response.Items)).
+		Msg("successfully fetched events")
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		log.Error().
-			Int("status_code", resp.StatusCode).
-			Str("response", string(body)).
-			Msg("unexpected status code")
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	return response, nil
+}
+
+// SyncEvents получает изменения календаря.
+// С пустым syncToken выполняется полная синхронизация начиная с startTime,
+// иначе - инкрементальная. Если токен устарел, возвращается ErrSyncTokenExpired
+func SyncEvents(ctx context.Context, token, calendarID, syncToken string, startTime time.Time) (*EventsResponse, error) {
+	log := logger.Get()
+
+	// timeMin, timeMax и orderBy нельзя передавать вместе с syncToken
+	// showDeleted нужен, чтобы и полная синхронизация возвращала отменённые события
+	q := url.Values{}
+	q.Set("singleEvents", "true")
+	q.Set("showDeleted", "true")
+	if syncToken != "" {
+		q.Set("syncToken", syncToken)
+	} else {
+		q.Set("timeMin", startTime.Format(time.RFC3339))
 	}
 
-	var response EventsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		log.Error().Err(err).Msg("failed to decode JSON")
-		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	log.Info().
+		Str("calendar_id", calendarID).
+		Bool("incremental", syncToken != "").
+		Msg("syncing google calendar events")
+
+	response, err := listEvents(ctx, token, calendarID, q)
+	if err != nil {
+		return nil, err
 	}
 
 	log.Info().
 		Str("calendar_id", calendarID).
 		Int("event_count", len(response.Items)).
-		Msg("successfully fetched events")
+		Bool("incremental", syncToken != "").
+		Msg("successfully synced events")
 
-	return &response, nil
+	return response, nil
 }
 
-// FetchAndStoreEvents получает события и сохраняет их в базу данных
+// FetchAndStoreEvents синхронизирует события всех календарей и сохраняет их в базу данных.
+// Для календаря без сохранённого syncToken (или с устаревшим) выполняется полная
+// синхронизация за последние days дней
 func FetchAndStoreEvents(store *internal_db.Store, days int, userID uuid.UUID) error {
 	log := logger.Get()
 	ctx := context.Background()
@@ -170,19 +257,64 @@ func FetchAndStoreEvents(store *internal_db.Store, days int, userID uuid.UUID) e
 		return fmt.Errorf("failed to fetch calendars: %w", err)
 	}
 
-	// Временной диапазон
-	endTime := time.Now().UTC()
-	startTime := endTime.AddDate(0, 0, -days)
+	token, err := getAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	startTime := time.Now().UTC().AddDate(0, 0, -days)
 
 	var uuidBytes [16]byte
 	copy(uuidBytes[:], userID.Bytes())
+	pgUserID := pgtype.UUID{Bytes: uuidBytes, Valid: true}
 
 	totalEvents := 0
 
 	// Проходим по каждому календарю
 	for _, calendar := range calendars.Items {
-		// Получаем события для календаря
-		events, err := FetchEvents(calendar.ID, startTime, endTime)
+		// Праздники и дни рождения по умолчанию не синхронизируются и не учитываются
+		defaultEnabled := !calendar.IsAutoGenerated()
+		calendarRow, err := store.GoogleCalendar.UpsertCalendar(ctx, googlecalendar_db.UpsertCalendarParams{
+			UserID:          pgUserID,
+			CalendarID:      calendar.ID,
+			Summary:         pgtype.Text{String: calendar.Summary, Valid: calendar.Summary != ""},
+			Description:     pgtype.Text{String: calendar.Description, Valid: calendar.Description != ""},
+			TimeZone:        pgtype.Text{String: calendar.TimeZone, Valid: calendar.TimeZone != ""},
+			BackgroundColor: pgtype.Text{String: calendar.BackgroundColor, Valid: calendar.BackgroundColor != ""},
+			ForegroundColor: pgtype.Text{String: calendar.ForegroundColor, Valid: calendar.ForegroundColor != ""},
+			AccessRole:      pgtype.Text{String: calendar.AccessRole, Valid: calendar.AccessRole != ""},
+			IsPrimary:       calendar.Primary,
+			SyncEnabled:     defaultEnabled,
+			Included:        defaultEnabled,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("calendar_id", calendar.ID).Msg("failed to upsert calendar")
+			continue
+		}
+		if !calendarRow.SyncEnabled {
+			log.Debug().Str("calendar_id", calendar.ID).Msg("calendar sync disabled, skipping")
+			continue
+		}
+
+		syncToken := ""
+		state, err := store.GoogleCalendar.GetSyncState(ctx, googlecalendar_db.GetSyncStateParams{
+			UserID:     pgUserID,
+			CalendarID: calendar.ID,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			log.Error().Err(err).Str("calendar_id", calendar.ID).Msg("failed to get sync state")
+			continue
+		}
+		if err == nil {
+			syncToken = state.SyncToken.String
+		}
+
+		events, err := SyncEvents(ctx, token, calendar.ID, syncToken, startTime)
+		if errors.Is(err, ErrSyncTokenExpired) {
+			log.Warn().Str("calendar_id", calendar.ID).Msg("sync token expired, running full sync")
+			syncToken = ""
+			events, err = SyncEvents(ctx, token, calendar.ID, "", startTime)
+		}
 		if err != nil {
 			log.Error().
 				Err(err).
@@ -191,64 +323,59 @@ func FetchAndStoreEvents(store *internal_db.Store, days int, userID uuid.UUID) e
 			continue
 		}
 
-		// Сохраняем события в БД
-		for _, event := range events.Items {
-			if event.Status == "cancelled" {
-				continue
-			}
-
-			eventStartTime, err := event.Start.ParseEventTime()
-			if err != nil {
-				log.Error().Err(err).Str("event_id", event.ID).Msg("failed to parse start time")
-				continue
-			}
-
-			eventEndTime, err := event.End.ParseEventTime()
-			if err != nil {
-				log.Error().Err(err).Str("event_id", event.ID).Msg("failed to parse end time")
-				continue
-			}
-
-			duration, _ := event.GetDuration()
-
-			err = store.ExecTxGoogleCalendar(ctx, func(q *googlecalendar_db.Queries) error {
-				_, err := q.UpsertEvent(ctx, googlecalendar_db.UpsertEventParams{
-					UserID:      pgtype.UUID{Bytes: uuidBytes, Valid: true},
-					EventID:     event.ID,
-					CalendarID:  calendar.ID,
-					Summary:     pgtype.Text{String: event.Summary, Valid: event.Summary != ""},
-					Description: pgtype.Text{String: event.Description, Valid: event.Description != ""},
-					Location:    pgtype.Text{String: event.Location, Valid: event.Location != ""},
-					StartTime:   pgtype.Timestamptz{Time: eventStartTime, Valid: true},
-					EndTime:     pgtype.Timestamptz{Time: eventEndTime, Valid: true},
-					IsAllDay:    pgtype.Bool{Bool: event.IsAllDayEvent(), Valid: true},
-					Duration:    pgtype.Int4{Int32: int32(duration.Minutes()), Valid: true},
-					Status:      pgtype.Text{String: event.Status, Valid: true},
-				})
+		// События и новый syncToken сохраняются в одной транзакции,
+		// чтобы токен не сдвинулся при ошибке записи
+		saved, cancelled := 0, 0
+		err = store.ExecTxGoogleCalendar(ctx, func(q *googlecalendar_db.Queries) error {
+			saved, cancelled = 0, 0
+			for _, event := range events.Items {
+				// Отменённые и удалённые события приходят только с id и статусом,
+				// поэтому помечаем уже сохранённую строку вместо upsert
+				if event.Status == EventStatusCancelled {
+					rows, err := q.CancelEvent(ctx, googlecalendar_db.CancelEventParams{
+						UserID:  pgUserID,
+						EventID: event.ID,
+					})
+					if err != nil {
+						log.Error().
+							Err(err).
+							Str("event_id", event.ID).
+							Msg("failed to cancel event")
+						return fmt.Errorf("failed to cancel event: %w", err)
+					}
+					cancelled += int(rows)
+					continue
+				}
 
+				ok, err := storeEvent(ctx, q, pgUserID, calendar.ID, &event)
 				if err != nil {
-					log.Error().
-						Err(err).
-						Str("event_id", event.ID).
-						Msg("failed to upsert event")
-					return fmt.Errorf("failed to upsert event: %w", err)
+					return err
+				}
+				if ok {
+					saved++
 				}
-
-				return nil
-			})
-
-			if err != nil {
-				log.Error().Err(err).Str("event_id", event.ID).Msg("transaction failed")
-				continue
 			}
 
-			totalEvents++
+			return q.UpsertSyncState(ctx, googlecalendar_db.UpsertSyncStateParams{
+				UserID:     pgUserID,
+				CalendarID: calendar.ID,
+				SyncToken:  pgtype.Text{String: events.NextSyncToken, Valid: events.NextSyncToken != ""},
+				FullSync:   syncToken == "",
+			})
+		})
+		if err != nil {
+			log.Error().Err(err).Str("calendar_id", calendar.ID).Msg("transaction failed")
+			continue
 		}
 
+		totalEvents += saved
+
 		log.Info().
 			Str("calendar_id", calendar.ID).
 			Str("calendar_name", calendar.Summary).
 			Int("events_count", len(events.Items)).
+			Int("events_cancelled", cancelled).
+			Bool("incremental", syncToken != "").
 			Msg("processed calendar events")
 	}
 
@@ -259,3 +386,109 @@ func FetchAndStoreEvents(store *internal_db.Store, days int, userID uuid.UUID) e
 
 	return nil
 }
+
+// storeEvent сохраняет событие вместе с участниками. Событие с неразбираемым временем
+// пропускается с записью в лог, тогда возвращается false
+func storeEvent(ctx context.Context, q *googlecalendar_db.Queries, userID pgtype.UUID, calendarID string, event *Event) (bool, error) {
+	log := logger.Get()
+
+	eventStartTime, err := event.Start.ParseEventTime()
+	if err != nil {
+		log.Error().Err(err).Str("event_id", event.ID).Msg("failed to parse start time")
+		return false, nil
+	}
+
+	eventEndTime, err := event.End.ParseEventTime()
+	if err != nil {
+		log.Error().Err(err).Str("event_id", event.ID).Msg("failed to parse end time")
+		return false, nil
+	}
+
+	duration, _ := event.GetDuration()
+	organizerEmail, organizerName := event.OrganizerInfo()
+	responseStatus := event.SelfResponseStatus()
+
+	transparency := event.Transparency
+	if transparency == "" {
+		transparency = TransparencyOpaque
+	}
+	eventType := event.EventType
+	if eventType == "" {
+		eventType = EventTypeDefault
+	}
+
+	_, err = q.UpsertEvent(ctx, googlecalendar_db.UpsertEventParams{
+		UserID:           userID,
+		EventID:          event.ID,
+		CalendarID:       calendarID,
+		Summary:          pgtype.Text{String: event.Summary, Valid: event.Summary != ""},
+		Description:      pgtype.Text{String: event.Description, Valid: event.Description != ""},
+		Location:         pgtype.Text{String: event.Location, Valid: event.Location != ""},
+		StartTime:        pgtype.Timestamptz{Time: eventStartTime, Valid: true},
+		EndTime:          pgtype.Timestamptz{Time: eventEndTime, Valid: true},
+		IsAllDay:         pgtype.Bool{Bool: event.IsAllDayEvent(), Valid: true},
+		Duration:         pgtype.Int4{Int32: int32(duration.Minutes()), Valid: true},
+		Status:           pgtype.Text{String: event.Status, Valid: true},
+		OrganizerEmail:   pgtype.Text{String: organizerEmail, Valid: organizerEmail != ""},
+		OrganizerName:    pgtype.Text{String: organizerName, Valid: organizerName != ""},
+		RecurringEventID: pgtype.Text{String: event.RecurringEventID, Valid: event.RecurringEventID != ""},
+		Recurrence:       event.Recurrence,
+		Transparency:     pgtype.Text{String: transparency, Valid: true},
+		EventType:        pgtype.Text{String: eventType, Valid: true},
+		ResponseStatus:   pgtype.Text{String: responseStatus, Valid: responseStatus != ""},
+		AttendeesCount:   int32(len(event.Attendees)),
+		IcalUid:          pgtype.Text{String: event.ICalUID, Valid: event.ICalUID != ""},
+		SourceHref:       pgtype.Text{String: event.SourceHref, Valid: event.SourceHref != ""},
+	})
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("event_id", event.ID).
+			Msg("failed to upsert event")
+		return false, fmt.Errorf("failed to upsert event: %w", err)
+	}
+
+	if err := saveAttendees(ctx, q, userID, event); err != nil {
+		log.Error().
+			Err(err).
+			Str("event_id", event.ID).
+			Msg("failed to save attendees")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// saveAttendees заменяет сохранённых участников события текущим списком
+func saveAttendees(ctx context.Context, q *googlecalendar_db.Queries, userID pgtype.UUID, event *Event) error {
+	err := q.DeleteEventAttendees(ctx, googlecalendar_db.DeleteEventAttendeesParams{
+		UserID:  userID,
+		EventID: event.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete attendees: %w", err)
+	}
+
+	for _, attendee := range event.Attendees {
+		if attendee.Email == "" {
+			continue
+		}
+
+		err := q.UpsertEventAttendee(ctx, googlecalendar_db.UpsertEventAttendeeParams{
+			UserID:         userID,
+			EventID:        event.ID,
+			Email:          attendee.Email,
+			DisplayName:    pgtype.Text{String: attendee.DisplayName, Valid: attendee.DisplayName != ""},
+			ResponseStatus: pgtype.Text{String: attendee.ResponseStatus, Valid: attendee.ResponseStatus != ""},
+			IsOrganizer:    attendee.Organizer,
+			IsSelf:         attendee.Self,
+			IsOptional:     attendee.Optional,
+			IsResource:     attendee.Resource,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upsert attendee %s: %w", attendee.Email, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,569 @@
+package googlecalendar
+
+import (
+	"DataLake/auth"
+	caldavauth "DataLake/auth/caldav"
+	internal_db "DataLake/internal/db"
+	googlecalendar_db "DataLake/internal/db/googlecalendar"
+	"DataLake/internal/ical"
+	"DataLake/internal/logger"
+	"DataLake/internal/timezone"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// Префикс calendar_id для календарей CalDAV
+	CalDAVCalendarPrefix = "caldav:"
+
+	// Не реже этого интервала выполняется полная синхронизация, чтобы развернуть
+	// экземпляры повторяющихся событий, которые вошли в окно feedDaysAhead
+	caldavFullSyncInterval = 7 * 24 * time.Hour
+
+	// Сколько ресурсов запрашивается одним calendar-multiget
+	caldavMultigetBatch = 100
+)
+
+// CalDAVCalendar календарь, найденный на CalDAV сервере
+type CalDAVCalendar struct {
+	Href        string
+	DisplayName string
+	Description string
+	Color       string
+}
+
+// ID возвращает calendar_id, под которым события календаря хранятся в googlecalendar_events
+func (c CalDAVCalendar) ID() string {
+	return CalDAVCalendarPrefix + c.Href
+}
+
+// caldavResourceID возвращает префикс event_id экземпляров ресурса: при удалении или изменении
+// ресурса по нему отменяются все его экземпляры. Href и UID бывают длинными, поэтому в event_id
+// (VARCHAR(255)) попадают только их хеши, а сами значения хранятся в source_href и ical_uid
+func caldavResourceID(calendarHref, resource string) string {
+	return CalDAVCalendarPrefix + hashID(calendarHref+"\n"+resource)
+}
+
+// caldavEvent приводит экземпляр события ресурса CalDAV к модели Google Calendar
+func caldavEvent(prefix, resource string, o ical.Occurrence, owners map[string]bool) Event {
+	event := feedEvent(prefix, o, owners)
+	event.SourceHref = resource
+	return event
+}
+
+// CalDAVChanges изменения коллекции после sync-collection REPORT
+type CalDAVChanges struct {
+	Changed   []string // href изменённых и новых ресурсов
+	Deleted   []string // href удалённых ресурсов
+	SyncToken string
+}
+
+// CalDAVClient клиент CalDAV с Basic авторизацией паролем приложения
+type CalDAVClient struct {
+	baseURL  *url.URL
+	username string
+	password string
+	client   *http.Client
+}
+
+// multistatus ответ WebDAV 207 Multi-Status
+type multistatus struct {
+	Responses []davResponse `xml:"DAV: response"`
+	SyncToken string        `xml:"DAV: sync-token"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"DAV: href"`
+	Status    string        `xml:"DAV: status"`
+	Propstats []davPropstat `xml:"DAV: propstat"`
+}
+
+type davPropstat struct {
+	Prop   davProp `xml:"DAV: prop"`
+	Status string  `xml:"DAV: status"`
+}
+
+type davProp struct {
+	CurrentUserPrincipal davHref `xml:"DAV: current-user-principal"`
+	CalendarHomeSet      davHref `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+	DisplayName          string  `xml:"DAV: displayname"`
+	ResourceType         struct {
+		Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+	} `xml:"DAV: resourcetype"`
+	SupportedComponents struct {
+		Comps []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"urn:ietf:params:xml:ns:caldav comp"`
+	} `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-component-set"`
+	Description  string `xml:"urn:ietf:params:xml:ns:caldav calendar-description"`
+	Color        string `xml:"http://apple.com/ns/ical/ calendar-color"`
+	ETag         string `xml:"DAV: getetag"`
+	CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+}
+
+type davHref struct {
+	Href string `xml:"DAV: href"`
+}
+
+// prop возвращает свойства из propstat со статусом 200
+func (r davResponse) prop() davProp {
+	for _, ps := range r.Propstats {
+		if strings.Contains(ps.Status, " 200 ") {
+			return ps.Prop
+		}
+	}
+	return davProp{}
+}
+
+// NewCalDAVClientFromStorage создаёт клиента для CALDAV_URL с паролем приложения из TokenStorage
+func NewCalDAVClientFromStorage(storage auth.TokenStorage) (*CalDAVClient, error) {
+	provider := caldavauth.NewProviderFromEnv()
+	if !provider.Configured() {
+		return nil, fmt.Errorf("CALDAV_URL is not configured")
+	}
+
+	token, err := storage.LoadToken(caldavauth.ProviderName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load caldav app password: %w", err)
+	}
+
+	baseURL, err := url.Parse(provider.ServerURL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid CALDAV_URL: %w", err)
+	}
+
+	return &CalDAVClient{
+		baseURL:  baseURL,
+		username: token.UID,
+		password: token.AccessToken,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// request выполняет WebDAV запрос и разбирает ответ 207 Multi-Status
+func (c *CalDAVClient) request(ctx context.Context, method, href, depth, body string) (*multistatus, error) {
+	target, err := c.resolve(href)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", depth)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		// Неизвестный или устаревший sync-token (RFC 6578, DAV:valid-sync-token)
+		if method == "REPORT" && (resp.StatusCode == http.StatusGone || bytes.Contains(data, []byte("valid-sync-token"))) {
+			return nil, ErrSyncTokenExpired
+		}
+		return nil, fmt.Errorf("%s %s returned status %d: %s", method, href, resp.StatusCode, truncate(string(data), 512))
+	}
+
+	var ms multistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("failed to decode multistatus: %w", err)
+	}
+	return &ms, nil
+}
+
+// resolve приводит href из ответа сервера к абсолютному URL
+func (c *CalDAVClient) resolve(href string) (string, error) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid href %q: %w", href, err)
+	}
+	return c.baseURL.ResolveReference(ref).String(), nil
+}
+
+// Discover находит календари пользователя: current-user-principal -> calendar-home-set -> коллекции
+func (c *CalDAVClient) Discover(ctx context.Context) ([]CalDAVCalendar, error) {
+	ms, err := c.request(ctx, "PROPFIND", c.baseURL.Path, "0",
+		`<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:"><d:prop><d:current-user-principal/></d:prop></d:propfind>`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find principal: %w", err)
+	}
+	principal := c.baseURL.Path
+	if len(ms.Responses) > 0 && ms.Responses[0].prop().CurrentUserPrincipal.Href != "" {
+		principal = ms.Responses[0].prop().CurrentUserPrincipal.Href
+	}
+
+	ms, err = c.request(ctx, "PROPFIND", principal, "0",
+		`<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><c:calendar-home-set/></d:prop></d:propfind>`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find calendar home: %w", err)
+	}
+	home := principal
+	if len(ms.Responses) > 0 && ms.Responses[0].prop().CalendarHomeSet.Href != "" {
+		home = ms.Responses[0].prop().CalendarHomeSet.Href
+	}
+
+	ms, err = c.request(ctx, "PROPFIND", home, "1",
+		`<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:a="http://apple.com/ns/ical/">
+  <d:prop>
+    <d:resourcetype/>
+    <d:displayname/>
+    <c:calendar-description/>
+    <c:supported-calendar-component-set/>
+    <a:calendar-color/>
+  </d:prop>
+</d:propfind>`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+
+	var calendars []CalDAVCalendar
+	for _, r := range ms.Responses {
+		prop := r.prop()
+		if prop.ResourceType.Calendar == nil || !supportsEvents(prop) {
+			continue
+		}
+
+		href := r.Href
+		if u, err := url.Parse(href); err == nil {
+			href = u.Path
+		}
+		// Apple calendar-color имеет вид #RRGGBBAA, Google хранит #rrggbb
+		color := strings.TrimSpace(prop.Color)
+		if len(color) == 9 && strings.HasPrefix(color, "#") {
+			color = color[:7]
+		}
+		calendars = append(calendars, CalDAVCalendar{
+			Href:        href,
+			DisplayName: prop.DisplayName,
+			Description: prop.Description,
+			Color:       strings.ToLower(color),
+		})
+	}
+
+	return calendars, nil
+}
+
+// supportsEvents проверяет, что коллекция хранит VEVENT (а не только задачи VTODO)
+func supportsEvents(prop davProp) bool {
+	if len(prop.SupportedComponents.Comps) == 0 {
+		return true
+	}
+	for _, comp := range prop.SupportedComponents.Comps {
+		if strings.EqualFold(comp.Name, "VEVENT") {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncCollection выполняет sync-collection REPORT (RFC 6578).
+// С пустым syncToken возвращает все ресурсы коллекции, иначе - изменения с момента токена.
+// Если токен устарел, возвращается ErrSyncTokenExpired
+func (c *CalDAVClient) SyncCollection(ctx context.Context, href, syncToken string) (*CalDAVChanges, error) {
+	var token bytes.Buffer
+	xml.EscapeText(&token, []byte(syncToken))
+
+	ms, err := c.request(ctx, "REPORT", href, "1", `<?xml version="1.0" encoding="utf-8"?>
+<d:sync-collection xmlns:d="DAV:">
+  <d:sync-token>`+token.String()+`</d:sync-token>
+  <d:sync-level>1</d:sync-level>
+  <d:prop><d:getetag/></d:prop>
+</d:sync-collection>`)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &CalDAVChanges{SyncToken: ms.SyncToken}
+	for _, r := range ms.Responses {
+		resource := r.Href
+		if u, err := url.Parse(resource); err == nil {
+			resource = u.Path
+		}
+		if strings.HasSuffix(resource, "/") {
+			continue
+		}
+
+		if strings.Contains(r.Status, " 404 ") {
+			changes.Deleted = append(changes.Deleted, resource)
+		} else {
+			changes.Changed = append(changes.Changed, resource)
+		}
+	}
+
+	return changes, nil
+}
+
+// Multiget загружает calendar-data ресурсов коллекции, возвращает href -> содержимое .ics
+func (c *CalDAVClient) Multiget(ctx context.Context, href string, resources []string) (map[string]string, error) {
+	result := make(map[string]string, len(resources))
+
+	for start := 0; start < len(resources); start += caldavMultigetBatch {
+		end := start + caldavMultigetBatch
+		if end > len(resources) {
+			end = len(resources)
+		}
+
+		var body strings.Builder
+		body.WriteString(`<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+`)
+		for _, resource := range resources[start:end] {
+			body.WriteString("  <d:href>")
+			xml.EscapeText(&body, []byte(resource))
+			body.WriteString("</d:href>\n")
+		}
+		body.WriteString("</c:calendar-multiget>")
+
+		ms, err := c.request(ctx, "REPORT", href, "1", body.String())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range ms.Responses {
+			resource := r.Href
+			if u, err := url.Parse(resource); err == nil {
+				resource = u.Path
+			}
+			if data := r.prop().CalendarData; data != "" {
+				result[resource] = data
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// FetchAndStoreCalDAV синхронизирует календари CalDAV сервера из CALDAV_URL.
+// Изменения забираются через sync-collection, события сохраняются тем же путём,
+// что и события Google Calendar. Повторяющиеся события разворачиваются за последние
+// days дней и feedDaysAhead дней вперёд
+func FetchAndStoreCalDAV(store *internal_db.Store, days int, userID uuid.UUID) error {
+	log := logger.Get()
+	ctx := context.Background()
+
+	storage, err := auth.NewFileTokenStorageFromEnv("tokens.json")
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	client, err := NewCalDAVClientFromStorage(storage)
+	if err != nil {
+		return err
+	}
+
+	calendars, err := client.Discover(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to discover caldav calendars: %w", err)
+	}
+
+	var uuidBytes [16]byte
+	copy(uuidBytes[:], userID.Bytes())
+	pgUserID := pgtype.UUID{Bytes: uuidBytes, Valid: true}
+
+	owners := OwnerEmailsFromEnv()
+	for _, calendar := range calendars {
+		if err := storeCalDAVCalendar(ctx, store, client, pgUserID, calendar, owners, days); err != nil {
+			log.Error().Err(err).Str("calendar_id", calendar.ID()).Msg("failed to sync caldav calendar")
+		}
+	}
+
+	log.Info().Int("calendars", len(calendars)).Msg("successfully synced caldav calendars")
+	return nil
+}
+
+func storeCalDAVCalendar(ctx context.Context, store *internal_db.Store, client *CalDAVClient, userID pgtype.UUID, calendar CalDAVCalendar, owners map[string]bool, days int) error {
+	log := logger.Get()
+	calendarID := calendar.ID()
+
+	summary := calendar.DisplayName
+	if summary == "" {
+		summary = path.Base(strings.TrimSuffix(calendar.Href, "/"))
+	}
+	calendarRow, err := store.GoogleCalendar.UpsertCalendar(ctx, googlecalendar_db.UpsertCalendarParams{
+		UserID:          userID,
+		CalendarID:      calendarID,
+		Summary:         pgtype.Text{String: summary, Valid: true},
+		Description:     pgtype.Text{String: calendar.Description, Valid: calendar.Description != ""},
+		BackgroundColor: pgtype.Text{String: calendar.Color, Valid: calendar.Color != ""},
+		AccessRole:      pgtype.Text{String: "owner", Valid: true},
+		SyncEnabled:     true,
+		Included:        true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert calendar: %w", err)
+	}
+	if !calendarRow.SyncEnabled {
+		log.Debug().Str("calendar_id", calendarID).Msg("calendar sync disabled, skipping")
+		return nil
+	}
+
+	syncToken := ""
+	state, err := store.GoogleCalendar.GetSyncState(ctx, googlecalendar_db.GetSyncStateParams{
+		UserID:     userID,
+		CalendarID: calendarID,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get sync state: %w", err)
+	}
+	if err == nil && state.LastFullSyncAt.Valid && time.Since(state.LastFullSyncAt.Time) < caldavFullSyncInterval {
+		syncToken = state.SyncToken.String
+	}
+
+	changes, err := client.SyncCollection(ctx, calendar.Href, syncToken)
+	if errors.Is(err, ErrSyncTokenExpired) {
+		log.Warn().Str("calendar_id", calendarID).Msg("sync token expired, running full sync")
+		syncToken = ""
+		changes, err = client.SyncCollection(ctx, calendar.Href, "")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync collection: %w", err)
+	}
+
+	resources, err := client.Multiget(ctx, calendar.Href, changes.Changed)
+	if err != nil {
+		return fmt.Errorf("failed to fetch calendar data: %w", err)
+	}
+
+	loc := timezone.Location()
+	now := time.Now()
+	from := now.AddDate(0, 0, -days)
+	to := now.AddDate(0, 0, feedDaysAhead)
+
+	saved, cancelled := 0, int64(0)
+	err = store.ExecTxGoogleCalendar(ctx, func(q *googlecalendar_db.Queries) error {
+		saved, cancelled = 0, 0
+		allIDs, failed := []string{}, []string{}
+
+		for _, resource := range changes.Changed {
+			prefix := caldavResourceID(calendar.Href, resource)
+
+			// События ресурса, который не удалось загрузить, остаются как есть до следующей синхронизации
+			data, ok := resources[resource]
+			if !ok {
+				log.Warn().Str("href", resource).Msg("calendar data missing from multiget response")
+				failed = append(failed, prefix+":")
+				continue
+			}
+			cal, err := ical.Parse(strings.NewReader(data), loc)
+			if err != nil {
+				log.Error().Err(err).Str("href", resource).Msg("failed to parse calendar data")
+				failed = append(failed, prefix+":")
+				continue
+			}
+			occurrences := ical.Expand(cal.Events, from, to)
+
+			eventIDs := make([]string, 0, len(occurrences))
+			for _, occurrence := range occurrences {
+				event := caldavEvent(prefix, resource, occurrence, owners)
+				ok, err := storeEvent(ctx, q, userID, calendarID, &event)
+				if err != nil {
+					return err
+				}
+				if ok {
+					saved++
+				}
+				eventIDs = append(eventIDs, event.ID)
+			}
+			allIDs = append(allIDs, eventIDs...)
+
+			rows, err := q.CancelEventsByPrefix(ctx, googlecalendar_db.CancelEventsByPrefixParams{
+				UserID:     userID,
+				CalendarID: calendarID,
+				Prefix:     prefix + ":",
+				EventIds:   eventIDs,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to cancel removed instances: %w", err)
+			}
+			cancelled += rows
+		}
+
+		for _, resource := range changes.Deleted {
+			rows, err := q.CancelEventsByPrefix(ctx, googlecalendar_db.CancelEventsByPrefixParams{
+				UserID:     userID,
+				CalendarID: calendarID,
+				Prefix:     caldavResourceID(calendar.Href, resource) + ":",
+				EventIds:   []string{},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to cancel deleted events: %w", err)
+			}
+			cancelled += rows
+		}
+
+		// Полная синхронизация возвращает все ресурсы, остальные события календаря удалены
+		if syncToken == "" {
+			rows, err := q.CancelMissingEvents(ctx, googlecalendar_db.CancelMissingEventsParams{
+				UserID:       userID,
+				CalendarID:   calendarID,
+				StartTime:    pgtype.Timestamptz{Time: from, Valid: true},
+				EndTime:      pgtype.Timestamptz{Time: to, Valid: true},
+				EventIds:     allIDs,
+				KeepPrefixes: failed,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to cancel missing events: %w", err)
+			}
+			cancelled += rows
+		}
+
+		// sync-token не сдвигается, чтобы следующая синхронизация снова забрала незагруженные ресурсы
+		if len(failed) > 0 {
+			log.Warn().Str("calendar_id", calendarID).Int("resources_failed", len(failed)).Msg("keeping previous sync token")
+			return nil
+		}
+
+		return q.UpsertSyncState(ctx, googlecalendar_db.UpsertSyncStateParams{
+			UserID:     userID,
+			CalendarID: calendarID,
+			SyncToken:  pgtype.Text{String: changes.SyncToken, Valid: changes.SyncToken != ""},
+			FullSync:   syncToken == "",
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("transaction failed: %w", err)
+	}
+
+	log.Info().
+		Str("calendar_id", calendarID).
+		Str("calendar_name", summary).
+		Int("resources_changed", len(changes.Changed)).
+		Int("resources_deleted", len(changes.Deleted)).
+		Int("events_count", saved).
+		Int64("events_cancelled", cancelled).
+		Bool("incremental", syncToken != "").
+		Msg("processed caldav calendar events")
+
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,285 @@
+package googlecalendar
+
+import (
+	internal_db "DataLake/internal/db"
+	googlecalendar_db "DataLake/internal/db/googlecalendar"
+	"DataLake/internal/ical"
+	"DataLake/internal/logger"
+	"DataLake/internal/timezone"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// Префикс calendar_id для календарей из iCalendar-фидов
+	FeedCalendarPrefix = "ics:"
+
+	// На сколько дней вперёд разворачиваются повторяющиеся события фидов
+	feedDaysAhead = 365
+
+	// Предельный размер фида; больший фид считается ошибкой, а не обрезается
+	maxFeedSize = 50 << 20
+)
+
+// Feed календарь в формате iCalendar: URL подписки (http, https, webcal) или путь к .ics файлу
+type Feed struct {
+	Name string
+	URL  string
+}
+
+// CalendarID возвращает calendar_id, под которым события фида хранятся в googlecalendar_events
+func (f Feed) CalendarID() string {
+	return FeedCalendarPrefix + f.Name
+}
+
+// FeedsFromEnv читает фиды из ICS_FEEDS в формате name=url через запятую
+func FeedsFromEnv() []Feed {
+	var feeds []Feed
+	for _, entry := range strings.Split(os.Getenv("ICS_FEEDS"), ",") {
+		name, feedURL, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" || feedURL == "" {
+			continue
+		}
+		feeds = append(feeds, Feed{Name: strings.TrimSpace(name), URL: strings.TrimSpace(feedURL)})
+	}
+	return feeds
+}
+
+// OwnerEmailsFromEnv читает адреса пользователя из CALENDAR_OWNER_EMAILS через запятую.
+// По ним в событиях iCalendar и CalDAV находится участник self
+func OwnerEmailsFromEnv() map[string]bool {
+	owners := make(map[string]bool)
+	for _, email := range strings.Split(os.Getenv("CALENDAR_OWNER_EMAILS"), ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			owners[email] = true
+		}
+	}
+	return owners
+}
+
+// FetchFeed загружает и разбирает фид. Время без часового пояса считается в loc
+func FetchFeed(ctx context.Context, feedURL string, loc *time.Location) (*ical.Calendar, error) {
+	if strings.HasPrefix(feedURL, "webcal://") {
+		feedURL = "https://" + strings.TrimPrefix(feedURL, "webcal://")
+	}
+
+	if !strings.HasPrefix(feedURL, "http://") && !strings.HasPrefix(feedURL, "https://") {
+		f, err := os.Open(strings.TrimPrefix(feedURL, "file://"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open feed file: %w", err)
+		}
+		defer f.Close()
+		return parseFeed(f, loc)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("feed returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return parseFeed(resp.Body, loc)
+}
+
+// parseFeed разбирает фид не больше maxFeedSize байт
+func parseFeed(r io.Reader, loc *time.Location) (*ical.Calendar, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxFeedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	if len(data) > maxFeedSize {
+		return nil, fmt.Errorf("feed exceeds %d bytes", maxFeedSize)
+	}
+	return ical.Parse(bytes.NewReader(data), loc)
+}
+
+// FetchAndStoreFeeds загружает все фиды из ICS_FEEDS и сохраняет события за последние days дней
+// и feedDaysAhead дней вперёд. Каждый фид - отдельный календарь в googlecalendar_calendars,
+// поэтому к нему применяются настройки sync_enabled и included
+func FetchAndStoreFeeds(store *internal_db.Store, days int, userID uuid.UUID) error {
+	log := logger.Get()
+	ctx := context.Background()
+
+	var uuidBytes [16]byte
+	copy(uuidBytes[:], userID.Bytes())
+	pgUserID := pgtype.UUID{Bytes: uuidBytes, Valid: true}
+
+	loc := timezone.Location()
+	now := time.Now()
+	from := now.AddDate(0, 0, -days)
+	to := now.AddDate(0, 0, feedDaysAhead)
+
+	owners := OwnerEmailsFromEnv()
+
+	var failed []string
+	for _, feed := range FeedsFromEnv() {
+		if err := storeFeed(ctx, store, pgUserID, feed, owners, loc, from, to); err != nil {
+			log.Error().Err(err).Str("feed", feed.Name).Msg("failed to store ics feed")
+			failed = append(failed, feed.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to store ics feeds: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func storeFeed(ctx context.Context, store *internal_db.Store, userID pgtype.UUID, feed Feed, owners map[string]bool, loc *time.Location, from, to time.Time) error {
+	log := logger.Get()
+
+	cal, err := FetchFeed(ctx, feed.URL, loc)
+	if err != nil {
+		return err
+	}
+
+	summary := cal.Name
+	if summary == "" {
+		summary = feed.Name
+	}
+	calendarID := feed.CalendarID()
+	calendarRow, err := store.GoogleCalendar.UpsertCalendar(ctx, googlecalendar_db.UpsertCalendarParams{
+		UserID:      userID,
+		CalendarID:  calendarID,
+		Summary:     pgtype.Text{String: summary, Valid: true},
+		TimeZone:    pgtype.Text{String: cal.TimeZone, Valid: cal.TimeZone != ""},
+		AccessRole:  pgtype.Text{String: "reader", Valid: true},
+		SyncEnabled: true,
+		Included:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert calendar: %w", err)
+	}
+	if !calendarRow.SyncEnabled {
+		log.Debug().Str("calendar_id", calendarID).Msg("calendar sync disabled, skipping")
+		return nil
+	}
+
+	occurrences := ical.Expand(cal.Events, from, to)
+
+	saved, cancelled := 0, int64(0)
+	err = store.ExecTxGoogleCalendar(ctx, func(q *googlecalendar_db.Queries) error {
+		saved = 0
+		eventIDs := make([]string, 0, len(occurrences))
+		for _, occurrence := range occurrences {
+			event := feedEvent(calendarID, occurrence, owners)
+			ok, err := storeEvent(ctx, q, userID, calendarID, &event)
+			if err != nil {
+				return err
+			}
+			if ok {
+				saved++
+			}
+			eventIDs = append(eventIDs, event.ID)
+		}
+
+		cancelled, err = q.CancelMissingEvents(ctx, googlecalendar_db.CancelMissingEventsParams{
+			UserID:       userID,
+			CalendarID:   calendarID,
+			StartTime:    pgtype.Timestamptz{Time: from, Valid: true},
+			EndTime:      pgtype.Timestamptz{Time: to, Valid: true},
+			EventIds:     eventIDs,
+			KeepPrefixes: []string{},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to cancel missing events: %w", err)
+		}
+
+		return q.UpsertSyncState(ctx, googlecalendar_db.UpsertSyncStateParams{
+			UserID:     userID,
+			CalendarID: calendarID,
+			FullSync:   true,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("transaction failed: %w", err)
+	}
+
+	log.Info().
+		Str("calendar_id", calendarID).
+		Str("calendar_name", summary).
+		Int("events_count", saved).
+		Int64("events_cancelled", cancelled).
+		Msg("processed ics feed events")
+
+	return nil
+}
+
+// feedEvent приводит экземпляр события iCalendar к модели Google Calendar.
+// id экземпляра и серии - idPrefix и хеш InstanceID и UID: так они не пересекаются с id Google
+// и помещаются в VARCHAR(255) при любой длине UID. Исходный UID сохраняется в ICalUID.
+// Участник и организатор с адресом из owners отмечаются как self
+func feedEvent(idPrefix string, o ical.Occurrence, owners map[string]bool) Event {
+	event := Event{
+		ID:           idPrefix + ":" + hashID(o.InstanceID),
+		Status:       o.Status,
+		Summary:      o.Summary,
+		Description:  o.Description,
+		Location:     o.Location,
+		Transparency: o.Transparency,
+		EventType:    EventTypeDefault,
+		ICalUID:      o.UID,
+	}
+	if event.Status == "" {
+		event.Status = EventStatusConfirmed
+	}
+	if o.RecurringUID != "" {
+		event.RecurringEventID = idPrefix + ":" + hashID(o.RecurringUID)
+	}
+	if o.RRule != "" {
+		event.Recurrence = []string{"RRULE:" + o.RRule}
+	}
+
+	if o.AllDay {
+		event.Start = &EventDateTime{Date: o.Start.Format("2006-01-02")}
+		event.End = &EventDateTime{Date: o.End.Format("2006-01-02")}
+	} else {
+		event.Start = &EventDateTime{DateTime: o.Start.Format(time.RFC3339), TimeZone: o.Start.Location().String()}
+		event.End = &EventDateTime{DateTime: o.End.Format(time.RFC3339), TimeZone: o.End.Location().String()}
+	}
+
+	if o.Organizer != nil {
+		event.Organizer = &Person{Email: o.Organizer.Email, DisplayName: o.Organizer.Name, Self: owners[o.Organizer.Email]}
+	}
+	for _, a := range o.Attendees {
+		event.Attendees = append(event.Attendees, Attendee{
+			Email:          a.Email,
+			DisplayName:    a.Name,
+			Organizer:      o.Organizer != nil && a.Email == o.Organizer.Email,
+			Self:           owners[a.Email],
+			Resource:       a.Resource,
+			Optional:       a.Optional,
+			ResponseStatus: a.PartStat,
+		})
+	}
+
+	return event
+}
+
+func hashID(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,7 @@
 package googlecalendar
 
 import (
+	"strings"
 	"time"
 )
 
@@ -28,6 +29,13 @@ type Calendar struct {
 	Primary         bool   `json:"primary,omitempty"`
 }
 
+// IsAutoGenerated проверяет, является ли календарь автоматическим календарём
+// праздников или дней рождения Google
+func (c *Calendar) IsAutoGenerated() bool {
+	return strings.HasSuffix(c.ID, "#holiday@group.v.calendar.google.com") ||
+		strings.HasSuffix(c.ID, "#contacts@group.v.calendar.google.com")
+}
+
 // EventsResponse представляет список событий
 type EventsResponse struct {
 	Kind          string  `json:"kind"`
@@ -37,6 +45,7 @@ type EventsResponse struct {
 	TimeZone      string  `json:"timeZone"`
 	AccessRole    string  `json:"accessRole"`
 	NextPageToken string  `json:"nextPageToken,omitempty"`
+	NextSyncToken string  `json:"nextSyncToken,omitempty"`
 	Items         []Event `json:"items"`
 }
 
@@ -65,8 +74,34 @@ type Event struct {
 	Recurrence       []string       `json:"recurrence,omitempty"`
 	RecurringEventID string         `json:"recurringEventId,omitempty"`
 	EventType        string         `json:"eventType,omitempty"`
+
+	// SourceHref href ресурса CalDAV, из которого получено событие; в API Google Calendar его нет
+	SourceHref string `json:"-"`
 }
 
+// Статусы события
+const (
+	EventStatusConfirmed = "confirmed"
+	EventStatusTentative = "tentative"
+	EventStatusCancelled = "cancelled"
+)
+
+// Значения transparency, eventType и responseStatus
+const (
+	TransparencyOpaque      = "opaque"
+	TransparencyTransparent = "transparent"
+
+	EventTypeDefault         = "default"
+	EventTypeOutOfOffice     = "outOfOffice"
+	EventTypeFocusTime       = "focusTime"
+	EventTypeWorkingLocation = "workingLocation"
+
+	ResponseStatusNeedsAction = "needsAction"
+	ResponseStatusDeclined    = "declined"
+	ResponseStatusTentative   = "tentative"
+	ResponseStatusAccepted    = "accepted"
+)
+
 // EventDateTime представляет дату/время события
 type EventDateTime struct {
 	Date     string `json:"date,omitempty"`     // Для событий на весь день (формат: yyyy-mm-dd)
@@ -126,3 +161,22 @@ func (e *Event) GetDuration() (time.Duration, error) {
 func (e *Event) IsAllDayEvent() bool {
 	return e.Start != nil && e.Start.Date != ""
 }
+
+// SelfResponseStatus возвращает ответ самого пользователя на приглашение.
+// Для событий без участников возвращается пустая строка
+func (e *Event) SelfResponseStatus() string {
+	for _, attendee := range e.Attendees {
+		if attendee.Self {
+			return attendee.ResponseStatus
+		}
+	}
+	return ""
+}
+
+// OrganizerInfo возвращает email и имя организатора события
+func (e *Event) OrganizerInfo() (string, string) {
+	if e.Organizer == nil {
+		return "", ""
+	}
+	return e.Organizer.Email, e.Organizer.DisplayName
+}
@@ -7,45 +7,60 @@ import (
 	googlefit_db "DataLake/internal/db/googlefit"
 	"DataLake/internal/logger"
 	"DataLake/internal/metrics"
+	"DataLake/internal/timezone"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	uuid "github.com/satori/go.uuid"
 )
 
-// FetchSummaries получает агрегированные данные за последние N дней
-func FetchSummaries(days int) (*AggregatedDataResponse, error) {
+const fitnessAPIBaseURL = "https://www.googleapis.com/fitness/v1"
+
+// getAccessToken возвращает действующий access token Google Fit
+func getAccessToken(ctx context.Context) (string, error) {
 	log := logger.Get()
-	start := time.Now()
-	metrics.GoogleFitFetchTotal.Inc()
-	ctx := context.Background()
 
 	storage, err := auth.NewFileTokenStorageFromEnv("tokens.json")
 	if err != nil {
-		metrics.GoogleFitFetchErrors.Inc()
 		log.Error().Err(err).Msg("failed to initialize token storage")
-		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+		return "", fmt.Errorf("failed to initialize storage: %w", err)
 	}
 	provider := googlefitauth.NewProviderFromEnv()
 	tokenManager := auth.NewTokenManager(storage, provider)
 
 	token, err := tokenManager.GetValidToken(ctx, "googlefit")
 	if err != nil {
-		metrics.GoogleFitFetchErrors.Inc()
 		log.Error().Err(err).Msg("failed to get valid token")
-		return nil, fmt.Errorf("failed to get valid token: %w", err)
+		return "", fmt.Errorf("failed to get valid token: %w", err)
 	}
 
-	url := "https://www.googleapis.com/fitness/v1/users/me/dataset:aggregate"
+	return token.AccessToken, nil
+}
 
-	endTime := time.Now().UTC()
-	startTime := endTime.AddDate(0, 0, -days)
+// FetchSummaries получает агрегированные данные за последние N дней
+func FetchSummaries(days int) (*AggregatedDataResponse, error) {
+	log := logger.Get()
+	start := time.Now()
+	metrics.GoogleFitFetchTotal.Inc()
+	ctx := context.Background()
+
+	token, err := getAccessToken(ctx)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, err
+	}
+
+	// Дни выравниваются по полуночи в часовом поясе пользователя
+	loc := timezone.Location()
+	endTime := time.Now()
+	startTime := timezone.StartOfDay(endTime, loc).AddDate(0, 0, -days)
 
 	aggregateBy := map[string]interface{}{
 		"aggregateBy": []map[string]string{
@@ -55,9 +70,25 @@ func FetchSummaries(days int) (*AggregatedDataResponse, error) {
 			{
 				"dataTypeName": DataTypeDistance,
 			},
+			{
+				"dataTypeName": DataTypeHeartRate,
+			},
+			{
+				"dataTypeName": DataTypeCalories,
+			},
+			{
+				"dataTypeName": DataTypeMoveMinutes,
+			},
+			{
+				"dataTypeName": DataTypeHeartPoints,
+			},
 		},
-		"bucketByTime": map[string]int64{
-			"durationMillis": 86400000, // 1 день
+		"bucketByTime": map[string]interface{}{
+			"period": map[string]interface{}{
+				"type":       "day",
+				"value":      1,
+				"timeZoneId": loc.String(),
+			},
 		},
 		"startTimeMillis": startTime.UnixMilli(),
 		"endTimeMillis":   endTime.UnixMilli(),
@@ -67,28 +98,45 @@ func FetchSummaries(days int) (*AggregatedDataResponse, error) {
 		Str("start_date", startTime.Format("2006-01-02")).
 		Str("end_date", endTime.Format("2006-01-02")).
 		Int("days", days).
+		Str("timezone", loc.String()).
 		Msg("fetching google fit summaries")
 
-	bodyBytes, err := json.Marshal(aggregateBy)
+	response, err := fetchAggregate(ctx, token, aggregateBy)
 	if err != nil {
 		metrics.GoogleFitFetchErrors.Inc()
+		return nil, err
+	}
+
+	metrics.GoogleFitFetchDuration.Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("buckets_fetched", len(response.Bucket)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully fetched google fit summaries")
+
+	return response, nil
+}
+
+// fetchAggregate выполняет запрос dataset:aggregate
+func fetchAggregate(ctx context.Context, token string, aggregateBy map[string]interface{}) (*AggregatedDataResponse, error) {
+	log := logger.Get()
+
+	bodyBytes, err := json.Marshal(aggregateBy)
+	if err != nil {
 		log.Error().Err(err).Msg("failed to marshal request body")
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", fitnessAPIBaseURL+"/users/me/dataset:aggregate", bytes.NewBuffer(bodyBytes))
 	if err != nil {
-		metrics.GoogleFitFetchErrors.Inc()
 		log.Error().Err(err).Msg("failed to create request")
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		metrics.GoogleFitFetchErrors.Inc()
 		log.Error().Err(err).Msg("failed to execute request")
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -96,7 +144,6 @@ func FetchSummaries(days int) (*AggregatedDataResponse, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		metrics.GoogleFitFetchErrors.Inc()
 		log.Error().
 			Int("status_code", resp.StatusCode).
 			Str("response", string(body)).
@@ -106,17 +153,10 @@ func FetchSummaries(days int) (*AggregatedDataResponse, error) {
 
 	var response AggregatedDataResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		metrics.GoogleFitFetchErrors.Inc()
 		log.Error().Err(err).Msg("failed to decode JSON")
 		return nil, fmt.Errorf("failed to decode JSON: %w", err)
 	}
 
-	metrics.GoogleFitFetchDuration.Observe(time.Since(start).Seconds())
-	log.Info().
-		Int("buckets_fetched", len(response.Bucket)).
-		Dur("duration", time.Since(start)).
-		Msg("successfully fetched google fit summaries")
-
 	return &response, nil
 }
 
@@ -130,7 +170,7 @@ func SaveSummaries(store *internal_db.Store, response *AggregatedDataResponse, u
 	var uuidBytes [16]byte
 	copy(uuidBytes[:], userID.Bytes())
 
-	stats, err := response.ExtractDailyStats()
+	stats, err := response.ExtractDailyStats(timezone.Location())
 	if err != nil {
 		log.Error().Err(err).Msg("failed to extract daily stats")
 		return fmt.Errorf("failed to extract daily stats: %w", err)
@@ -159,6 +199,34 @@ func SaveSummaries(store *internal_db.Store, response *AggregatedDataResponse, u
 			}
 			metrics.DatabaseOperationsTotal.WithLabelValues("upsert_daily_stat", "success").Inc()
 
+			if stat.HasHeartRate {
+				_, err := q.UpsertDailyHeartRate(ctx, googlefit_db.UpsertDailyHeartRateParams{
+					UserID: pgtype.UUID{Bytes: uuidBytes, Valid: true},
+					Date:   pgtype.Date{Time: stat.Date, Valid: true},
+					MinBpm: stat.MinBpm,
+					AvgBpm: stat.AvgBpm,
+					MaxBpm: stat.MaxBpm,
+				})
+				if err != nil {
+					metrics.DatabaseOperationsTotal.WithLabelValues("upsert_daily_heart_rate", "error").Inc()
+					return fmt.Errorf("failed to upsert heart rate for %s: %w", stat.Date.Format("2006-01-02"), err)
+				}
+				metrics.DatabaseOperationsTotal.WithLabelValues("upsert_daily_heart_rate", "success").Inc()
+			}
+
+			_, err = q.UpsertDailyActivity(ctx, googlefit_db.UpsertDailyActivityParams{
+				UserID:      pgtype.UUID{Bytes: uuidBytes, Valid: true},
+				Date:        pgtype.Date{Time: stat.Date, Valid: true},
+				Calories:    pgtype.Float8{Float64: stat.Calories, Valid: true},
+				MoveMinutes: pgtype.Int4{Int32: int32(stat.MoveMinutes), Valid: true},
+				HeartPoints: pgtype.Float8{Float64: stat.HeartPoints, Valid: true},
+			})
+			if err != nil {
+				metrics.DatabaseOperationsTotal.WithLabelValues("upsert_daily_activity", "error").Inc()
+				return fmt.Errorf("failed to upsert activity for %s: %w", stat.Date.Format("2006-01-02"), err)
+			}
+			metrics.DatabaseOperationsTotal.WithLabelValues("upsert_daily_activity", "success").Inc()
+
 			log.Debug().
 				Str("date", stat.Date.Format("2006-01-02")).
 				Int("steps", stat.Steps).
@@ -181,3 +249,715 @@ func SaveSummaries(store *internal_db.Store, response *AggregatedDataResponse, u
 
 	return nil
 }
+
+// fetchDataset получает сырые точки источника данных за интервал [startTime, endTime)
+func fetchDataset(ctx context.Context, token, dataSourceID string, startTime, endTime time.Time) (*Dataset, error) {
+	log := logger.Get()
+
+	reqURL := fmt.Sprintf("%s/users/me/dataSources/%s/datasets/%d-%d",
+		fitnessAPIBaseURL,
+		url.PathEscape(dataSourceID),
+		startTime.UnixNano(),
+		endTime.UnixNano(),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to execute request")
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		log.Error().
+			Int("status_code", resp.StatusCode).
+			Str("data_source", dataSourceID).
+			Str("response", string(body)).
+			Msg("unexpected status code")
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var dataset Dataset
+	if err := json.NewDecoder(resp.Body).Decode(&dataset); err != nil {
+		log.Error().Err(err).Msg("failed to decode JSON")
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	return &dataset, nil
+}
+
+// FetchSleepSegments получает сегменты сна со стадиями за последние N дней
+func FetchSleepSegments(days int) ([]SleepSegment, error) {
+	log := logger.Get()
+	start := time.Now()
+	metrics.GoogleFitFetchTotal.Inc()
+	ctx := context.Background()
+
+	token, err := getAccessToken(ctx)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, err
+	}
+
+	endTime := time.Now().UTC()
+	startTime := endTime.AddDate(0, 0, -days)
+
+	log.Info().
+		Str("start_date", startTime.Format("2006-01-02")).
+		Str("end_date", endTime.Format("2006-01-02")).
+		Msg("fetching google fit sleep segments")
+
+	dataset, err := fetchDataset(ctx, token, SleepSegmentDataSource, startTime, endTime)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, err
+	}
+
+	segments, err := dataset.ExtractSleepSegments()
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		log.Error().Err(err).Msg("failed to extract sleep segments")
+		return nil, fmt.Errorf("failed to extract sleep segments: %w", err)
+	}
+
+	metrics.GoogleFitFetchDuration.Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("segments_fetched", len(segments)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully fetched google fit sleep segments")
+
+	return segments, nil
+}
+
+// SaveSleepSegments сохраняет сегменты сна в БД
+func SaveSleepSegments(store *internal_db.Store, segments []SleepSegment, userID uuid.UUID) error {
+	log := logger.Get()
+	start := time.Now()
+
+	ctx := context.Background()
+
+	var uuidBytes [16]byte
+	copy(uuidBytes[:], userID.Bytes())
+
+	err := store.ExecTxGoogleFit(ctx, func(q *googlefit_db.Queries) error {
+		for _, segment := range segments {
+			err := q.UpsertSleepSegment(ctx, googlefit_db.UpsertSleepSegmentParams{
+				UserID:    pgtype.UUID{Bytes: uuidBytes, Valid: true},
+				StartTime: pgtype.Timestamptz{Time: segment.StartTime, Valid: true},
+				EndTime:   pgtype.Timestamptz{Time: segment.EndTime, Valid: true},
+				Stage:     int32(segment.Stage),
+			})
+			if err != nil {
+				metrics.DatabaseOperationsTotal.WithLabelValues("upsert_sleep_segment", "error").Inc()
+				log.Error().
+					Err(err).
+					Time("start_time", segment.StartTime).
+					Msg("failed to upsert sleep segment")
+				return fmt.Errorf("failed to upsert sleep segment at %s: %w", segment.StartTime.Format(time.RFC3339), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	metrics.DatabaseOperationsTotal.WithLabelValues("upsert_sleep_segment", "success").Inc()
+
+	metrics.DatabaseOperationDuration.WithLabelValues("save_googlefit_sleep").Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("segments_saved", len(segments)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully saved google fit sleep segments")
+
+	return nil
+}
+
+// FetchBodyMeasurements получает сырые измерения веса, процента жира и роста за последние N дней
+func FetchBodyMeasurements(days int) ([]BodyMeasurement, error) {
+	log := logger.Get()
+	start := time.Now()
+	metrics.GoogleFitFetchTotal.Inc()
+	ctx := context.Background()
+
+	token, err := getAccessToken(ctx)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, err
+	}
+
+	endTime := time.Now().UTC()
+	startTime := endTime.AddDate(0, 0, -days)
+
+	log.Info().
+		Str("start_date", startTime.Format("2006-01-02")).
+		Str("end_date", endTime.Format("2006-01-02")).
+		Msg("fetching google fit body measurements")
+
+	var measurements []BodyMeasurement
+	for _, metric := range []string{BodyMetricWeight, BodyMetricBodyFat, BodyMetricHeight} {
+		dataset, err := fetchDataset(ctx, token, BodyDataSources[metric], startTime, endTime)
+		if err != nil {
+			metrics.GoogleFitFetchErrors.Inc()
+			return nil, fmt.Errorf("failed to fetch %s: %w", metric, err)
+		}
+
+		points, err := dataset.ExtractBodyMeasurements(metric)
+		if err != nil {
+			metrics.GoogleFitFetchErrors.Inc()
+			log.Error().Err(err).Str("metric", metric).Msg("failed to extract body measurements")
+			return nil, fmt.Errorf("failed to extract %s: %w", metric, err)
+		}
+		measurements = append(measurements, points...)
+	}
+
+	metrics.GoogleFitFetchDuration.Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("measurements_fetched", len(measurements)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully fetched google fit body measurements")
+
+	return measurements, nil
+}
+
+// SaveBodyMeasurements сохраняет измерения тела в БД
+func SaveBodyMeasurements(store *internal_db.Store, measurements []BodyMeasurement, userID uuid.UUID) error {
+	log := logger.Get()
+	start := time.Now()
+
+	ctx := context.Background()
+
+	var uuidBytes [16]byte
+	copy(uuidBytes[:], userID.Bytes())
+
+	err := store.ExecTxGoogleFit(ctx, func(q *googlefit_db.Queries) error {
+		for _, m := range measurements {
+			err := q.UpsertBodyMeasurement(ctx, googlefit_db.UpsertBodyMeasurementParams{
+				UserID:     pgtype.UUID{Bytes: uuidBytes, Valid: true},
+				Metric:     m.Metric,
+				MeasuredAt: pgtype.Timestamptz{Time: m.MeasuredAt, Valid: true},
+				Value:      m.Value,
+			})
+			if err != nil {
+				metrics.DatabaseOperationsTotal.WithLabelValues("upsert_body_measurement", "error").Inc()
+				log.Error().
+					Err(err).
+					Str("metric", m.Metric).
+					Time("measured_at", m.MeasuredAt).
+					Msg("failed to upsert body measurement")
+				return fmt.Errorf("failed to upsert %s at %s: %w", m.Metric, m.MeasuredAt.Format(time.RFC3339), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	metrics.DatabaseOperationsTotal.WithLabelValues("upsert_body_measurement", "success").Inc()
+
+	metrics.DatabaseOperationDuration.WithLabelValues("save_googlefit_body").Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("measurements_saved", len(measurements)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully saved google fit body measurements")
+
+	return nil
+}
+
+// fetchSessions получает список сессий за интервал, включая удалённые, проходя по всем страницам
+func fetchSessions(ctx context.Context, token string, startTime, endTime time.Time) (*SessionsResponse, error) {
+	log := logger.Get()
+	client := &http.Client{Timeout: 30 * time.Second}
+	result := &SessionsResponse{}
+	pageToken := ""
+
+	for {
+		query := url.Values{}
+		query.Set("startTime", startTime.Format(time.RFC3339))
+		query.Set("endTime", endTime.Format(time.RFC3339))
+		query.Set("includeDeleted", "true")
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", fitnessAPIBaseURL+"/users/me/sessions?"+query.Encode(), nil)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to create request")
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to execute request")
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			log.Error().
+				Int("status_code", resp.StatusCode).
+				Str("response", string(body)).
+				Msg("unexpected status code")
+			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+
+		var page SessionsResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to decode JSON")
+			return nil, fmt.Errorf("failed to decode JSON: %w", err)
+		}
+
+		result.Session = append(result.Session, page.Session...)
+		result.DeletedSession = append(result.DeletedSession, page.DeletedSession...)
+
+		// API может вернуть тот же токен на последней странице
+		if page.NextPageToken == "" || page.NextPageToken == pageToken {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	return result, nil
+}
+
+// FetchWorkouts получает тренировки за последние N дней вместе с агрегатами по каждой сессии.
+// Возвращает также ID сессий, удалённых в Google Fit. Сессии сна пропускаются - они хранятся как сегменты сна.
+func FetchWorkouts(days int) ([]Workout, []string, error) {
+	log := logger.Get()
+	start := time.Now()
+	metrics.GoogleFitFetchTotal.Inc()
+	ctx := context.Background()
+
+	token, err := getAccessToken(ctx)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, nil, err
+	}
+
+	endTime := time.Now().UTC()
+	startTime := endTime.AddDate(0, 0, -days)
+
+	log.Info().
+		Str("start_date", startTime.Format("2006-01-02")).
+		Str("end_date", endTime.Format("2006-01-02")).
+		Msg("fetching google fit sessions")
+
+	sessions, err := fetchSessions(ctx, token, startTime, endTime)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, nil, err
+	}
+
+	aggregateBy := map[string]interface{}{
+		"aggregateBy": []map[string]string{
+			{"dataTypeName": DataTypeStepCount},
+			{"dataTypeName": DataTypeDistance},
+			{"dataTypeName": DataTypeCalories},
+			{"dataTypeName": DataTypeHeartRate},
+		},
+		"bucketBySession": map[string]int64{
+			"minDurationMillis": 60000, // 1 минута
+		},
+		"startTimeMillis": startTime.UnixMilli(),
+		"endTimeMillis":   endTime.UnixMilli(),
+	}
+
+	aggregated, err := fetchAggregate(ctx, token, aggregateBy)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, nil, err
+	}
+	sessionStats := aggregated.ExtractSessionStats()
+
+	workouts := make([]Workout, 0, len(sessions.Session))
+	for _, session := range sessions.Session {
+		if session.ActivityType == ActivityTypeSleep {
+			continue
+		}
+
+		sessionStart, err := session.StartTime()
+		if err != nil {
+			metrics.GoogleFitFetchErrors.Inc()
+			return nil, nil, fmt.Errorf("invalid start time for session %s: %w", session.ID, err)
+		}
+		sessionEnd, err := session.EndTime()
+		if err != nil {
+			metrics.GoogleFitFetchErrors.Inc()
+			return nil, nil, fmt.Errorf("invalid end time for session %s: %w", session.ID, err)
+		}
+
+		workouts = append(workouts, Workout{
+			SessionID:    session.ID,
+			Name:         session.Name,
+			Description:  session.Description,
+			ActivityType: session.ActivityType,
+			Application:  session.Application.PackageName,
+			StartTime:    sessionStart,
+			EndTime:      sessionEnd,
+			Stats:        sessionStats[session.ID],
+		})
+	}
+
+	deleted := make([]string, 0, len(sessions.DeletedSession))
+	for _, session := range sessions.DeletedSession {
+		deleted = append(deleted, session.ID)
+	}
+
+	metrics.GoogleFitFetchDuration.Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("sessions_fetched", len(workouts)).
+		Int("sessions_deleted", len(deleted)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully fetched google fit sessions")
+
+	return workouts, deleted, nil
+}
+
+// SaveWorkouts сохраняет тренировки в БД и удаляет сессии, удалённые в Google Fit
+func SaveWorkouts(store *internal_db.Store, workouts []Workout, deleted []string, userID uuid.UUID) error {
+	log := logger.Get()
+	start := time.Now()
+
+	ctx := context.Background()
+
+	var uuidBytes [16]byte
+	copy(uuidBytes[:], userID.Bytes())
+
+	err := store.ExecTxGoogleFit(ctx, func(q *googlefit_db.Queries) error {
+		for _, workout := range workouts {
+			err := q.UpsertSession(ctx, googlefit_db.UpsertSessionParams{
+				UserID:       pgtype.UUID{Bytes: uuidBytes, Valid: true},
+				SessionID:    workout.SessionID,
+				Name:         pgtype.Text{String: workout.Name, Valid: workout.Name != ""},
+				Description:  pgtype.Text{String: workout.Description, Valid: workout.Description != ""},
+				ActivityType: int32(workout.ActivityType),
+				Application:  pgtype.Text{String: workout.Application, Valid: workout.Application != ""},
+				StartTime:    pgtype.Timestamptz{Time: workout.StartTime, Valid: true},
+				EndTime:      pgtype.Timestamptz{Time: workout.EndTime, Valid: true},
+				Steps:        pgtype.Int4{Int32: int32(workout.Stats.Steps), Valid: true},
+				Distance:     pgtype.Float8{Float64: workout.Stats.Distance, Valid: true},
+				Calories:     pgtype.Float8{Float64: workout.Stats.Calories, Valid: true},
+				AvgBpm:       pgtype.Float8{Float64: workout.Stats.AvgBpm, Valid: workout.Stats.HasHeartRate},
+				MaxBpm:       pgtype.Float8{Float64: workout.Stats.MaxBpm, Valid: workout.Stats.HasHeartRate},
+			})
+			if err != nil {
+				metrics.DatabaseOperationsTotal.WithLabelValues("upsert_session", "error").Inc()
+				log.Error().
+					Err(err).
+					Str("session_id", workout.SessionID).
+					Msg("failed to upsert session")
+				return fmt.Errorf("failed to upsert session %s: %w", workout.SessionID, err)
+			}
+		}
+
+		if len(deleted) > 0 {
+			err := q.DeleteSessions(ctx, googlefit_db.DeleteSessionsParams{
+				UserID:     pgtype.UUID{Bytes: uuidBytes, Valid: true},
+				SessionIds: deleted,
+			})
+			if err != nil {
+				metrics.DatabaseOperationsTotal.WithLabelValues("delete_sessions", "error").Inc()
+				return fmt.Errorf("failed to delete sessions: %w", err)
+			}
+			metrics.DatabaseOperationsTotal.WithLabelValues("delete_sessions", "success").Inc()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	metrics.DatabaseOperationsTotal.WithLabelValues("upsert_session", "success").Inc()
+
+	metrics.DatabaseOperationDuration.WithLabelValues("save_googlefit_sessions").Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("sessions_saved", len(workouts)).
+		Int("sessions_deleted", len(deleted)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully saved google fit sessions")
+
+	return nil
+}
+
+// FetchHourlySummaries получает почасовую статистику за последние N дней, начиная с локальной полуночи
+func FetchHourlySummaries(days int) ([]HourlyStats, error) {
+	log := logger.Get()
+	start := time.Now()
+	metrics.GoogleFitFetchTotal.Inc()
+	ctx := context.Background()
+
+	token, err := getAccessToken(ctx)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, err
+	}
+
+	loc := timezone.Location()
+	endTime := time.Now()
+	startTime := timezone.StartOfDay(endTime, loc).AddDate(0, 0, -days)
+
+	aggregateBy := map[string]interface{}{
+		"aggregateBy": []map[string]string{
+			{"dataTypeName": DataTypeStepCount},
+			{"dataTypeName": DataTypeDistance},
+			{"dataTypeName": DataTypeCalories},
+			{"dataTypeName": DataTypeMoveMinutes},
+		},
+		"bucketByTime": map[string]int64{
+			"durationMillis": 3600000, // 1 час
+		},
+		"startTimeMillis": startTime.UnixMilli(),
+		"endTimeMillis":   endTime.UnixMilli(),
+	}
+
+	log.Info().
+		Str("start_date", startTime.Format("2006-01-02")).
+		Str("end_date", endTime.In(loc).Format("2006-01-02")).
+		Str("timezone", loc.String()).
+		Msg("fetching google fit hourly summaries")
+
+	response, err := fetchAggregate(ctx, token, aggregateBy)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, err
+	}
+
+	stats, err := response.ExtractHourlyStats()
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		log.Error().Err(err).Msg("failed to extract hourly stats")
+		return nil, fmt.Errorf("failed to extract hourly stats: %w", err)
+	}
+
+	metrics.GoogleFitFetchDuration.Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("hours_fetched", len(stats)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully fetched google fit hourly summaries")
+
+	return stats, nil
+}
+
+// SaveHourlySummaries сохраняет почасовую статистику в БД
+func SaveHourlySummaries(store *internal_db.Store, stats []HourlyStats, userID uuid.UUID) error {
+	log := logger.Get()
+	start := time.Now()
+
+	ctx := context.Background()
+
+	var uuidBytes [16]byte
+	copy(uuidBytes[:], userID.Bytes())
+
+	err := store.ExecTxGoogleFit(ctx, func(q *googlefit_db.Queries) error {
+		for _, stat := range stats {
+			err := q.UpsertHourlyStat(ctx, googlefit_db.UpsertHourlyStatParams{
+				UserID:      pgtype.UUID{Bytes: uuidBytes, Valid: true},
+				HourStart:   pgtype.Timestamptz{Time: stat.HourStart, Valid: true},
+				Steps:       pgtype.Int4{Int32: int32(stat.Steps), Valid: true},
+				Distance:    pgtype.Float8{Float64: stat.Distance, Valid: true},
+				Calories:    pgtype.Float8{Float64: stat.Calories, Valid: true},
+				MoveMinutes: pgtype.Int4{Int32: int32(stat.MoveMinutes), Valid: true},
+			})
+			if err != nil {
+				metrics.DatabaseOperationsTotal.WithLabelValues("upsert_hourly_stat", "error").Inc()
+				log.Error().
+					Err(err).
+					Time("hour_start", stat.HourStart).
+					Msg("failed to upsert hourly stat")
+				return fmt.Errorf("failed to upsert hourly stat for %s: %w", stat.HourStart.Format(time.RFC3339), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	metrics.DatabaseOperationsTotal.WithLabelValues("upsert_hourly_stat", "success").Inc()
+
+	metrics.DatabaseOperationDuration.WithLabelValues("save_googlefit_hourly").Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("hours_saved", len(stats)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully saved google fit hourly summaries")
+
+	return nil
+}
+
+// fetchDataSources получает список источников данных для указанных типов
+func fetchDataSources(ctx context.Context, token string, dataTypes []string) ([]DataSource, error) {
+	log := logger.Get()
+
+	query := url.Values{}
+	for _, dataType := range dataTypes {
+		query.Add("dataTypeName", dataType)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fitnessAPIBaseURL+"/users/me/dataSources?"+query.Encode(), nil)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to execute request")
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		log.Error().
+			Int("status_code", resp.StatusCode).
+			Str("response", string(body)).
+			Msg("unexpected status code")
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var response DataSourcesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		log.Error().Err(err).Msg("failed to decode JSON")
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	return response.DataSource, nil
+}
+
+// FetchSourceStats получает сырые источники шагов и расстояния и их дневные суммы за последние N дней.
+// Дни выравниваются по часовому поясу пользователя.
+func FetchSourceStats(days int) ([]DataSource, []SourceDailyStat, error) {
+	log := logger.Get()
+	start := time.Now()
+	metrics.GoogleFitFetchTotal.Inc()
+	ctx := context.Background()
+
+	token, err := getAccessToken(ctx)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, nil, err
+	}
+
+	loc := timezone.Location()
+	endTime := time.Now()
+	startTime := timezone.StartOfDay(endTime, loc).AddDate(0, 0, -days)
+
+	sources, err := fetchDataSources(ctx, token, SourceDataTypes)
+	if err != nil {
+		metrics.GoogleFitFetchErrors.Inc()
+		return nil, nil, err
+	}
+
+	rawSources := make([]DataSource, 0, len(sources))
+	var stats []SourceDailyStat
+	for _, source := range sources {
+		if source.Type != DataSourceTypeRaw {
+			continue
+		}
+		rawSources = append(rawSources, source)
+
+		dataset, err := fetchDataset(ctx, token, source.DataStreamID, startTime, endTime)
+		if err != nil {
+			metrics.GoogleFitFetchErrors.Inc()
+			return nil, nil, fmt.Errorf("failed to fetch data source %s: %w", source.DataStreamID, err)
+		}
+
+		sourceStats, err := dataset.ExtractSourceDailyStats(source.DataType.Name, loc)
+		if err != nil {
+			metrics.GoogleFitFetchErrors.Inc()
+			log.Error().Err(err).Str("data_source", source.DataStreamID).Msg("failed to extract source stats")
+			return nil, nil, fmt.Errorf("failed to extract stats for %s: %w", source.DataStreamID, err)
+		}
+		stats = append(stats, sourceStats...)
+	}
+
+	metrics.GoogleFitFetchDuration.Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("sources_fetched", len(rawSources)).
+		Int("source_days_fetched", len(stats)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully fetched google fit data sources")
+
+	return rawSources, stats, nil
+}
+
+// SaveSourceStats сохраняет источники данных и их дневные суммы в БД
+func SaveSourceStats(store *internal_db.Store, sources []DataSource, stats []SourceDailyStat, userID uuid.UUID) error {
+	log := logger.Get()
+	start := time.Now()
+
+	ctx := context.Background()
+
+	var uuidBytes [16]byte
+	copy(uuidBytes[:], userID.Bytes())
+
+	err := store.ExecTxGoogleFit(ctx, func(q *googlefit_db.Queries) error {
+		for _, source := range sources {
+			var device Device
+			if source.Device != nil {
+				device = *source.Device
+			}
+
+			err := q.UpsertDataSource(ctx, googlefit_db.UpsertDataSourceParams{
+				UserID:             pgtype.UUID{Bytes: uuidBytes, Valid: true},
+				DataSourceID:       source.DataStreamID,
+				DataType:           source.DataType.Name,
+				Type:               source.Type,
+				DeviceManufacturer: pgtype.Text{String: device.Manufacturer, Valid: device.Manufacturer != ""},
+				DeviceModel:        pgtype.Text{String: device.Model, Valid: device.Model != ""},
+				DeviceType:         pgtype.Text{String: device.Type, Valid: device.Type != ""},
+				Application:        pgtype.Text{String: source.Application.PackageName, Valid: source.Application.PackageName != ""},
+			})
+			if err != nil {
+				metrics.DatabaseOperationsTotal.WithLabelValues("upsert_data_source", "error").Inc()
+				log.Error().Err(err).Str("data_source", source.DataStreamID).Msg("failed to upsert data source")
+				return fmt.Errorf("failed to upsert data source %s: %w", source.DataStreamID, err)
+			}
+		}
+
+		for _, stat := range stats {
+			err := q.UpsertSourceDailyStat(ctx, googlefit_db.UpsertSourceDailyStatParams{
+				UserID:       pgtype.UUID{Bytes: uuidBytes, Valid: true},
+				DataSourceID: stat.DataSourceID,
+				DataType:     stat.DataType,
+				Date:         pgtype.Date{Time: stat.Date, Valid: true},
+				Value:        stat.Value,
+			})
+			if err != nil {
+				metrics.DatabaseOperationsTotal.WithLabelValues("upsert_source_daily_stat", "error").Inc()
+				log.Error().
+					Err(err).
+					Str("data_source", stat.DataSourceID).
+					Str("date", stat.Date.Format("2006-01-02")).
+					Msg("failed to upsert source daily stat")
+				return fmt.Errorf("failed to upsert source stat for %s: %w", stat.DataSourceID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	metrics.DatabaseOperationsTotal.WithLabelValues("upsert_source_daily_stat", "success").Inc()
+
+	metrics.DatabaseOperationDuration.WithLabelValues("save_googlefit_sources").Observe(time.Since(start).Seconds())
+	log.Info().
+		Int("sources_saved", len(sources)).
+		Int("source_days_saved", len(stats)).
+		Dur("duration", time.Since(start)).
+		Msg("successfully saved google fit data sources")
+
+	return nil
+}
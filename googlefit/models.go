@@ -1,6 +1,7 @@
 package googlefit
 
 import (
+	"DataLake/internal/timezone"
 	"strconv"
 	"time"
 )
@@ -45,13 +46,57 @@ type Dataset struct {
 	Point        []Point `json:"point"`
 }
 
-// Bucket represents a time bucket containing multiple datasets
+// Bucket represents a time bucket containing multiple datasets.
+// Session is set only for buckets produced by bucketBySession.
 type Bucket struct {
 	StartTimeMillis string    `json:"startTimeMillis"`
 	EndTimeMillis   string    `json:"endTimeMillis"`
+	Session         *Session  `json:"session,omitempty"`
 	Dataset         []Dataset `json:"dataset"`
 }
 
+// Application identifies the app that recorded a session
+type Application struct {
+	PackageName string `json:"packageName,omitempty"`
+	Name        string `json:"name,omitempty"`
+}
+
+// Session represents a Google Fit session (workout)
+type Session struct {
+	ID              string      `json:"id"`
+	Name            string      `json:"name"`
+	Description     string      `json:"description"`
+	StartTimeMillis string      `json:"startTimeMillis"`
+	EndTimeMillis   string      `json:"endTimeMillis"`
+	ActivityType    int         `json:"activityType"`
+	Application     Application `json:"application"`
+}
+
+// StartTime returns the start time as time.Time
+func (s *Session) StartTime() (time.Time, error) {
+	millis, err := strconv.ParseInt(s.StartTimeMillis, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// EndTime returns the end time as time.Time
+func (s *Session) EndTime() (time.Time, error) {
+	millis, err := strconv.ParseInt(s.EndTimeMillis, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// SessionsResponse represents the response from the sessions list endpoint
+type SessionsResponse struct {
+	Session        []Session `json:"session"`
+	DeletedSession []Session `json:"deletedSession"`
+	NextPageToken  string    `json:"nextPageToken"`
+}
+
 // StartTime returns the start time as time.Time
 func (b *Bucket) StartTime() (time.Time, error) {
 	millis, err := strconv.ParseInt(b.StartTimeMillis, 10, 64)
@@ -80,44 +125,369 @@ type DailyStats struct {
 	Date     time.Time
 	Steps    int
 	Distance float64
+
+	// Heart rate summary, valid only when HasHeartRate is true
+	HasHeartRate bool
+	MinBpm       float64
+	AvgBpm       float64
+	MaxBpm       float64
+
+	Calories    float64
+	MoveMinutes int
+	HeartPoints float64
+}
+
+// HourlyStats represents aggregated statistics for a single hour
+type HourlyStats struct {
+	HourStart   time.Time
+	Steps       int
+	Distance    float64
+	Calories    float64
+	MoveMinutes int
+}
+
+// Device describes the device that recorded a data source
+type Device struct {
+	UID          string `json:"uid,omitempty"`
+	Type         string `json:"type,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+}
+
+// DataType describes the data type of a data source
+type DataType struct {
+	Name string `json:"name"`
 }
 
-// ExtractDailyStats extracts daily statistics from the aggregated response
-func (r *AggregatedDataResponse) ExtractDailyStats() ([]DailyStats, error) {
+// DataSource represents a Google Fit data source (a device or app stream)
+type DataSource struct {
+	DataStreamID   string      `json:"dataStreamId"`
+	DataStreamName string      `json:"dataStreamName,omitempty"`
+	Type           string      `json:"type"`
+	DataType       DataType    `json:"dataType"`
+	Device         *Device     `json:"device,omitempty"`
+	Application    Application `json:"application"`
+}
+
+// DataSourcesResponse represents the response from the dataSources list endpoint
+type DataSourcesResponse struct {
+	DataSource []DataSource `json:"dataSource"`
+}
+
+// SourceDailyStat is the daily total of a single data type reported by one data source
+type SourceDailyStat struct {
+	DataSourceID string
+	DataType     string
+	Date         time.Time
+	Value        float64
+}
+
+// ExtractSourceDailyStats sums points per origin data source and calendar day in loc.
+// Points without originDataSourceId are attributed to the dataset's own source.
+func (d *Dataset) ExtractSourceDailyStats(dataType string, loc *time.Location) ([]SourceDailyStat, error) {
+	type key struct {
+		source string
+		date   time.Time
+	}
+	totals := make(map[key]float64)
+	var order []key
+
+	for _, point := range d.Point {
+		if len(point.Value) == 0 {
+			continue
+		}
+
+		startTime, err := point.StartTime()
+		if err != nil {
+			return nil, err
+		}
+
+		source := point.OriginDataSourceId
+		if source == "" {
+			source = d.DataSourceId
+		}
+
+		k := key{source: source, date: timezone.Date(startTime, loc)}
+		if _, ok := totals[k]; !ok {
+			order = append(order, k)
+		}
+		// Integer types (steps) use intVal, float types (distance) use fpVal
+		totals[k] += float64(point.Value[0].IntVal) + point.Value[0].FpVal
+	}
+
+	stats := make([]SourceDailyStat, 0, len(order))
+	for _, k := range order {
+		stats = append(stats, SourceDailyStat{
+			DataSourceID: k.source,
+			DataType:     dataType,
+			Date:         k.date,
+			Value:        totals[k],
+		})
+	}
+
+	return stats, nil
+}
+
+// Workout represents a session together with its aggregated statistics
+type Workout struct {
+	SessionID    string
+	Name         string
+	Description  string
+	ActivityType int
+	Application  string
+	StartTime    time.Time
+	EndTime      time.Time
+	Stats        DailyStats
+}
+
+// SleepSegment represents a single sleep stage interval
+type SleepSegment struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Stage     int
+}
+
+// ExtractDailyStats extracts daily statistics from the aggregated response.
+// Each bucket is stamped with its calendar date in loc.
+func (r *AggregatedDataResponse) ExtractDailyStats(loc *time.Location) ([]DailyStats, error) {
 	var stats []DailyStats
 
 	for _, bucket := range r.Bucket {
-		stat := DailyStats{}
+		startTime, err := bucket.StartTime()
+		if err != nil {
+			return nil, err
+		}
+
+		stat := bucket.stats()
+		stat.Date = timezone.Date(startTime, loc)
+		stats = append(stats, stat)
+	}
+
+	return stats, nil
+}
+
+// ExtractHourlyStats extracts hourly statistics from an hourly bucketed response
+func (r *AggregatedDataResponse) ExtractHourlyStats() ([]HourlyStats, error) {
+	stats := make([]HourlyStats, 0, len(r.Bucket))
 
+	for _, bucket := range r.Bucket {
 		startTime, err := bucket.StartTime()
 		if err != nil {
 			return nil, err
 		}
-		stat.Date = startTime
 
-		for _, dataset := range bucket.Dataset {
-			for _, point := range dataset.Point {
-				if len(point.Value) == 0 {
-					continue
-				}
+		stat := bucket.stats()
+		stats = append(stats, HourlyStats{
+			HourStart:   startTime,
+			Steps:       stat.Steps,
+			Distance:    stat.Distance,
+			Calories:    stat.Calories,
+			MoveMinutes: stat.MoveMinutes,
+		})
+	}
+
+	return stats, nil
+}
+
+// ExtractSessionStats extracts per-session statistics from a bucketBySession response, keyed by session ID
+func (r *AggregatedDataResponse) ExtractSessionStats() map[string]DailyStats {
+	stats := make(map[string]DailyStats, len(r.Bucket))
+
+	for _, bucket := range r.Bucket {
+		if bucket.Session == nil {
+			continue
+		}
+		stats[bucket.Session.ID] = bucket.stats()
+	}
 
-				switch dataset.DataSourceId {
-				case "derived:com.google.step_count.delta:com.google.android.gms:aggregated":
-					stat.Steps = point.Value[0].IntVal
-				case "derived:com.google.distance.delta:com.google.android.gms:aggregated":
-					stat.Distance = point.Value[0].FpVal
+	return stats
+}
+
+// stats sums up all datasets of the bucket
+func (b *Bucket) stats() DailyStats {
+	stat := DailyStats{}
+
+	for _, dataset := range b.Dataset {
+		for _, point := range dataset.Point {
+			if len(point.Value) == 0 {
+				continue
+			}
+
+			switch point.DataTypeName {
+			case DataTypeStepCount:
+				stat.Steps += point.Value[0].IntVal
+			case DataTypeDistance:
+				stat.Distance += point.Value[0].FpVal
+			}
+
+			switch point.DataTypeName {
+			case DataTypeHeartRateSummary:
+				// Summary values: average, max, min
+				if len(point.Value) >= 3 {
+					stat.HasHeartRate = true
+					stat.AvgBpm = point.Value[0].FpVal
+					stat.MaxBpm = point.Value[1].FpVal
+					stat.MinBpm = point.Value[2].FpVal
 				}
+			case DataTypeCalories:
+				stat.Calories += point.Value[0].FpVal
+			case DataTypeMoveMinutes:
+				stat.MoveMinutes += point.Value[0].IntVal
+			case DataTypeHeartPointsSummary:
+				// Summary values: intensity (heart points), duration
+				stat.HeartPoints += point.Value[0].FpVal
 			}
 		}
+	}
 
-		stats = append(stats, stat)
+	return stat
+}
+
+// ExtractSleepSegments converts sleep segment points into SleepSegment values
+func (d *Dataset) ExtractSleepSegments() ([]SleepSegment, error) {
+	segments := make([]SleepSegment, 0, len(d.Point))
+
+	for _, point := range d.Point {
+		if len(point.Value) == 0 {
+			continue
+		}
+
+		startTime, err := point.StartTime()
+		if err != nil {
+			return nil, err
+		}
+		endTime, err := point.EndTime()
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, SleepSegment{
+			StartTime: startTime,
+			EndTime:   endTime,
+			Stage:     point.Value[0].IntVal,
+		})
 	}
 
-	return stats, nil
+	return segments, nil
+}
+
+// BodyMeasurement represents a single point-in-time body measurement
+type BodyMeasurement struct {
+	Metric     string
+	MeasuredAt time.Time
+	Value      float64
+}
+
+// ExtractBodyMeasurements converts raw weight, body fat or height points into BodyMeasurement values
+func (d *Dataset) ExtractBodyMeasurements(metric string) ([]BodyMeasurement, error) {
+	measurements := make([]BodyMeasurement, 0, len(d.Point))
+
+	for _, point := range d.Point {
+		if len(point.Value) == 0 {
+			continue
+		}
+
+		measuredAt, err := point.EndTime()
+		if err != nil {
+			return nil, err
+		}
+
+		measurements = append(measurements, BodyMeasurement{
+			Metric:     metric,
+			MeasuredAt: measuredAt,
+			Value:      point.Value[0].FpVal,
+		})
+	}
+
+	return measurements, nil
 }
 
 // DataType constants for Google Fit
 const (
-	DataTypeStepCount = "com.google.step_count.delta"
-	DataTypeDistance  = "com.google.distance.delta"
+	DataTypeStepCount    = "com.google.step_count.delta"
+	DataTypeDistance     = "com.google.distance.delta"
+	DataTypeHeartRate    = "com.google.heart_rate.bpm"
+	DataTypeCalories     = "com.google.calories.expended"
+	DataTypeMoveMinutes  = "com.google.active_minutes"
+	DataTypeHeartPoints  = "com.google.heart_minutes"
+	DataTypeSleepSegment = "com.google.sleep.segment"
+	DataTypeWeight       = "com.google.weight"
+	DataTypeBodyFat      = "com.google.body.fat.percentage"
+	DataTypeHeight       = "com.google.height"
+
+	// Aggregated data types returned by dataset:aggregate
+	DataTypeHeartRateSummary   = "com.google.heart_rate.summary"
+	DataTypeHeartPointsSummary = "com.google.heart_minutes.summary"
+)
+
+// SleepSegmentDataSource is the merged sleep data source maintained by Google Fit
+const SleepSegmentDataSource = "derived:com.google.sleep.segment:com.google.android.gms:merged"
+
+// SourceDataTypes are the data types for which per-source provenance is collected
+// and a preferred source can be chosen
+var SourceDataTypes = []string{DataTypeStepCount, DataTypeDistance}
+
+// Data source types returned by the dataSources endpoint
+const (
+	DataSourceTypeRaw     = "raw"
+	DataSourceTypeDerived = "derived"
 )
+
+// Body metric names stored in googlefit_body_measurements
+const (
+	BodyMetricWeight  = "weight"   // kg
+	BodyMetricBodyFat = "body_fat" // percent
+	BodyMetricHeight  = "height"   // meters
+)
+
+// BodyDataSources maps body metrics to the merged data sources maintained by Google Fit
+var BodyDataSources = map[string]string{
+	BodyMetricWeight:  "derived:" + DataTypeWeight + ":com.google.android.gms:merge_weight",
+	BodyMetricBodyFat: "derived:" + DataTypeBodyFat + ":com.google.android.gms:merged",
+	BodyMetricHeight:  "derived:" + DataTypeHeight + ":com.google.android.gms:merge_height",
+}
+
+// Sleep stage values for com.google.sleep.segment
+const (
+	SleepStageAwake    = 1
+	SleepStageSleep    = 2
+	SleepStageOutOfBed = 3
+	SleepStageLight    = 4
+	SleepStageDeep     = 5
+	SleepStageREM      = 6
+)
+
+// Activity type values for sessions, see
+// https://developers.google.com/fit/rest/v1/reference/activity-types
+const (
+	ActivityTypeBiking           = 1
+	ActivityTypeWalking          = 7
+	ActivityTypeRunning          = 8
+	ActivityTypeSleep            = 72
+	ActivityTypeStrengthTraining = 80
+)
+
+var activityTypeNames = map[int]string{
+	ActivityTypeBiking:           "biking",
+	ActivityTypeWalking:          "walking",
+	ActivityTypeRunning:          "running",
+	ActivityTypeSleep:            "sleep",
+	ActivityTypeStrengthTraining: "strength_training",
+	9:                            "aerobics",
+	24:                           "elliptical",
+	35:                           "hiking",
+	82:                           "swimming",
+	97:                           "weightlifting",
+	100:                          "yoga",
+	108:                          "other",
+	113:                          "crossfit",
+	114:                          "hiit",
+}
+
+// ActivityTypeName returns a readable name for a Google Fit activity type
+func ActivityTypeName(activityType int) string {
+	if name, ok := activityTypeNames[activityType]; ok {
+		return name
+	}
+	return "activity_" + strconv.Itoa(activityType)
+}
@@ -11,46 +11,402 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type BulkInsertEventsParams struct {
+const addPrivateApp = `-- name: AddPrivateApp :exec
+INSERT INTO activity_private_apps (user_id, app)
+VALUES ($1, $2)
+ON CONFLICT (user_id, app) DO NOTHING
+`
+
+type AddPrivateAppParams struct {
+	UserID pgtype.UUID
+	App    string
+}
+
+func (q *Queries) AddPrivateApp(ctx context.Context, arg AddPrivateAppParams) error {
+	_, err := q.db.Exec(ctx, addPrivateApp, arg.UserID, arg.App)
+	return err
+}
+
+const countEventsByApp = `-- name: CountEventsByApp :one
+SELECT COUNT(*) FROM activity_events e
+LEFT JOIN activity_devices d ON d.id = e.device_id
+WHERE e.user_id = $1 AND e.app = $2
+  AND e.timestamp >= $3 AND e.timestamp < $4
+  AND (cardinality($5::text[]) = 0 OR d.hostname = ANY($5::text[]))
+`
+
+type CountEventsByAppParams struct {
+	UserID    pgtype.UUID
+	App       string
+	StartTime pgtype.Timestamptz
+	EndTime   pgtype.Timestamptz
+	Hostnames []string
+}
+
+func (q *Queries) CountEventsByApp(ctx context.Context, arg CountEventsByAppParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countEventsByApp,
+		arg.UserID,
+		arg.App,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Hostnames,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createCategoryRule = `-- name: CreateCategoryRule :one
+INSERT INTO activity_category_rules (
+    user_id, category, field, pattern, ignore_case, color
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING id, user_id, category, field, pattern, ignore_case, color, created_at, updated_at
+`
+
+type CreateCategoryRuleParams struct {
+	UserID     pgtype.UUID
+	Category   []string
+	Field      string
+	Pattern    string
+	IgnoreCase bool
+	Color      pgtype.Text
+}
+
+func (q *Queries) CreateCategoryRule(ctx context.Context, arg CreateCategoryRuleParams) (ActivityCategoryRule, error) {
+	row := q.db.QueryRow(ctx, createCategoryRule,
+		arg.UserID,
+		arg.Category,
+		arg.Field,
+		arg.Pattern,
+		arg.IgnoreCase,
+		arg.Color,
+	)
+	var i ActivityCategoryRule
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Category,
+		&i.Field,
+		&i.Pattern,
+		&i.IgnoreCase,
+		&i.Color,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createRedactionRule = `-- name: CreateRedactionRule :one
+INSERT INTO activity_redaction_rules (
+    user_id, app, field, pattern, action, replacement
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING id, user_id, app, field, pattern, action, replacement, created_at, updated_at
+`
+
+type CreateRedactionRuleParams struct {
+	UserID      pgtype.UUID
+	App         string
+	Field       string
+	Pattern     string
+	Action      string
+	Replacement string
+}
+
+func (q *Queries) CreateRedactionRule(ctx context.Context, arg CreateRedactionRuleParams) (ActivityRedactionRule, error) {
+	row := q.db.QueryRow(ctx, createRedactionRule,
+		arg.UserID,
+		arg.App,
+		arg.Field,
+		arg.Pattern,
+		arg.Action,
+		arg.Replacement,
+	)
+	var i ActivityRedactionRule
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.App,
+		&i.Field,
+		&i.Pattern,
+		&i.Action,
+		&i.Replacement,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteAllCategoryRules = `-- name: DeleteAllCategoryRules :exec
+DELETE FROM activity_category_rules
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteAllCategoryRules(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteAllCategoryRules, userID)
+	return err
+}
+
+const deleteAllPrivateApps = `-- name: DeleteAllPrivateApps :exec
+DELETE FROM activity_private_apps
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteAllPrivateApps(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteAllPrivateApps, userID)
+	return err
+}
+
+const deleteCategoryRule = `-- name: DeleteCategoryRule :execrows
+DELETE FROM activity_category_rules
+WHERE user_id = $1 AND id = $2
+`
+
+type DeleteCategoryRuleParams struct {
+	UserID pgtype.UUID
+	ID     int32
+}
+
+func (q *Queries) DeleteCategoryRule(ctx context.Context, arg DeleteCategoryRuleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCategoryRule, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRedactionRule = `-- name: DeleteRedactionRule :execrows
+DELETE FROM activity_redaction_rules
+WHERE user_id = $1 AND id = $2
+`
+
+type DeleteRedactionRuleParams struct {
+	UserID pgtype.UUID
+	ID     int32
+}
+
+func (q *Queries) DeleteRedactionRule(ctx context.Context, arg DeleteRedactionRuleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRedactionRule, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getEventsByApp = `-- name: GetEventsByApp :many
+SELECT
+    e.id,
+    e.timestamp,
+    e.duration,
+    e.title,
+    e.url,
+    e.bucket_id,
+    d.hostname
+FROM activity_events e
+LEFT JOIN activity_devices d ON d.id = e.device_id
+WHERE e.user_id = $1 AND e.app = $2
+  AND e.timestamp >= $3 AND e.timestamp < $4
+  AND (cardinality($5::text[]) = 0 OR d.hostname = ANY($5::text[]))
+ORDER BY e.timestamp DESC, e.id DESC
+LIMIT $6 OFFSET $7
+`
+
+type GetEventsByAppParams struct {
+	UserID    pgtype.UUID
+	App       string
+	StartTime pgtype.Timestamptz
+	EndTime   pgtype.Timestamptz
+	Hostnames []string
+	RowLimit  pgtype.Int4
+	RowOffset int32
+}
+
+type GetEventsByAppRow struct {
+	ID        int64
 	Timestamp pgtype.Timestamptz
 	Duration  float64
-	App       string
 	Title     pgtype.Text
+	Url       pgtype.Text
 	BucketID  string
+	Hostname  pgtype.Text
+}
+
+func (q *Queries) GetEventsByApp(ctx context.Context, arg GetEventsByAppParams) ([]GetEventsByAppRow, error) {
+	rows, err := q.db.Query(ctx, getEventsByApp,
+		arg.UserID,
+		arg.App,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Hostnames,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEventsByAppRow
+	for rows.Next() {
+		var i GetEventsByAppRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Timestamp,
+			&i.Duration,
+			&i.Title,
+			&i.Url,
+			&i.BucketID,
+			&i.Hostname,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategoryRules = `-- name: ListCategoryRules :many
+SELECT id, user_id, category, field, pattern, ignore_case, color, created_at, updated_at FROM activity_category_rules
+WHERE user_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListCategoryRules(ctx context.Context, userID pgtype.UUID) ([]ActivityCategoryRule, error) {
+	rows, err := q.db.Query(ctx, listCategoryRules, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ActivityCategoryRule
+	for rows.Next() {
+		var i ActivityCategoryRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Category,
+			&i.Field,
+			&i.Pattern,
+			&i.IgnoreCase,
+			&i.Color,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDevices = `-- name: ListDevices :many
+SELECT id, user_id, hostname, first_seen, last_seen, created_at FROM activity_devices
+WHERE user_id = $1
+ORDER BY last_seen DESC
+`
+
+func (q *Queries) ListDevices(ctx context.Context, userID pgtype.UUID) ([]ActivityDevice, error) {
+	rows, err := q.db.Query(ctx, listDevices, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ActivityDevice
+	for rows.Next() {
+		var i ActivityDevice
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Hostname,
+			&i.FirstSeen,
+			&i.LastSeen,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getAppStats = `-- name: GetAppStats :many
+const listEventSpans = `-- name: ListEventSpans :many
+
 SELECT
-    app,
-    SUM(duration)::float as total_duration,
-    COUNT(*) as event_count
-FROM activity_events
-WHERE timestamp >= $1 AND timestamp < $2
-GROUP BY app
-ORDER BY total_duration DESC
+    e.device_id,
+    d.hostname,
+    e.app,
+    e.title,
+    e.timestamp,
+    e.duration,
+    e.bucket_id,
+    e.url,
+    e.domain
+FROM activity_events e
+LEFT JOIN activity_devices d ON d.id = e.device_id
+WHERE e.user_id = $1
+  AND e.timestamp >= $2::timestamptz - interval '1 day'
+  AND e.timestamp < $3
+  AND e.timestamp + make_interval(secs => e.duration) > $2
+  AND (cardinality($4::text[]) = 0 OR d.hostname = ANY($4::text[]))
+ORDER BY e.timestamp
 `
 
-type GetAppStatsParams struct {
-	Timestamp   pgtype.Timestamptz
-	Timestamp_2 pgtype.Timestamptz
+type ListEventSpansParams struct {
+	UserID    pgtype.UUID
+	StartTime pgtype.Timestamptz
+	EndTime   pgtype.Timestamptz
+	Hostnames []string
 }
 
-type GetAppStatsRow struct {
-	App           string
-	TotalDuration float64
-	EventCount    int64
+type ListEventSpansRow struct {
+	DeviceID  pgtype.Int8
+	Hostname  pgtype.Text
+	App       string
+	Title     pgtype.Text
+	Timestamp pgtype.Timestamptz
+	Duration  float64
+	BucketID  string
+	Url       pgtype.Text
+	Domain    pgtype.Text
 }
 
-func (q *Queries) GetAppStats(ctx context.Context, arg GetAppStatsParams) ([]GetAppStatsRow, error) {
-	rows, err := q.db.Query(ctx, getAppStats, arg.Timestamp, arg.Timestamp_2)
+// События, пересекающие [start_time, end_time), с hostname устройства.
+// Периоды not-afk длятся часами, поэтому начало события ищется с запасом в сутки
+func (q *Queries) ListEventSpans(ctx context.Context, arg ListEventSpansParams) ([]ListEventSpansRow, error) {
+	rows, err := q.db.Query(ctx, listEventSpans,
+		arg.UserID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Hostnames,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAppStatsRow
+	var items []ListEventSpansRow
 	for rows.Next() {
-		var i GetAppStatsRow
-		if err := rows.Scan(&i.App, &i.TotalDuration, &i.EventCount); err != nil {
+		var i ListEventSpansRow
+		if err := rows.Scan(
+			&i.DeviceID,
+			&i.Hostname,
+			&i.App,
+			&i.Title,
+			&i.Timestamp,
+			&i.Duration,
+			&i.BucketID,
+			&i.Url,
+			&i.Domain,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -61,35 +417,42 @@ func (q *Queries) GetAppStats(ctx context.Context, arg GetAppStatsParams) ([]Get
 	return items, nil
 }
 
-const getEventsByApp = `-- name: GetEventsByApp :many
-SELECT id, timestamp, duration, app, title, bucket_id, created_at FROM activity_events
-WHERE app = $1 AND timestamp >= $2 AND timestamp < $3
-ORDER BY timestamp DESC
+const listEventsForRedaction = `-- name: ListEventsForRedaction :many
+SELECT id, app, title, url, domain FROM activity_events
+WHERE user_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
 `
 
-type GetEventsByAppParams struct {
-	App         string
-	Timestamp   pgtype.Timestamptz
-	Timestamp_2 pgtype.Timestamptz
+type ListEventsForRedactionParams struct {
+	UserID   pgtype.UUID
+	AfterID  int64
+	RowLimit int32
 }
 
-func (q *Queries) GetEventsByApp(ctx context.Context, arg GetEventsByAppParams) ([]ActivityEvent, error) {
-	rows, err := q.db.Query(ctx, getEventsByApp, arg.App, arg.Timestamp, arg.Timestamp_2)
+type ListEventsForRedactionRow struct {
+	ID     int64
+	App    string
+	Title  pgtype.Text
+	Url    pgtype.Text
+	Domain pgtype.Text
+}
+
+func (q *Queries) ListEventsForRedaction(ctx context.Context, arg ListEventsForRedactionParams) ([]ListEventsForRedactionRow, error) {
+	rows, err := q.db.Query(ctx, listEventsForRedaction, arg.UserID, arg.AfterID, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ActivityEvent
+	var items []ListEventsForRedactionRow
 	for rows.Next() {
-		var i ActivityEvent
+		var i ListEventsForRedactionRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Timestamp,
-			&i.Duration,
 			&i.App,
 			&i.Title,
-			&i.BucketID,
-			&i.CreatedAt,
+			&i.Url,
+			&i.Domain,
 		); err != nil {
 			return nil, err
 		}
@@ -101,35 +464,57 @@ func (q *Queries) GetEventsByApp(ctx context.Context, arg GetEventsByAppParams)
 	return items, nil
 }
 
-const getRecentEvents = `-- name: GetRecentEvents :many
-SELECT id, timestamp, duration, app, title, bucket_id, created_at FROM activity_events
-WHERE timestamp >= $1
-ORDER BY timestamp DESC
-LIMIT $2
+const listPrivateApps = `-- name: ListPrivateApps :many
+SELECT app FROM activity_private_apps
+WHERE user_id = $1
+ORDER BY app
 `
 
-type GetRecentEventsParams struct {
-	Timestamp pgtype.Timestamptz
-	Limit     int32
+func (q *Queries) ListPrivateApps(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, listPrivateApps, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var app string
+		if err := rows.Scan(&app); err != nil {
+			return nil, err
+		}
+		items = append(items, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetRecentEvents(ctx context.Context, arg GetRecentEventsParams) ([]ActivityEvent, error) {
-	rows, err := q.db.Query(ctx, getRecentEvents, arg.Timestamp, arg.Limit)
+const listRedactionRules = `-- name: ListRedactionRules :many
+SELECT id, user_id, app, field, pattern, action, replacement, created_at, updated_at FROM activity_redaction_rules
+WHERE user_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListRedactionRules(ctx context.Context, userID pgtype.UUID) ([]ActivityRedactionRule, error) {
+	rows, err := q.db.Query(ctx, listRedactionRules, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ActivityEvent
+	var items []ActivityRedactionRule
 	for rows.Next() {
-		var i ActivityEvent
+		var i ActivityRedactionRule
 		if err := rows.Scan(
 			&i.ID,
-			&i.Timestamp,
-			&i.Duration,
+			&i.UserID,
 			&i.App,
-			&i.Title,
-			&i.BucketID,
+			&i.Field,
+			&i.Pattern,
+			&i.Action,
+			&i.Replacement,
 			&i.CreatedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -140,3 +525,160 @@ func (q *Queries) GetRecentEvents(ctx context.Context, arg GetRecentEventsParams
 	}
 	return items, nil
 }
+
+const redactEvent = `-- name: RedactEvent :exec
+UPDATE activity_events SET
+    title = $1,
+    url = $2,
+    domain = $3,
+    updated_at = now()
+WHERE user_id = $4 AND id = $5
+`
+
+type RedactEventParams struct {
+	Title  pgtype.Text
+	Url    pgtype.Text
+	Domain pgtype.Text
+	UserID pgtype.UUID
+	ID     int64
+}
+
+func (q *Queries) RedactEvent(ctx context.Context, arg RedactEventParams) error {
+	_, err := q.db.Exec(ctx, redactEvent,
+		arg.Title,
+		arg.Url,
+		arg.Domain,
+		arg.UserID,
+		arg.ID,
+	)
+	return err
+}
+
+const updateCategoryRule = `-- name: UpdateCategoryRule :one
+UPDATE activity_category_rules SET
+    category = $1,
+    field = $2,
+    pattern = $3,
+    ignore_case = $4,
+    color = $5,
+    updated_at = now()
+WHERE user_id = $6 AND id = $7
+RETURNING id, user_id, category, field, pattern, ignore_case, color, created_at, updated_at
+`
+
+type UpdateCategoryRuleParams struct {
+	Category   []string
+	Field      string
+	Pattern    string
+	IgnoreCase bool
+	Color      pgtype.Text
+	UserID     pgtype.UUID
+	ID         int32
+}
+
+func (q *Queries) UpdateCategoryRule(ctx context.Context, arg UpdateCategoryRuleParams) (ActivityCategoryRule, error) {
+	row := q.db.QueryRow(ctx, updateCategoryRule,
+		arg.Category,
+		arg.Field,
+		arg.Pattern,
+		arg.IgnoreCase,
+		arg.Color,
+		arg.UserID,
+		arg.ID,
+	)
+	var i ActivityCategoryRule
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Category,
+		&i.Field,
+		&i.Pattern,
+		&i.IgnoreCase,
+		&i.Color,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateRedactionRule = `-- name: UpdateRedactionRule :one
+UPDATE activity_redaction_rules SET
+    app = $1,
+    field = $2,
+    pattern = $3,
+    action = $4,
+    replacement = $5,
+    updated_at = now()
+WHERE user_id = $6 AND id = $7
+RETURNING id, user_id, app, field, pattern, action, replacement, created_at, updated_at
+`
+
+type UpdateRedactionRuleParams struct {
+	App         string
+	Field       string
+	Pattern     string
+	Action      string
+	Replacement string
+	UserID      pgtype.UUID
+	ID          int32
+}
+
+func (q *Queries) UpdateRedactionRule(ctx context.Context, arg UpdateRedactionRuleParams) (ActivityRedactionRule, error) {
+	row := q.db.QueryRow(ctx, updateRedactionRule,
+		arg.App,
+		arg.Field,
+		arg.Pattern,
+		arg.Action,
+		arg.Replacement,
+		arg.UserID,
+		arg.ID,
+	)
+	var i ActivityRedactionRule
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.App,
+		&i.Field,
+		&i.Pattern,
+		&i.Action,
+		&i.Replacement,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertDevice = `-- name: UpsertDevice :one
+INSERT INTO activity_devices (
+    user_id,
+    hostname,
+    first_seen,
+    last_seen
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (user_id, hostname)
+DO UPDATE SET
+    first_seen = LEAST(activity_devices.first_seen, EXCLUDED.first_seen),
+    last_seen = GREATEST(activity_devices.last_seen, EXCLUDED.last_seen)
+RETURNING id
+`
+
+type UpsertDeviceParams struct {
+	UserID    pgtype.UUID
+	Hostname  string
+	FirstSeen pgtype.Timestamptz
+	LastSeen  pgtype.Timestamptz
+}
+
+func (q *Queries) UpsertDevice(ctx context.Context, arg UpsertDeviceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, upsertDevice,
+		arg.UserID,
+		arg.Hostname,
+		arg.FirstSeen,
+		arg.LastSeen,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
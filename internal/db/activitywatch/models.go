@@ -8,6 +8,27 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ActivityCategoryRule struct {
+	ID         int32
+	UserID     pgtype.UUID
+	Category   []string
+	Field      string
+	Pattern    string
+	IgnoreCase bool
+	Color      pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type ActivityDevice struct {
+	ID        int64
+	UserID    pgtype.UUID
+	Hostname  string
+	FirstSeen pgtype.Timestamptz
+	LastSeen  pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
 type ActivityEvent struct {
 	ID        int64
 	Timestamp pgtype.Timestamptz
@@ -16,4 +37,30 @@ type ActivityEvent struct {
 	Title     pgtype.Text
 	BucketID  string
 	CreatedAt pgtype.Timestamptz
+	AwEventID pgtype.Int8
+	UpdatedAt pgtype.Timestamptz
+	UserID    pgtype.UUID
+	DeviceID  pgtype.Int8
+	Url       pgtype.Text
+	Domain    pgtype.Text
+	Audible   bool
+	Incognito bool
+}
+
+type ActivityPrivateApp struct {
+	UserID    pgtype.UUID
+	App       string
+	CreatedAt pgtype.Timestamptz
+}
+
+type ActivityRedactionRule struct {
+	ID          int32
+	UserID      pgtype.UUID
+	App         string
+	Field       string
+	Pattern     string
+	Action      string
+	Replacement string
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
 }
@@ -11,6 +11,99 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelEvent = `-- name: CancelEvent :execrows
+UPDATE googlecalendar_events
+SET status = 'cancelled', updated_at = now()
+WHERE user_id = $1 AND event_id = $2
+`
+
+type CancelEventParams struct {
+	UserID  pgtype.UUID
+	EventID string
+}
+
+func (q *Queries) CancelEvent(ctx context.Context, arg CancelEventParams) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelEvent, arg.UserID, arg.EventID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const cancelEventsByPrefix = `-- name: CancelEventsByPrefix :execrows
+
+UPDATE googlecalendar_events
+SET status = 'cancelled', updated_at = now()
+WHERE user_id = $1
+  AND calendar_id = $2
+  AND starts_with(event_id, $3::text)
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND event_id <> ALL($4::text[])
+`
+
+type CancelEventsByPrefixParams struct {
+	UserID     pgtype.UUID
+	CalendarID string
+	Prefix     string
+	EventIds   []string
+}
+
+// Отменяет экземпляры одного ресурса CalDAV (event_id с префиксом prefix), кроме перечисленных
+func (q *Queries) CancelEventsByPrefix(ctx context.Context, arg CancelEventsByPrefixParams) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelEventsByPrefix,
+		arg.UserID,
+		arg.CalendarID,
+		arg.Prefix,
+		arg.EventIds,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const cancelMissingEvents = `-- name: CancelMissingEvents :execrows
+
+UPDATE googlecalendar_events
+SET status = 'cancelled', updated_at = now()
+WHERE user_id = $1
+  AND calendar_id = $2
+  AND end_time > $3
+  AND start_time < $4
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND event_id <> ALL($5::text[])
+  AND NOT EXISTS (
+      SELECT 1 FROM unnest($6::text[]) AS p(prefix)
+      WHERE starts_with(event_id, p.prefix)
+  )
+`
+
+type CancelMissingEventsParams struct {
+	UserID       pgtype.UUID
+	CalendarID   string
+	StartTime    pgtype.Timestamptz
+	EndTime      pgtype.Timestamptz
+	EventIds     []string
+	KeepPrefixes []string
+}
+
+// Фиды iCalendar приходят полным снимком: события окна, которых нет в снимке, отменены.
+// События с префиксами из keep_prefixes не трогаются (ресурсы CalDAV, которые не удалось загрузить)
+func (q *Queries) CancelMissingEvents(ctx context.Context, arg CancelMissingEventsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelMissingEvents,
+		arg.UserID,
+		arg.CalendarID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.EventIds,
+		arg.KeepPrefixes,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createEvent = `-- name: CreateEvent :one
 
 INSERT INTO googlecalendar_events (
@@ -18,7 +111,7 @@ INSERT INTO googlecalendar_events (
     start_time, end_time, is_all_day, duration, status
 )
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-RETURNING id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at
+RETURNING id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at, organizer_email, organizer_name, recurring_event_id, recurrence, transparency, event_type, response_status, attendees_count, ical_uid, source_href
 `
 
 type CreateEventParams struct {
@@ -66,6 +159,59 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Googl
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizerEmail,
+		&i.OrganizerName,
+		&i.RecurringEventID,
+		&i.Recurrence,
+		&i.Transparency,
+		&i.EventType,
+		&i.ResponseStatus,
+		&i.AttendeesCount,
+		&i.IcalUid,
+		&i.SourceHref,
+	)
+	return i, err
+}
+
+const createFeedToken = `-- name: CreateFeedToken :one
+
+INSERT INTO calendar_feed_tokens (
+    user_id, name, token_hash, include_events, include_wakatime, include_activitywatch
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, token_hash, include_events, include_wakatime, include_activitywatch, created_at, last_used_at
+`
+
+type CreateFeedTokenParams struct {
+	UserID               pgtype.UUID
+	Name                 string
+	TokenHash            string
+	IncludeEvents        bool
+	IncludeWakatime      bool
+	IncludeActivitywatch bool
+}
+
+// Feed Tokens Queries -------------------------------------------------------------------
+func (q *Queries) CreateFeedToken(ctx context.Context, arg CreateFeedTokenParams) (CalendarFeedToken, error) {
+	row := q.db.QueryRow(ctx, createFeedToken,
+		arg.UserID,
+		arg.Name,
+		arg.TokenHash,
+		arg.IncludeEvents,
+		arg.IncludeWakatime,
+		arg.IncludeActivitywatch,
+	)
+	var i CalendarFeedToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.IncludeEvents,
+		&i.IncludeWakatime,
+		&i.IncludeActivitywatch,
+		&i.CreatedAt,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
@@ -79,10 +225,45 @@ func (q *Queries) DeleteEvent(ctx context.Context, id int32) error {
 	return err
 }
 
+const deleteEventAttendees = `-- name: DeleteEventAttendees :exec
+
+DELETE FROM googlecalendar_event_attendees
+WHERE user_id = $1 AND event_id = $2
+`
+
+type DeleteEventAttendeesParams struct {
+	UserID  pgtype.UUID
+	EventID string
+}
+
+// Attendees Queries -------------------------------------------------------------------
+func (q *Queries) DeleteEventAttendees(ctx context.Context, arg DeleteEventAttendeesParams) error {
+	_, err := q.db.Exec(ctx, deleteEventAttendees, arg.UserID, arg.EventID)
+	return err
+}
+
+const deleteFeedToken = `-- name: DeleteFeedToken :execrows
+DELETE FROM calendar_feed_tokens
+WHERE user_id = $1 AND id = $2
+`
+
+type DeleteFeedTokenParams struct {
+	UserID pgtype.UUID
+	ID     int32
+}
+
+func (q *Queries) DeleteFeedToken(ctx context.Context, arg DeleteFeedTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFeedToken, arg.UserID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getAverageDailyEvents = `-- name: GetAverageDailyEvents :one
 SELECT
-    AVG(event_count)::FLOAT as avg_events_per_day,
-    AVG(total_duration)::FLOAT as avg_duration_per_day
+    COALESCE(AVG(event_count), 0)::FLOAT as avg_events_per_day,
+    COALESCE(AVG(total_duration), 0)::FLOAT as avg_duration_per_day
 FROM (
     SELECT
         DATE(start_time) as event_date,
@@ -92,6 +273,14 @@ FROM (
     WHERE user_id = $1
       AND start_time >= $2
       AND end_time <= $3
+      AND COALESCE(status, 'confirmed') <> 'cancelled'
+      AND NOT COALESCE(is_all_day, false)
+      AND COALESCE(transparency, 'opaque') <> 'transparent'
+      AND COALESCE(response_status, '') <> 'declined'
+      AND calendar_id NOT IN (
+          SELECT c.calendar_id FROM googlecalendar_calendars c
+          WHERE c.user_id = $1 AND NOT c.included
+      )
     GROUP BY DATE(start_time)
 ) AS daily_stats
 `
@@ -118,10 +307,18 @@ const getBusiestDays = `-- name: GetBusiestDays :many
 SELECT
     DATE(start_time) as event_date,
     COUNT(*) as event_count,
-    SUM(duration) as total_duration_minutes
+    COALESCE(SUM(duration), 0)::bigint as total_duration_minutes
 FROM googlecalendar_events
 WHERE user_id = $1
   AND start_time >= CURRENT_DATE - INTERVAL '30 days'
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND NOT COALESCE(is_all_day, false)
+  AND COALESCE(transparency, 'opaque') <> 'transparent'
+  AND COALESCE(response_status, '') <> 'declined'
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = $1 AND NOT c.included
+  )
 GROUP BY DATE(start_time)
 ORDER BY event_count DESC, total_duration_minutes DESC
 LIMIT $2
@@ -159,38 +356,73 @@ func (q *Queries) GetBusiestDays(ctx context.Context, arg GetBusiestDaysParams)
 }
 
 const getCalendarEventsByDateRange = `-- name: GetCalendarEventsByDateRange :many
+
 SELECT
     event_id,
     summary,
     description,
     start_time,
-    end_time
+    end_time,
+    status,
+    calendar_id,
+    is_all_day,
+    organizer_email,
+    recurring_event_id,
+    transparency,
+    event_type,
+    response_status,
+    attendees_count
 FROM
     googlecalendar_events
 WHERE
     user_id = $1
   AND start_time >= $2
   AND start_time <= $3
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = $1 AND NOT c.included
+  )
+  AND CASE $4::text
+        WHEN 'all' THEN TRUE
+        WHEN '' THEN COALESCE(status, 'confirmed') <> 'cancelled'
+        ELSE COALESCE(status, 'confirmed') = $4::text
+      END
 ORDER BY
     start_time ASC
 `
 
 type GetCalendarEventsByDateRangeParams struct {
-	UserID      pgtype.UUID
-	StartTime   pgtype.Timestamptz
-	StartTime_2 pgtype.Timestamptz
+	UserID    pgtype.UUID
+	StartTime pgtype.Timestamptz
+	EndTime   pgtype.Timestamptz
+	Status    string
 }
 
 type GetCalendarEventsByDateRangeRow struct {
-	EventID     string
-	Summary     pgtype.Text
-	Description pgtype.Text
-	StartTime   pgtype.Timestamptz
-	EndTime     pgtype.Timestamptz
+	EventID          string
+	Summary          pgtype.Text
+	Description      pgtype.Text
+	StartTime        pgtype.Timestamptz
+	EndTime          pgtype.Timestamptz
+	Status           pgtype.Text
+	CalendarID       string
+	IsAllDay         pgtype.Bool
+	OrganizerEmail   pgtype.Text
+	RecurringEventID pgtype.Text
+	Transparency     pgtype.Text
+	EventType        pgtype.Text
+	ResponseStatus   pgtype.Text
+	AttendeesCount   int32
 }
 
+// status: пустая строка - все кроме отменённых, 'all' - все, иначе точное совпадение
 func (q *Queries) GetCalendarEventsByDateRange(ctx context.Context, arg GetCalendarEventsByDateRangeParams) ([]GetCalendarEventsByDateRangeRow, error) {
-	rows, err := q.db.Query(ctx, getCalendarEventsByDateRange, arg.UserID, arg.StartTime, arg.StartTime_2)
+	rows, err := q.db.Query(ctx, getCalendarEventsByDateRange,
+		arg.UserID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Status,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -204,6 +436,15 @@ func (q *Queries) GetCalendarEventsByDateRange(ctx context.Context, arg GetCalen
 			&i.Description,
 			&i.StartTime,
 			&i.EndTime,
+			&i.Status,
+			&i.CalendarID,
+			&i.IsAllDay,
+			&i.OrganizerEmail,
+			&i.RecurringEventID,
+			&i.Transparency,
+			&i.EventType,
+			&i.ResponseStatus,
+			&i.AttendeesCount,
 		); err != nil {
 			return nil, err
 		}
@@ -219,13 +460,20 @@ const getDailyEventsSummary = `-- name: GetDailyEventsSummary :many
 
 SELECT
     DATE(start_time) as event_date,
-    COUNT(*) as total_events,
-    SUM(duration) as total_duration_minutes,
-    COUNT(CASE WHEN is_all_day THEN 1 END) as all_day_events
+    COUNT(*) FILTER (WHERE NOT COALESCE(is_all_day, false)) as total_events,
+    COALESCE(SUM(duration) FILTER (WHERE NOT COALESCE(is_all_day, false)), 0)::bigint as total_duration_minutes,
+    COUNT(*) FILTER (WHERE is_all_day) as all_day_events
 FROM googlecalendar_events
 WHERE user_id = $1
   AND start_time >= $2
   AND end_time <= $3
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND COALESCE(transparency, 'opaque') <> 'transparent'
+  AND COALESCE(response_status, '') <> 'declined'
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = $1 AND NOT c.included
+  )
 GROUP BY DATE(start_time)
 ORDER BY event_date DESC
 `
@@ -244,6 +492,9 @@ type GetDailyEventsSummaryRow struct {
 }
 
 // Analytics Queries -------------------------------------------------------------------
+// Аналитика учитывает только занятое время: без отменённых, свободных (transparent)
+// и отклонённых событий, а также без событий из исключённых календарей.
+// События на весь день не входят в длительность
 func (q *Queries) GetDailyEventsSummary(ctx context.Context, arg GetDailyEventsSummaryParams) ([]GetDailyEventsSummaryRow, error) {
 	rows, err := q.db.Query(ctx, getDailyEventsSummary, arg.UserID, arg.StartTime, arg.EndTime)
 	if err != nil {
@@ -270,7 +521,7 @@ func (q *Queries) GetDailyEventsSummary(ctx context.Context, arg GetDailyEventsS
 }
 
 const getEventByID = `-- name: GetEventByID :one
-SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at FROM googlecalendar_events WHERE user_id = $1 AND event_id = $2
+SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at, organizer_email, organizer_name, recurring_event_id, recurrence, transparency, event_type, response_status, attendees_count, ical_uid, source_href FROM googlecalendar_events WHERE user_id = $1 AND event_id = $2
 `
 
 type GetEventByIDParams struct {
@@ -296,6 +547,16 @@ func (q *Queries) GetEventByID(ctx context.Context, arg GetEventByIDParams) (Goo
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizerEmail,
+		&i.OrganizerName,
+		&i.RecurringEventID,
+		&i.Recurrence,
+		&i.Transparency,
+		&i.EventType,
+		&i.ResponseStatus,
+		&i.AttendeesCount,
+		&i.IcalUid,
+		&i.SourceHref,
 	)
 	return i, err
 }
@@ -304,11 +565,15 @@ const getEventsByCalendarSummary = `-- name: GetEventsByCalendarSummary :many
 SELECT
     calendar_id,
     COUNT(*) as total_events,
-    SUM(duration) as total_duration_minutes
+    COALESCE(SUM(duration), 0)::bigint as total_duration_minutes
 FROM googlecalendar_events
 WHERE user_id = $1
   AND start_time >= $2
   AND end_time <= $3
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND NOT COALESCE(is_all_day, false)
+  AND COALESCE(transparency, 'opaque') <> 'transparent'
+  AND COALESCE(response_status, '') <> 'declined'
 GROUP BY calendar_id
 ORDER BY total_events DESC
 `
@@ -345,8 +610,204 @@ func (q *Queries) GetEventsByCalendarSummary(ctx context.Context, arg GetEventsB
 	return items, nil
 }
 
+const getFeedTokenByHash = `-- name: GetFeedTokenByHash :one
+SELECT id, user_id, name, token_hash, include_events, include_wakatime, include_activitywatch, created_at, last_used_at FROM calendar_feed_tokens
+WHERE token_hash = $1
+`
+
+func (q *Queries) GetFeedTokenByHash(ctx context.Context, tokenHash string) (CalendarFeedToken, error) {
+	row := q.db.QueryRow(ctx, getFeedTokenByHash, tokenHash)
+	var i CalendarFeedToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.IncludeEvents,
+		&i.IncludeWakatime,
+		&i.IncludeActivitywatch,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getSyncState = `-- name: GetSyncState :one
+
+SELECT user_id, calendar_id, sync_token, last_full_sync_at, last_sync_at FROM googlecalendar_sync_state
+WHERE user_id = $1 AND calendar_id = $2
+`
+
+type GetSyncStateParams struct {
+	UserID     pgtype.UUID
+	CalendarID string
+}
+
+// Sync State Queries -------------------------------------------------------------------
+func (q *Queries) GetSyncState(ctx context.Context, arg GetSyncStateParams) (GooglecalendarSyncState, error) {
+	row := q.db.QueryRow(ctx, getSyncState, arg.UserID, arg.CalendarID)
+	var i GooglecalendarSyncState
+	err := row.Scan(
+		&i.UserID,
+		&i.CalendarID,
+		&i.SyncToken,
+		&i.LastFullSyncAt,
+		&i.LastSyncAt,
+	)
+	return i, err
+}
+
+const listBusyEventsByRange = `-- name: ListBusyEventsByRange :many
+
+SELECT
+    event_id,
+    summary,
+    start_time,
+    end_time,
+    attendees_count
+FROM googlecalendar_events
+WHERE user_id = $1
+  AND end_time > $2
+  AND start_time < $3
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND NOT COALESCE(is_all_day, false)
+  AND COALESCE(transparency, 'opaque') <> 'transparent'
+  AND COALESCE(response_status, '') <> 'declined'
+  AND COALESCE(event_type, 'default') NOT IN ('outOfOffice', 'focusTime', 'workingLocation')
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = $1 AND NOT c.included
+  )
+ORDER BY start_time ASC
+`
+
+type ListBusyEventsByRangeParams struct {
+	UserID    pgtype.UUID
+	StartTime pgtype.Timestamptz
+	EndTime   pgtype.Timestamptz
+}
+
+type ListBusyEventsByRangeRow struct {
+	EventID        string
+	Summary        pgtype.Text
+	StartTime      pgtype.Timestamptz
+	EndTime        pgtype.Timestamptz
+	AttendeesCount int32
+}
+
+// Встречи, занимающие время: без отменённых, свободных, отклонённых, на весь день,
+// служебных типов (отсутствие, время фокуса, место работы) и из исключённых календарей
+func (q *Queries) ListBusyEventsByRange(ctx context.Context, arg ListBusyEventsByRangeParams) ([]ListBusyEventsByRangeRow, error) {
+	rows, err := q.db.Query(ctx, listBusyEventsByRange, arg.UserID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBusyEventsByRangeRow
+	for rows.Next() {
+		var i ListBusyEventsByRangeRow
+		if err := rows.Scan(
+			&i.EventID,
+			&i.Summary,
+			&i.StartTime,
+			&i.EndTime,
+			&i.AttendeesCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCalendars = `-- name: ListCalendars :many
+SELECT id, user_id, calendar_id, summary, description, time_zone, background_color, foreground_color, access_role, is_primary, sync_enabled, included, created_at, updated_at FROM googlecalendar_calendars
+WHERE user_id = $1
+ORDER BY is_primary DESC, summary ASC
+`
+
+func (q *Queries) ListCalendars(ctx context.Context, userID pgtype.UUID) ([]GooglecalendarCalendar, error) {
+	rows, err := q.db.Query(ctx, listCalendars, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GooglecalendarCalendar
+	for rows.Next() {
+		var i GooglecalendarCalendar
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CalendarID,
+			&i.Summary,
+			&i.Description,
+			&i.TimeZone,
+			&i.BackgroundColor,
+			&i.ForegroundColor,
+			&i.AccessRole,
+			&i.IsPrimary,
+			&i.SyncEnabled,
+			&i.Included,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventAttendees = `-- name: ListEventAttendees :many
+SELECT id, user_id, event_id, email, display_name, response_status, is_organizer, is_self, is_optional, is_resource FROM googlecalendar_event_attendees
+WHERE user_id = $1 AND event_id = $2
+ORDER BY is_organizer DESC, email ASC
+`
+
+type ListEventAttendeesParams struct {
+	UserID  pgtype.UUID
+	EventID string
+}
+
+func (q *Queries) ListEventAttendees(ctx context.Context, arg ListEventAttendeesParams) ([]GooglecalendarEventAttendee, error) {
+	rows, err := q.db.Query(ctx, listEventAttendees, arg.UserID, arg.EventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GooglecalendarEventAttendee
+	for rows.Next() {
+		var i GooglecalendarEventAttendee
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.EventID,
+			&i.Email,
+			&i.DisplayName,
+			&i.ResponseStatus,
+			&i.IsOrganizer,
+			&i.IsSelf,
+			&i.IsOptional,
+			&i.IsResource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEventsByCalendar = `-- name: ListEventsByCalendar :many
-SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at FROM googlecalendar_events
+SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at, organizer_email, organizer_name, recurring_event_id, recurrence, transparency, event_type, response_status, attendees_count, ical_uid, source_href FROM googlecalendar_events
 WHERE user_id = $1 AND calendar_id = $2
 ORDER BY start_time DESC
 LIMIT $3 OFFSET $4
@@ -388,6 +849,16 @@ func (q *Queries) ListEventsByCalendar(ctx context.Context, arg ListEventsByCale
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OrganizerEmail,
+			&i.OrganizerName,
+			&i.RecurringEventID,
+			&i.Recurrence,
+			&i.Transparency,
+			&i.EventType,
+			&i.ResponseStatus,
+			&i.AttendeesCount,
+			&i.IcalUid,
+			&i.SourceHref,
 		); err != nil {
 			return nil, err
 		}
@@ -400,7 +871,7 @@ func (q *Queries) ListEventsByCalendar(ctx context.Context, arg ListEventsByCale
 }
 
 const listEventsByDate = `-- name: ListEventsByDate :many
-SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at FROM googlecalendar_events
+SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at, organizer_email, organizer_name, recurring_event_id, recurrence, transparency, event_type, response_status, attendees_count, ical_uid, source_href FROM googlecalendar_events
 WHERE user_id = $1 AND DATE(start_time) = $2
 ORDER BY start_time ASC
 `
@@ -434,6 +905,16 @@ func (q *Queries) ListEventsByDate(ctx context.Context, arg ListEventsByDatePara
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OrganizerEmail,
+			&i.OrganizerName,
+			&i.RecurringEventID,
+			&i.Recurrence,
+			&i.Transparency,
+			&i.EventType,
+			&i.ResponseStatus,
+			&i.AttendeesCount,
+			&i.IcalUid,
+			&i.SourceHref,
 		); err != nil {
 			return nil, err
 		}
@@ -446,7 +927,7 @@ func (q *Queries) ListEventsByDate(ctx context.Context, arg ListEventsByDatePara
 }
 
 const listEventsByDateRange = `-- name: ListEventsByDateRange :many
-SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at FROM googlecalendar_events
+SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at, organizer_email, organizer_name, recurring_event_id, recurrence, transparency, event_type, response_status, attendees_count, ical_uid, source_href FROM googlecalendar_events
 WHERE user_id = $1 AND start_time >= $2 AND end_time <= $3
 ORDER BY start_time ASC
 `
@@ -481,6 +962,16 @@ func (q *Queries) ListEventsByDateRange(ctx context.Context, arg ListEventsByDat
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OrganizerEmail,
+			&i.OrganizerName,
+			&i.RecurringEventID,
+			&i.Recurrence,
+			&i.Transparency,
+			&i.EventType,
+			&i.ResponseStatus,
+			&i.AttendeesCount,
+			&i.IcalUid,
+			&i.SourceHref,
 		); err != nil {
 			return nil, err
 		}
@@ -493,7 +984,7 @@ func (q *Queries) ListEventsByDateRange(ctx context.Context, arg ListEventsByDat
 }
 
 const listEventsByUser = `-- name: ListEventsByUser :many
-SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at FROM googlecalendar_events
+SELECT id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at, organizer_email, organizer_name, recurring_event_id, recurrence, transparency, event_type, response_status, attendees_count, ical_uid, source_href FROM googlecalendar_events
 WHERE user_id = $1
 ORDER BY start_time DESC
 LIMIT $2 OFFSET $3
@@ -529,6 +1020,131 @@ func (q *Queries) ListEventsByUser(ctx context.Context, arg ListEventsByUserPara
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OrganizerEmail,
+			&i.OrganizerName,
+			&i.RecurringEventID,
+			&i.Recurrence,
+			&i.Transparency,
+			&i.EventType,
+			&i.ResponseStatus,
+			&i.AttendeesCount,
+			&i.IcalUid,
+			&i.SourceHref,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExportEventsByRange = `-- name: ListExportEventsByRange :many
+
+SELECT
+    event_id,
+    summary,
+    description,
+    location,
+    start_time,
+    end_time,
+    is_all_day,
+    status,
+    transparency,
+    recurring_event_id,
+    updated_at
+FROM googlecalendar_events
+WHERE user_id = $1
+  AND start_time < $2
+  AND end_time > $3
+  AND COALESCE(status, 'confirmed') <> 'cancelled'
+  AND calendar_id NOT IN (
+      SELECT c.calendar_id FROM googlecalendar_calendars c
+      WHERE c.user_id = $1 AND NOT c.included
+  )
+ORDER BY start_time ASC
+`
+
+type ListExportEventsByRangeParams struct {
+	UserID    pgtype.UUID
+	EndTime   pgtype.Timestamptz
+	StartTime pgtype.Timestamptz
+}
+
+type ListExportEventsByRangeRow struct {
+	EventID          string
+	Summary          pgtype.Text
+	Description      pgtype.Text
+	Location         pgtype.Text
+	StartTime        pgtype.Timestamptz
+	EndTime          pgtype.Timestamptz
+	IsAllDay         pgtype.Bool
+	Status           pgtype.Text
+	Transparency     pgtype.Text
+	RecurringEventID pgtype.Text
+	UpdatedAt        pgtype.Timestamptz
+}
+
+// Экспорт в iCalendar: события включённых календарей, пересекающие диапазон
+func (q *Queries) ListExportEventsByRange(ctx context.Context, arg ListExportEventsByRangeParams) ([]ListExportEventsByRangeRow, error) {
+	rows, err := q.db.Query(ctx, listExportEventsByRange, arg.UserID, arg.EndTime, arg.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExportEventsByRangeRow
+	for rows.Next() {
+		var i ListExportEventsByRangeRow
+		if err := rows.Scan(
+			&i.EventID,
+			&i.Summary,
+			&i.Description,
+			&i.Location,
+			&i.StartTime,
+			&i.EndTime,
+			&i.IsAllDay,
+			&i.Status,
+			&i.Transparency,
+			&i.RecurringEventID,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedTokens = `-- name: ListFeedTokens :many
+SELECT id, user_id, name, token_hash, include_events, include_wakatime, include_activitywatch, created_at, last_used_at FROM calendar_feed_tokens
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListFeedTokens(ctx context.Context, userID pgtype.UUID) ([]CalendarFeedToken, error) {
+	rows, err := q.db.Query(ctx, listFeedTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CalendarFeedToken
+	for rows.Next() {
+		var i CalendarFeedToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.TokenHash,
+			&i.IncludeEvents,
+			&i.IncludeWakatime,
+			&i.IncludeActivitywatch,
+			&i.CreatedAt,
+			&i.LastUsedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -540,13 +1156,68 @@ func (q *Queries) ListEventsByUser(ctx context.Context, arg ListEventsByUserPara
 	return items, nil
 }
 
+const touchFeedToken = `-- name: TouchFeedToken :exec
+UPDATE calendar_feed_tokens
+SET last_used_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) TouchFeedToken(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, touchFeedToken, id)
+	return err
+}
+
+const updateCalendarSettings = `-- name: UpdateCalendarSettings :one
+UPDATE googlecalendar_calendars
+SET
+    sync_enabled = COALESCE($1, sync_enabled),
+    included = COALESCE($2, included),
+    updated_at = now()
+WHERE user_id = $3 AND calendar_id = $4
+RETURNING id, user_id, calendar_id, summary, description, time_zone, background_color, foreground_color, access_role, is_primary, sync_enabled, included, created_at, updated_at
+`
+
+type UpdateCalendarSettingsParams struct {
+	SyncEnabled pgtype.Bool
+	Included    pgtype.Bool
+	UserID      pgtype.UUID
+	CalendarID  string
+}
+
+func (q *Queries) UpdateCalendarSettings(ctx context.Context, arg UpdateCalendarSettingsParams) (GooglecalendarCalendar, error) {
+	row := q.db.QueryRow(ctx, updateCalendarSettings,
+		arg.SyncEnabled,
+		arg.Included,
+		arg.UserID,
+		arg.CalendarID,
+	)
+	var i GooglecalendarCalendar
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CalendarID,
+		&i.Summary,
+		&i.Description,
+		&i.TimeZone,
+		&i.BackgroundColor,
+		&i.ForegroundColor,
+		&i.AccessRole,
+		&i.IsPrimary,
+		&i.SyncEnabled,
+		&i.Included,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateEvent = `-- name: UpdateEvent :one
 UPDATE googlecalendar_events
 SET summary = $2, description = $3, location = $4,
     start_time = $5, end_time = $6, is_all_day = $7,
     duration = $8, status = $9, updated_at = now()
 WHERE id = $1
-RETURNING id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at
+RETURNING id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at, organizer_email, organizer_name, recurring_event_id, recurrence, transparency, event_type, response_status, attendees_count, ical_uid, source_href
 `
 
 type UpdateEventParams struct {
@@ -589,6 +1260,87 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Googl
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizerEmail,
+		&i.OrganizerName,
+		&i.RecurringEventID,
+		&i.Recurrence,
+		&i.Transparency,
+		&i.EventType,
+		&i.ResponseStatus,
+		&i.AttendeesCount,
+		&i.IcalUid,
+		&i.SourceHref,
+	)
+	return i, err
+}
+
+const upsertCalendar = `-- name: UpsertCalendar :one
+
+INSERT INTO googlecalendar_calendars (
+    user_id, calendar_id, summary, description, time_zone,
+    background_color, foreground_color, access_role, is_primary,
+    sync_enabled, included
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (user_id, calendar_id)
+DO UPDATE SET
+    summary = EXCLUDED.summary,
+    description = EXCLUDED.description,
+    time_zone = EXCLUDED.time_zone,
+    background_color = EXCLUDED.background_color,
+    foreground_color = EXCLUDED.foreground_color,
+    access_role = EXCLUDED.access_role,
+    is_primary = EXCLUDED.is_primary,
+    updated_at = now()
+RETURNING id, user_id, calendar_id, summary, description, time_zone, background_color, foreground_color, access_role, is_primary, sync_enabled, included, created_at, updated_at
+`
+
+type UpsertCalendarParams struct {
+	UserID          pgtype.UUID
+	CalendarID      string
+	Summary         pgtype.Text
+	Description     pgtype.Text
+	TimeZone        pgtype.Text
+	BackgroundColor pgtype.Text
+	ForegroundColor pgtype.Text
+	AccessRole      pgtype.Text
+	IsPrimary       bool
+	SyncEnabled     bool
+	Included        bool
+}
+
+// Calendars Queries -------------------------------------------------------------------
+// Настройки sync_enabled и included задаются только при первой вставке
+func (q *Queries) UpsertCalendar(ctx context.Context, arg UpsertCalendarParams) (GooglecalendarCalendar, error) {
+	row := q.db.QueryRow(ctx, upsertCalendar,
+		arg.UserID,
+		arg.CalendarID,
+		arg.Summary,
+		arg.Description,
+		arg.TimeZone,
+		arg.BackgroundColor,
+		arg.ForegroundColor,
+		arg.AccessRole,
+		arg.IsPrimary,
+		arg.SyncEnabled,
+		arg.Included,
+	)
+	var i GooglecalendarCalendar
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CalendarID,
+		&i.Summary,
+		&i.Description,
+		&i.TimeZone,
+		&i.BackgroundColor,
+		&i.ForegroundColor,
+		&i.AccessRole,
+		&i.IsPrimary,
+		&i.SyncEnabled,
+		&i.Included,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -596,9 +1348,11 @@ func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Googl
 const upsertEvent = `-- name: UpsertEvent :one
 INSERT INTO googlecalendar_events (
     user_id, event_id, calendar_id, summary, description, location,
-    start_time, end_time, is_all_day, duration, status
+    start_time, end_time, is_all_day, duration, status,
+    organizer_email, organizer_name, recurring_event_id, recurrence,
+    transparency, event_type, response_status, attendees_count, ical_uid, source_href
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 ON CONFLICT (user_id, event_id)
 DO UPDATE SET
     calendar_id = EXCLUDED.calendar_id,
@@ -610,22 +1364,42 @@ DO UPDATE SET
     is_all_day = EXCLUDED.is_all_day,
     duration = EXCLUDED.duration,
     status = EXCLUDED.status,
+    organizer_email = EXCLUDED.organizer_email,
+    organizer_name = EXCLUDED.organizer_name,
+    recurring_event_id = EXCLUDED.recurring_event_id,
+    recurrence = EXCLUDED.recurrence,
+    transparency = EXCLUDED.transparency,
+    event_type = EXCLUDED.event_type,
+    response_status = EXCLUDED.response_status,
+    attendees_count = EXCLUDED.attendees_count,
+    ical_uid = EXCLUDED.ical_uid,
+    source_href = EXCLUDED.source_href,
     updated_at = now()
-RETURNING id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at
+RETURNING id, user_id, event_id, calendar_id, summary, description, location, start_time, end_time, is_all_day, duration, status, created_at, updated_at, organizer_email, organizer_name, recurring_event_id, recurrence, transparency, event_type, response_status, attendees_count, ical_uid, source_href
 `
 
 type UpsertEventParams struct {
-	UserID      pgtype.UUID
-	EventID     string
-	CalendarID  string
-	Summary     pgtype.Text
-	Description pgtype.Text
-	Location    pgtype.Text
-	StartTime   pgtype.Timestamptz
-	EndTime     pgtype.Timestamptz
-	IsAllDay    pgtype.Bool
-	Duration    pgtype.Int4
-	Status      pgtype.Text
+	UserID           pgtype.UUID
+	EventID          string
+	CalendarID       string
+	Summary          pgtype.Text
+	Description      pgtype.Text
+	Location         pgtype.Text
+	StartTime        pgtype.Timestamptz
+	EndTime          pgtype.Timestamptz
+	IsAllDay         pgtype.Bool
+	Duration         pgtype.Int4
+	Status           pgtype.Text
+	OrganizerEmail   pgtype.Text
+	OrganizerName    pgtype.Text
+	RecurringEventID pgtype.Text
+	Recurrence       []string
+	Transparency     pgtype.Text
+	EventType        pgtype.Text
+	ResponseStatus   pgtype.Text
+	AttendeesCount   int32
+	IcalUid          pgtype.Text
+	SourceHref       pgtype.Text
 }
 
 func (q *Queries) UpsertEvent(ctx context.Context, arg UpsertEventParams) (GooglecalendarEvent, error) {
@@ -641,6 +1415,16 @@ func (q *Queries) UpsertEvent(ctx context.Context, arg UpsertEventParams) (Googl
 		arg.IsAllDay,
 		arg.Duration,
 		arg.Status,
+		arg.OrganizerEmail,
+		arg.OrganizerName,
+		arg.RecurringEventID,
+		arg.Recurrence,
+		arg.Transparency,
+		arg.EventType,
+		arg.ResponseStatus,
+		arg.AttendeesCount,
+		arg.IcalUid,
+		arg.SourceHref,
 	)
 	var i GooglecalendarEvent
 	err := row.Scan(
@@ -658,6 +1442,86 @@ func (q *Queries) UpsertEvent(ctx context.Context, arg UpsertEventParams) (Googl
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizerEmail,
+		&i.OrganizerName,
+		&i.RecurringEventID,
+		&i.Recurrence,
+		&i.Transparency,
+		&i.EventType,
+		&i.ResponseStatus,
+		&i.AttendeesCount,
+		&i.IcalUid,
+		&i.SourceHref,
 	)
 	return i, err
 }
+
+const upsertEventAttendee = `-- name: UpsertEventAttendee :exec
+INSERT INTO googlecalendar_event_attendees (
+    user_id, event_id, email, display_name, response_status,
+    is_organizer, is_self, is_optional, is_resource
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (user_id, event_id, email)
+DO UPDATE SET
+    display_name = EXCLUDED.display_name,
+    response_status = EXCLUDED.response_status,
+    is_organizer = EXCLUDED.is_organizer,
+    is_self = EXCLUDED.is_self,
+    is_optional = EXCLUDED.is_optional,
+    is_resource = EXCLUDED.is_resource
+`
+
+type UpsertEventAttendeeParams struct {
+	UserID         pgtype.UUID
+	EventID        string
+	Email          string
+	DisplayName    pgtype.Text
+	ResponseStatus pgtype.Text
+	IsOrganizer    bool
+	IsSelf         bool
+	IsOptional     bool
+	IsResource     bool
+}
+
+func (q *Queries) UpsertEventAttendee(ctx context.Context, arg UpsertEventAttendeeParams) error {
+	_, err := q.db.Exec(ctx, upsertEventAttendee,
+		arg.UserID,
+		arg.EventID,
+		arg.Email,
+		arg.DisplayName,
+		arg.ResponseStatus,
+		arg.IsOrganizer,
+		arg.IsSelf,
+		arg.IsOptional,
+		arg.IsResource,
+	)
+	return err
+}
+
+const upsertSyncState = `-- name: UpsertSyncState :exec
+INSERT INTO googlecalendar_sync_state (user_id, calendar_id, sync_token, last_full_sync_at, last_sync_at)
+VALUES ($1, $2, $3, CASE WHEN $4::bool THEN now() END, now())
+ON CONFLICT (user_id, calendar_id)
+DO UPDATE SET
+    sync_token = EXCLUDED.sync_token,
+    last_full_sync_at = COALESCE(EXCLUDED.last_full_sync_at, googlecalendar_sync_state.last_full_sync_at),
+    last_sync_at = now()
+`
+
+type UpsertSyncStateParams struct {
+	UserID     pgtype.UUID
+	CalendarID string
+	SyncToken  pgtype.Text
+	FullSync   bool
+}
+
+func (q *Queries) UpsertSyncState(ctx context.Context, arg UpsertSyncStateParams) error {
+	_, err := q.db.Exec(ctx, upsertSyncState,
+		arg.UserID,
+		arg.CalendarID,
+		arg.SyncToken,
+		arg.FullSync,
+	)
+	return err
+}
@@ -8,19 +8,79 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type CalendarFeedToken struct {
+	ID                   int32
+	UserID               pgtype.UUID
+	Name                 string
+	TokenHash            string
+	IncludeEvents        bool
+	IncludeWakatime      bool
+	IncludeActivitywatch bool
+	CreatedAt            pgtype.Timestamptz
+	LastUsedAt           pgtype.Timestamptz
+}
+
+type GooglecalendarCalendar struct {
+	ID              int32
+	UserID          pgtype.UUID
+	CalendarID      string
+	Summary         pgtype.Text
+	Description     pgtype.Text
+	TimeZone        pgtype.Text
+	BackgroundColor pgtype.Text
+	ForegroundColor pgtype.Text
+	AccessRole      pgtype.Text
+	IsPrimary       bool
+	SyncEnabled     bool
+	Included        bool
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+}
+
 type GooglecalendarEvent struct {
-	ID          int32
-	UserID      pgtype.UUID
-	EventID     string
-	CalendarID  string
-	Summary     pgtype.Text
-	Description pgtype.Text
-	Location    pgtype.Text
-	StartTime   pgtype.Timestamptz
-	EndTime     pgtype.Timestamptz
-	IsAllDay    pgtype.Bool
-	Duration    pgtype.Int4
-	Status      pgtype.Text
-	CreatedAt   pgtype.Timestamptz
-	UpdatedAt   pgtype.Timestamptz
+	ID               int32
+	UserID           pgtype.UUID
+	EventID          string
+	CalendarID       string
+	Summary          pgtype.Text
+	Description      pgtype.Text
+	Location         pgtype.Text
+	StartTime        pgtype.Timestamptz
+	EndTime          pgtype.Timestamptz
+	IsAllDay         pgtype.Bool
+	Duration         pgtype.Int4
+	Status           pgtype.Text
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	OrganizerEmail   pgtype.Text
+	OrganizerName    pgtype.Text
+	RecurringEventID pgtype.Text
+	Recurrence       []string
+	Transparency     pgtype.Text
+	EventType        pgtype.Text
+	ResponseStatus   pgtype.Text
+	AttendeesCount   int32
+	IcalUid          pgtype.Text
+	SourceHref       pgtype.Text
+}
+
+type GooglecalendarEventAttendee struct {
+	ID             int64
+	UserID         pgtype.UUID
+	EventID        string
+	Email          string
+	DisplayName    pgtype.Text
+	ResponseStatus pgtype.Text
+	IsOrganizer    bool
+	IsSelf         bool
+	IsOptional     bool
+	IsResource     bool
+}
+
+type GooglecalendarSyncState struct {
+	UserID         pgtype.UUID
+	CalendarID     string
+	SyncToken      pgtype.Text
+	LastFullSyncAt pgtype.Timestamptz
+	LastSyncAt     pgtype.Timestamptz
 }
@@ -55,6 +55,36 @@ func (q *Queries) DeleteDailyStat(ctx context.Context, id int32) error {
 	return err
 }
 
+const deletePreferredSource = `-- name: DeletePreferredSource :exec
+DELETE FROM googlefit_preferred_sources
+WHERE user_id = $1 AND data_type = $2
+`
+
+type DeletePreferredSourceParams struct {
+	UserID   pgtype.UUID
+	DataType string
+}
+
+func (q *Queries) DeletePreferredSource(ctx context.Context, arg DeletePreferredSourceParams) error {
+	_, err := q.db.Exec(ctx, deletePreferredSource, arg.UserID, arg.DataType)
+	return err
+}
+
+const deleteSessions = `-- name: DeleteSessions :exec
+DELETE FROM googlefit_sessions
+WHERE user_id = $1 AND session_id = ANY($2::text[])
+`
+
+type DeleteSessionsParams struct {
+	UserID     pgtype.UUID
+	SessionIds []string
+}
+
+func (q *Queries) DeleteSessions(ctx context.Context, arg DeleteSessionsParams) error {
+	_, err := q.db.Exec(ctx, deleteSessions, arg.UserID, arg.SessionIds)
+	return err
+}
+
 const getDailyStatByDate = `-- name: GetDailyStatByDate :one
 SELECT id, user_id, date, steps, distance, created_at, updated_at FROM googlefit_daily_stats WHERE user_id = $1 AND date = $2
 `
@@ -79,19 +109,57 @@ func (q *Queries) GetDailyStatByDate(ctx context.Context, arg GetDailyStatByDate
 	return i, err
 }
 
+const getDataSource = `-- name: GetDataSource :one
+SELECT id, user_id, data_source_id, data_type, type, device_manufacturer, device_model, device_type, application, created_at, updated_at FROM googlefit_data_sources
+WHERE user_id = $1 AND data_source_id = $2
+`
+
+type GetDataSourceParams struct {
+	UserID       pgtype.UUID
+	DataSourceID string
+}
+
+func (q *Queries) GetDataSource(ctx context.Context, arg GetDataSourceParams) (GooglefitDataSource, error) {
+	row := q.db.QueryRow(ctx, getDataSource, arg.UserID, arg.DataSourceID)
+	var i GooglefitDataSource
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.DataSourceID,
+		&i.DataType,
+		&i.Type,
+		&i.DeviceManufacturer,
+		&i.DeviceModel,
+		&i.DeviceType,
+		&i.Application,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getGoogleFitDailyStatsByDateRange = `-- name: GetGoogleFitDailyStatsByDateRange :many
+
 SELECT
-    date,
-    steps,
-    distance
+    d.date,
+    COALESCE(ps.value::int, d.steps) AS steps,
+    COALESCE(pd.value, d.distance) AS distance
 FROM
-    googlefit_daily_stats
+    googlefit_daily_stats d
+    LEFT JOIN googlefit_preferred_sources sps
+        ON sps.user_id = d.user_id AND sps.data_type = 'com.google.step_count.delta'
+    LEFT JOIN googlefit_source_daily_stats ps
+        ON ps.user_id = d.user_id AND ps.data_source_id = sps.data_source_id AND ps.date = d.date
+    LEFT JOIN googlefit_preferred_sources spd
+        ON spd.user_id = d.user_id AND spd.data_type = 'com.google.distance.delta'
+    LEFT JOIN googlefit_source_daily_stats pd
+        ON pd.user_id = d.user_id AND pd.data_source_id = spd.data_source_id AND pd.date = d.date
 WHERE
-    user_id = $1
-  AND date >= $2
-  AND date <= $3
+    d.user_id = $1
+  AND d.date >= $2
+  AND d.date <= $3
 ORDER BY
-    date DESC
+    d.date DESC
 `
 
 type GetGoogleFitDailyStatsByDateRangeParams struct {
@@ -106,6 +174,8 @@ type GetGoogleFitDailyStatsByDateRangeRow struct {
 	Distance pgtype.Float8
 }
 
+// Если для типа данных выбран предпочитаемый источник и у него есть данные за день,
+// берётся его значение вместо объединённого агрегата Google Fit
 func (q *Queries) GetGoogleFitDailyStatsByDateRange(ctx context.Context, arg GetGoogleFitDailyStatsByDateRangeParams) ([]GetGoogleFitDailyStatsByDateRangeRow, error) {
 	rows, err := q.db.Query(ctx, getGoogleFitDailyStatsByDateRange, arg.UserID, arg.Date, arg.Date_2)
 	if err != nil {
@@ -156,6 +226,78 @@ func (q *Queries) GetMonthlyAverage(ctx context.Context, userID pgtype.UUID) (Ge
 	return i, err
 }
 
+const getSleepSummaryByDateRange = `-- name: GetSleepSummaryByDateRange :many
+
+SELECT
+    (end_time AT TIME ZONE $1::text)::date AS night,
+    MIN(start_time)::timestamptz AS sleep_start,
+    MAX(end_time)::timestamptz AS sleep_end,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage IN (2, 4, 5, 6)), 0)::float AS asleep_minutes,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage = 4), 0)::float AS light_minutes,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage = 5), 0)::float AS deep_minutes,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage = 6), 0)::float AS rem_minutes,
+    COALESCE(SUM(EXTRACT(EPOCH FROM end_time - start_time) / 60) FILTER (WHERE stage IN (1, 3)), 0)::float AS awake_minutes
+FROM googlefit_sleep_segments
+WHERE user_id = $2
+  AND end_time >= $3 AND end_time < $4
+GROUP BY night
+ORDER BY night DESC
+`
+
+type GetSleepSummaryByDateRangeParams struct {
+	Tz        string
+	UserID    pgtype.UUID
+	StartTime pgtype.Timestamptz
+	EndTime   pgtype.Timestamptz
+}
+
+type GetSleepSummaryByDateRangeRow struct {
+	Night         pgtype.Date
+	SleepStart    pgtype.Timestamptz
+	SleepEnd      pgtype.Timestamptz
+	AsleepMinutes float64
+	LightMinutes  float64
+	DeepMinutes   float64
+	RemMinutes    float64
+	AwakeMinutes  float64
+}
+
+// Ночь относится к локальному дню пробуждения в часовом поясе tz,
+// start_time и end_time - границы локальных дней из localRange
+func (q *Queries) GetSleepSummaryByDateRange(ctx context.Context, arg GetSleepSummaryByDateRangeParams) ([]GetSleepSummaryByDateRangeRow, error) {
+	rows, err := q.db.Query(ctx, getSleepSummaryByDateRange,
+		arg.Tz,
+		arg.UserID,
+		arg.StartTime,
+		arg.EndTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSleepSummaryByDateRangeRow
+	for rows.Next() {
+		var i GetSleepSummaryByDateRangeRow
+		if err := rows.Scan(
+			&i.Night,
+			&i.SleepStart,
+			&i.SleepEnd,
+			&i.AsleepMinutes,
+			&i.LightMinutes,
+			&i.DeepMinutes,
+			&i.RemMinutes,
+			&i.AwakeMinutes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWeeklyStepsSummary = `-- name: GetWeeklyStepsSummary :many
 
 SELECT
@@ -202,6 +344,133 @@ func (q *Queries) GetWeeklyStepsSummary(ctx context.Context, userID pgtype.UUID)
 	return items, nil
 }
 
+const listBodyMeasurementsByRange = `-- name: ListBodyMeasurementsByRange :many
+SELECT id, user_id, metric, measured_at, value, created_at FROM googlefit_body_measurements
+WHERE user_id = $1 AND metric = $2 AND measured_at >= $3 AND measured_at < $4
+ORDER BY measured_at ASC
+`
+
+type ListBodyMeasurementsByRangeParams struct {
+	UserID       pgtype.UUID
+	Metric       string
+	MeasuredAt   pgtype.Timestamptz
+	MeasuredAt_2 pgtype.Timestamptz
+}
+
+func (q *Queries) ListBodyMeasurementsByRange(ctx context.Context, arg ListBodyMeasurementsByRangeParams) ([]GooglefitBodyMeasurement, error) {
+	rows, err := q.db.Query(ctx, listBodyMeasurementsByRange,
+		arg.UserID,
+		arg.Metric,
+		arg.MeasuredAt,
+		arg.MeasuredAt_2,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GooglefitBodyMeasurement
+	for rows.Next() {
+		var i GooglefitBodyMeasurement
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Metric,
+			&i.MeasuredAt,
+			&i.Value,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDailyActivityByDateRange = `-- name: ListDailyActivityByDateRange :many
+SELECT id, user_id, date, calories, move_minutes, heart_points, created_at, updated_at FROM googlefit_daily_activity
+WHERE user_id = $1 AND date BETWEEN $2 AND $3
+ORDER BY date DESC
+`
+
+type ListDailyActivityByDateRangeParams struct {
+	UserID pgtype.UUID
+	Date   pgtype.Date
+	Date_2 pgtype.Date
+}
+
+func (q *Queries) ListDailyActivityByDateRange(ctx context.Context, arg ListDailyActivityByDateRangeParams) ([]GooglefitDailyActivity, error) {
+	rows, err := q.db.Query(ctx, listDailyActivityByDateRange, arg.UserID, arg.Date, arg.Date_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GooglefitDailyActivity
+	for rows.Next() {
+		var i GooglefitDailyActivity
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Date,
+			&i.Calories,
+			&i.MoveMinutes,
+			&i.HeartPoints,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDailyHeartRateByDateRange = `-- name: ListDailyHeartRateByDateRange :many
+SELECT id, user_id, date, min_bpm, avg_bpm, max_bpm, created_at, updated_at FROM googlefit_daily_heart_rate
+WHERE user_id = $1 AND date BETWEEN $2 AND $3
+ORDER BY date DESC
+`
+
+type ListDailyHeartRateByDateRangeParams struct {
+	UserID pgtype.UUID
+	Date   pgtype.Date
+	Date_2 pgtype.Date
+}
+
+func (q *Queries) ListDailyHeartRateByDateRange(ctx context.Context, arg ListDailyHeartRateByDateRangeParams) ([]GooglefitDailyHeartRate, error) {
+	rows, err := q.db.Query(ctx, listDailyHeartRateByDateRange, arg.UserID, arg.Date, arg.Date_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GooglefitDailyHeartRate
+	for rows.Next() {
+		var i GooglefitDailyHeartRate
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Date,
+			&i.MinBpm,
+			&i.AvgBpm,
+			&i.MaxBpm,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listDailyStatsByDateRange = `-- name: ListDailyStatsByDateRange :many
 SELECT id, user_id, date, steps, distance, created_at, updated_at FROM googlefit_daily_stats
 WHERE user_id = $1 AND date BETWEEN $2 AND $3
@@ -283,6 +552,225 @@ func (q *Queries) ListDailyStatsByUser(ctx context.Context, arg ListDailyStatsBy
 	return items, nil
 }
 
+const listDataSourcesWithStats = `-- name: ListDataSourcesWithStats :many
+SELECT
+    s.data_source_id,
+    s.data_type,
+    s.type,
+    s.device_manufacturer,
+    s.device_model,
+    s.device_type,
+    s.application,
+    COUNT(d.id) AS days_with_data,
+    COALESCE(SUM(d.value), 0)::float AS total_value,
+    MAX(d.date)::date AS last_date,
+    (p.data_source_id IS NOT NULL)::bool AS preferred
+FROM googlefit_data_sources s
+    LEFT JOIN googlefit_source_daily_stats d
+        ON d.user_id = s.user_id AND d.data_source_id = s.data_source_id
+    LEFT JOIN googlefit_preferred_sources p
+        ON p.user_id = s.user_id AND p.data_type = s.data_type AND p.data_source_id = s.data_source_id
+WHERE s.user_id = $1
+GROUP BY s.id, p.data_source_id
+ORDER BY s.data_type, days_with_data DESC
+`
+
+type ListDataSourcesWithStatsRow struct {
+	DataSourceID       string
+	DataType           string
+	Type               string
+	DeviceManufacturer pgtype.Text
+	DeviceModel        pgtype.Text
+	DeviceType         pgtype.Text
+	Application        pgtype.Text
+	DaysWithData       int64
+	TotalValue         float64
+	LastDate           pgtype.Date
+	Preferred          bool
+}
+
+func (q *Queries) ListDataSourcesWithStats(ctx context.Context, userID pgtype.UUID) ([]ListDataSourcesWithStatsRow, error) {
+	rows, err := q.db.Query(ctx, listDataSourcesWithStats, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDataSourcesWithStatsRow
+	for rows.Next() {
+		var i ListDataSourcesWithStatsRow
+		if err := rows.Scan(
+			&i.DataSourceID,
+			&i.DataType,
+			&i.Type,
+			&i.DeviceManufacturer,
+			&i.DeviceModel,
+			&i.DeviceType,
+			&i.Application,
+			&i.DaysWithData,
+			&i.TotalValue,
+			&i.LastDate,
+			&i.Preferred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHourlyStatsByRange = `-- name: ListHourlyStatsByRange :many
+SELECT id, user_id, hour_start, steps, distance, calories, move_minutes, created_at, updated_at FROM googlefit_hourly_stats
+WHERE user_id = $1 AND hour_start >= $2 AND hour_start < $3
+ORDER BY hour_start ASC
+`
+
+type ListHourlyStatsByRangeParams struct {
+	UserID      pgtype.UUID
+	HourStart   pgtype.Timestamptz
+	HourStart_2 pgtype.Timestamptz
+}
+
+func (q *Queries) ListHourlyStatsByRange(ctx context.Context, arg ListHourlyStatsByRangeParams) ([]GooglefitHourlyStat, error) {
+	rows, err := q.db.Query(ctx, listHourlyStatsByRange, arg.UserID, arg.HourStart, arg.HourStart_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GooglefitHourlyStat
+	for rows.Next() {
+		var i GooglefitHourlyStat
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.HourStart,
+			&i.Steps,
+			&i.Distance,
+			&i.Calories,
+			&i.MoveMinutes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionsByRange = `-- name: ListSessionsByRange :many
+SELECT id, user_id, session_id, name, description, activity_type, application, start_time, end_time, steps, distance, calories, avg_bpm, max_bpm, created_at, updated_at FROM googlefit_sessions
+WHERE user_id = $1 AND start_time >= $2 AND start_time < $3
+ORDER BY start_time DESC
+`
+
+type ListSessionsByRangeParams struct {
+	UserID      pgtype.UUID
+	StartTime   pgtype.Timestamptz
+	StartTime_2 pgtype.Timestamptz
+}
+
+func (q *Queries) ListSessionsByRange(ctx context.Context, arg ListSessionsByRangeParams) ([]GooglefitSession, error) {
+	rows, err := q.db.Query(ctx, listSessionsByRange, arg.UserID, arg.StartTime, arg.StartTime_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GooglefitSession
+	for rows.Next() {
+		var i GooglefitSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.SessionID,
+			&i.Name,
+			&i.Description,
+			&i.ActivityType,
+			&i.Application,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Steps,
+			&i.Distance,
+			&i.Calories,
+			&i.AvgBpm,
+			&i.MaxBpm,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSleepSegmentsByRange = `-- name: ListSleepSegmentsByRange :many
+SELECT id, user_id, start_time, end_time, stage, created_at FROM googlefit_sleep_segments
+WHERE user_id = $1 AND end_time >= $2 AND start_time < $3
+ORDER BY start_time ASC
+`
+
+type ListSleepSegmentsByRangeParams struct {
+	UserID    pgtype.UUID
+	EndTime   pgtype.Timestamptz
+	StartTime pgtype.Timestamptz
+}
+
+func (q *Queries) ListSleepSegmentsByRange(ctx context.Context, arg ListSleepSegmentsByRangeParams) ([]GooglefitSleepSegment, error) {
+	rows, err := q.db.Query(ctx, listSleepSegmentsByRange, arg.UserID, arg.EndTime, arg.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GooglefitSleepSegment
+	for rows.Next() {
+		var i GooglefitSleepSegment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Stage,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPreferredSource = `-- name: SetPreferredSource :exec
+INSERT INTO googlefit_preferred_sources (user_id, data_type, data_source_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, data_type)
+DO UPDATE SET
+    data_source_id = EXCLUDED.data_source_id,
+    updated_at = now()
+`
+
+type SetPreferredSourceParams struct {
+	UserID       pgtype.UUID
+	DataType     string
+	DataSourceID string
+}
+
+func (q *Queries) SetPreferredSource(ctx context.Context, arg SetPreferredSourceParams) error {
+	_, err := q.db.Exec(ctx, setPreferredSource, arg.UserID, arg.DataType, arg.DataSourceID)
+	return err
+}
+
 const updateDailyStat = `-- name: UpdateDailyStat :one
 UPDATE googlefit_daily_stats
 SET steps = $2, distance = $3, updated_at = now()
@@ -311,6 +799,121 @@ func (q *Queries) UpdateDailyStat(ctx context.Context, arg UpdateDailyStatParams
 	return i, err
 }
 
+const upsertBodyMeasurement = `-- name: UpsertBodyMeasurement :exec
+
+INSERT INTO googlefit_body_measurements (user_id, metric, measured_at, value)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, metric, measured_at)
+DO UPDATE SET
+    value = EXCLUDED.value
+`
+
+type UpsertBodyMeasurementParams struct {
+	UserID     pgtype.UUID
+	Metric     string
+	MeasuredAt pgtype.Timestamptz
+	Value      float64
+}
+
+// Body Measurements Queries -------------------------------------------------------------------
+func (q *Queries) UpsertBodyMeasurement(ctx context.Context, arg UpsertBodyMeasurementParams) error {
+	_, err := q.db.Exec(ctx, upsertBodyMeasurement,
+		arg.UserID,
+		arg.Metric,
+		arg.MeasuredAt,
+		arg.Value,
+	)
+	return err
+}
+
+const upsertDailyActivity = `-- name: UpsertDailyActivity :one
+
+INSERT INTO googlefit_daily_activity (user_id, date, calories, move_minutes, heart_points)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, date)
+DO UPDATE SET
+    calories = EXCLUDED.calories,
+    move_minutes = EXCLUDED.move_minutes,
+    heart_points = EXCLUDED.heart_points,
+    updated_at = now()
+RETURNING id, user_id, date, calories, move_minutes, heart_points, created_at, updated_at
+`
+
+type UpsertDailyActivityParams struct {
+	UserID      pgtype.UUID
+	Date        pgtype.Date
+	Calories    pgtype.Float8
+	MoveMinutes pgtype.Int4
+	HeartPoints pgtype.Float8
+}
+
+// Activity Queries -------------------------------------------------------------------
+func (q *Queries) UpsertDailyActivity(ctx context.Context, arg UpsertDailyActivityParams) (GooglefitDailyActivity, error) {
+	row := q.db.QueryRow(ctx, upsertDailyActivity,
+		arg.UserID,
+		arg.Date,
+		arg.Calories,
+		arg.MoveMinutes,
+		arg.HeartPoints,
+	)
+	var i GooglefitDailyActivity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Date,
+		&i.Calories,
+		&i.MoveMinutes,
+		&i.HeartPoints,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertDailyHeartRate = `-- name: UpsertDailyHeartRate :one
+
+INSERT INTO googlefit_daily_heart_rate (user_id, date, min_bpm, avg_bpm, max_bpm)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, date)
+DO UPDATE SET
+    min_bpm = EXCLUDED.min_bpm,
+    avg_bpm = EXCLUDED.avg_bpm,
+    max_bpm = EXCLUDED.max_bpm,
+    updated_at = now()
+RETURNING id, user_id, date, min_bpm, avg_bpm, max_bpm, created_at, updated_at
+`
+
+type UpsertDailyHeartRateParams struct {
+	UserID pgtype.UUID
+	Date   pgtype.Date
+	MinBpm float64
+	AvgBpm float64
+	MaxBpm float64
+}
+
+// Heart Rate Queries -------------------------------------------------------------------
+func (q *Queries) UpsertDailyHeartRate(ctx context.Context, arg UpsertDailyHeartRateParams) (GooglefitDailyHeartRate, error) {
+	row := q.db.QueryRow(ctx, upsertDailyHeartRate,
+		arg.UserID,
+		arg.Date,
+		arg.MinBpm,
+		arg.AvgBpm,
+		arg.MaxBpm,
+	)
+	var i GooglefitDailyHeartRate
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Date,
+		&i.MinBpm,
+		&i.AvgBpm,
+		&i.MaxBpm,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const upsertDailyStat = `-- name: UpsertDailyStat :one
 INSERT INTO googlefit_daily_stats (user_id, date, steps, distance)
 VALUES ($1, $2, $3, $4)
@@ -348,3 +951,197 @@ func (q *Queries) UpsertDailyStat(ctx context.Context, arg UpsertDailyStatParams
 	)
 	return i, err
 }
+
+const upsertDataSource = `-- name: UpsertDataSource :exec
+
+INSERT INTO googlefit_data_sources (
+    user_id, data_source_id, data_type, type,
+    device_manufacturer, device_model, device_type, application
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (user_id, data_source_id)
+DO UPDATE SET
+    data_type = EXCLUDED.data_type,
+    type = EXCLUDED.type,
+    device_manufacturer = EXCLUDED.device_manufacturer,
+    device_model = EXCLUDED.device_model,
+    device_type = EXCLUDED.device_type,
+    application = EXCLUDED.application,
+    updated_at = now()
+`
+
+type UpsertDataSourceParams struct {
+	UserID             pgtype.UUID
+	DataSourceID       string
+	DataType           string
+	Type               string
+	DeviceManufacturer pgtype.Text
+	DeviceModel        pgtype.Text
+	DeviceType         pgtype.Text
+	Application        pgtype.Text
+}
+
+// Data Sources Queries -------------------------------------------------------------------
+func (q *Queries) UpsertDataSource(ctx context.Context, arg UpsertDataSourceParams) error {
+	_, err := q.db.Exec(ctx, upsertDataSource,
+		arg.UserID,
+		arg.DataSourceID,
+		arg.DataType,
+		arg.Type,
+		arg.DeviceManufacturer,
+		arg.DeviceModel,
+		arg.DeviceType,
+		arg.Application,
+	)
+	return err
+}
+
+const upsertHourlyStat = `-- name: UpsertHourlyStat :exec
+
+INSERT INTO googlefit_hourly_stats (user_id, hour_start, steps, distance, calories, move_minutes)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, hour_start)
+DO UPDATE SET
+    steps = EXCLUDED.steps,
+    distance = EXCLUDED.distance,
+    calories = EXCLUDED.calories,
+    move_minutes = EXCLUDED.move_minutes,
+    updated_at = now()
+`
+
+type UpsertHourlyStatParams struct {
+	UserID      pgtype.UUID
+	HourStart   pgtype.Timestamptz
+	Steps       pgtype.Int4
+	Distance    pgtype.Float8
+	Calories    pgtype.Float8
+	MoveMinutes pgtype.Int4
+}
+
+// Hourly Stats Queries -------------------------------------------------------------------
+func (q *Queries) UpsertHourlyStat(ctx context.Context, arg UpsertHourlyStatParams) error {
+	_, err := q.db.Exec(ctx, upsertHourlyStat,
+		arg.UserID,
+		arg.HourStart,
+		arg.Steps,
+		arg.Distance,
+		arg.Calories,
+		arg.MoveMinutes,
+	)
+	return err
+}
+
+const upsertSession = `-- name: UpsertSession :exec
+
+INSERT INTO googlefit_sessions (
+    user_id, session_id, name, description, activity_type, application,
+    start_time, end_time, steps, distance, calories, avg_bpm, max_bpm
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+ON CONFLICT (user_id, session_id)
+DO UPDATE SET
+    name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    activity_type = EXCLUDED.activity_type,
+    application = EXCLUDED.application,
+    start_time = EXCLUDED.start_time,
+    end_time = EXCLUDED.end_time,
+    steps = EXCLUDED.steps,
+    distance = EXCLUDED.distance,
+    calories = EXCLUDED.calories,
+    avg_bpm = EXCLUDED.avg_bpm,
+    max_bpm = EXCLUDED.max_bpm,
+    updated_at = now()
+`
+
+type UpsertSessionParams struct {
+	UserID       pgtype.UUID
+	SessionID    string
+	Name         pgtype.Text
+	Description  pgtype.Text
+	ActivityType int32
+	Application  pgtype.Text
+	StartTime    pgtype.Timestamptz
+	EndTime      pgtype.Timestamptz
+	Steps        pgtype.Int4
+	Distance     pgtype.Float8
+	Calories     pgtype.Float8
+	AvgBpm       pgtype.Float8
+	MaxBpm       pgtype.Float8
+}
+
+// Sessions Queries -------------------------------------------------------------------
+func (q *Queries) UpsertSession(ctx context.Context, arg UpsertSessionParams) error {
+	_, err := q.db.Exec(ctx, upsertSession,
+		arg.UserID,
+		arg.SessionID,
+		arg.Name,
+		arg.Description,
+		arg.ActivityType,
+		arg.Application,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Steps,
+		arg.Distance,
+		arg.Calories,
+		arg.AvgBpm,
+		arg.MaxBpm,
+	)
+	return err
+}
+
+const upsertSleepSegment = `-- name: UpsertSleepSegment :exec
+
+INSERT INTO googlefit_sleep_segments (user_id, start_time, end_time, stage)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, start_time)
+DO UPDATE SET
+    end_time = EXCLUDED.end_time,
+    stage = EXCLUDED.stage
+`
+
+type UpsertSleepSegmentParams struct {
+	UserID    pgtype.UUID
+	StartTime pgtype.Timestamptz
+	EndTime   pgtype.Timestamptz
+	Stage     int32
+}
+
+// Sleep Queries -------------------------------------------------------------------
+func (q *Queries) UpsertSleepSegment(ctx context.Context, arg UpsertSleepSegmentParams) error {
+	_, err := q.db.Exec(ctx, upsertSleepSegment,
+		arg.UserID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Stage,
+	)
+	return err
+}
+
+const upsertSourceDailyStat = `-- name: UpsertSourceDailyStat :exec
+INSERT INTO googlefit_source_daily_stats (user_id, data_source_id, data_type, date, value)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, data_source_id, date)
+DO UPDATE SET
+    value = EXCLUDED.value,
+    updated_at = now()
+`
+
+type UpsertSourceDailyStatParams struct {
+	UserID       pgtype.UUID
+	DataSourceID string
+	DataType     string
+	Date         pgtype.Date
+	Value        float64
+}
+
+func (q *Queries) UpsertSourceDailyStat(ctx context.Context, arg UpsertSourceDailyStatParams) error {
+	_, err := q.db.Exec(ctx, upsertSourceDailyStat,
+		arg.UserID,
+		arg.DataSourceID,
+		arg.DataType,
+		arg.Date,
+		arg.Value,
+	)
+	return err
+}
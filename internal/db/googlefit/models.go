@@ -8,6 +8,37 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type GooglefitBodyMeasurement struct {
+	ID         int64
+	UserID     pgtype.UUID
+	Metric     string
+	MeasuredAt pgtype.Timestamptz
+	Value      float64
+	CreatedAt  pgtype.Timestamptz
+}
+
+type GooglefitDailyActivity struct {
+	ID          int32
+	UserID      pgtype.UUID
+	Date        pgtype.Date
+	Calories    pgtype.Float8
+	MoveMinutes pgtype.Int4
+	HeartPoints pgtype.Float8
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type GooglefitDailyHeartRate struct {
+	ID        int32
+	UserID    pgtype.UUID
+	Date      pgtype.Date
+	MinBpm    float64
+	AvgBpm    float64
+	MaxBpm    float64
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
 type GooglefitDailyStat struct {
 	ID        int32
 	UserID    pgtype.UUID
@@ -17,3 +48,74 @@ type GooglefitDailyStat struct {
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
 }
+
+type GooglefitDataSource struct {
+	ID                 int32
+	UserID             pgtype.UUID
+	DataSourceID       string
+	DataType           string
+	Type               string
+	DeviceManufacturer pgtype.Text
+	DeviceModel        pgtype.Text
+	DeviceType         pgtype.Text
+	Application        pgtype.Text
+	CreatedAt          pgtype.Timestamptz
+	UpdatedAt          pgtype.Timestamptz
+}
+
+type GooglefitHourlyStat struct {
+	ID          int64
+	UserID      pgtype.UUID
+	HourStart   pgtype.Timestamptz
+	Steps       pgtype.Int4
+	Distance    pgtype.Float8
+	Calories    pgtype.Float8
+	MoveMinutes pgtype.Int4
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type GooglefitPreferredSource struct {
+	UserID       pgtype.UUID
+	DataType     string
+	DataSourceID string
+	UpdatedAt    pgtype.Timestamptz
+}
+
+type GooglefitSession struct {
+	ID           int64
+	UserID       pgtype.UUID
+	SessionID    string
+	Name         pgtype.Text
+	Description  pgtype.Text
+	ActivityType int32
+	Application  pgtype.Text
+	StartTime    pgtype.Timestamptz
+	EndTime      pgtype.Timestamptz
+	Steps        pgtype.Int4
+	Distance     pgtype.Float8
+	Calories     pgtype.Float8
+	AvgBpm       pgtype.Float8
+	MaxBpm       pgtype.Float8
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+}
+
+type GooglefitSleepSegment struct {
+	ID        int64
+	UserID    pgtype.UUID
+	StartTime pgtype.Timestamptz
+	EndTime   pgtype.Timestamptz
+	Stage     int32
+	CreatedAt pgtype.Timestamptz
+}
+
+type GooglefitSourceDailyStat struct {
+	ID           int64
+	UserID       pgtype.UUID
+	DataSourceID string
+	DataType     string
+	Date         pgtype.Date
+	Value        float64
+	UpdatedAt    pgtype.Timestamptz
+}
@@ -80,3 +80,20 @@ func (s *Store) ExecTxGoogleCalendar(ctx context.Context, fn func(*googlecalenda
 
 	return tx.Commit(ctx)
 }
+
+func (s *Store) ExecTxActivityWatch(ctx context.Context, fn func(*activitywatch_db.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	q := activitywatch_db.New(tx)
+
+	err = fn(q)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
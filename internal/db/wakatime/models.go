@@ -23,6 +23,7 @@ type WakatimeDay struct {
 	Text         pgtype.Text
 	CreatedAt    pgtype.Timestamptz
 	UpdatedAt    pgtype.Timestamptz
+	ContentHash  pgtype.Text
 }
 
 type WakatimeDependency struct {
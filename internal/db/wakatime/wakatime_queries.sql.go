@@ -13,9 +13,9 @@ import (
 
 const createDay = `-- name: CreateDay :one
 
-INSERT INTO wakatime_days (user_id, date, total_seconds, text)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, date, total_seconds, text, created_at, updated_at
+INSERT INTO wakatime_days (user_id, date, total_seconds, text, content_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, date, total_seconds, text, created_at, updated_at, content_hash
 `
 
 type CreateDayParams struct {
@@ -23,6 +23,7 @@ type CreateDayParams struct {
 	Date         pgtype.Date
 	TotalSeconds float64
 	Text         pgtype.Text
+	ContentHash  pgtype.Text
 }
 
 // Дни -------------------------------------------------------------------
@@ -32,6 +33,7 @@ func (q *Queries) CreateDay(ctx context.Context, arg CreateDayParams) (WakatimeD
 		arg.Date,
 		arg.TotalSeconds,
 		arg.Text,
+		arg.ContentHash,
 	)
 	var i WakatimeDay
 	err := row.Scan(
@@ -42,6 +44,7 @@ func (q *Queries) CreateDay(ctx context.Context, arg CreateDayParams) (WakatimeD
 		&i.Text,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ContentHash,
 	)
 	return i, err
 }
@@ -428,6 +431,96 @@ func (q *Queries) DeleteProjectsByDay(ctx context.Context, dayID int32) error {
 	return err
 }
 
+const deleteStaleDependencies = `-- name: DeleteStaleDependencies :exec
+DELETE FROM wakatime_dependencies
+WHERE day_id = $1 AND name <> ALL($2::text[])
+`
+
+type DeleteStaleDependenciesParams struct {
+	DayID int32
+	Names []string
+}
+
+func (q *Queries) DeleteStaleDependencies(ctx context.Context, arg DeleteStaleDependenciesParams) error {
+	_, err := q.db.Exec(ctx, deleteStaleDependencies, arg.DayID, arg.Names)
+	return err
+}
+
+const deleteStaleEditors = `-- name: DeleteStaleEditors :exec
+DELETE FROM wakatime_editors
+WHERE day_id = $1 AND name <> ALL($2::text[])
+`
+
+type DeleteStaleEditorsParams struct {
+	DayID int32
+	Names []string
+}
+
+func (q *Queries) DeleteStaleEditors(ctx context.Context, arg DeleteStaleEditorsParams) error {
+	_, err := q.db.Exec(ctx, deleteStaleEditors, arg.DayID, arg.Names)
+	return err
+}
+
+const deleteStaleLanguages = `-- name: DeleteStaleLanguages :exec
+DELETE FROM wakatime_languages
+WHERE day_id = $1 AND name <> ALL($2::text[])
+`
+
+type DeleteStaleLanguagesParams struct {
+	DayID int32
+	Names []string
+}
+
+func (q *Queries) DeleteStaleLanguages(ctx context.Context, arg DeleteStaleLanguagesParams) error {
+	_, err := q.db.Exec(ctx, deleteStaleLanguages, arg.DayID, arg.Names)
+	return err
+}
+
+const deleteStaleMachines = `-- name: DeleteStaleMachines :exec
+DELETE FROM wakatime_machines
+WHERE day_id = $1 AND name <> ALL($2::text[])
+`
+
+type DeleteStaleMachinesParams struct {
+	DayID int32
+	Names []string
+}
+
+func (q *Queries) DeleteStaleMachines(ctx context.Context, arg DeleteStaleMachinesParams) error {
+	_, err := q.db.Exec(ctx, deleteStaleMachines, arg.DayID, arg.Names)
+	return err
+}
+
+const deleteStaleOS = `-- name: DeleteStaleOS :exec
+DELETE FROM wakatime_os
+WHERE day_id = $1 AND name <> ALL($2::text[])
+`
+
+type DeleteStaleOSParams struct {
+	DayID int32
+	Names []string
+}
+
+func (q *Queries) DeleteStaleOS(ctx context.Context, arg DeleteStaleOSParams) error {
+	_, err := q.db.Exec(ctx, deleteStaleOS, arg.DayID, arg.Names)
+	return err
+}
+
+const deleteStaleProjects = `-- name: DeleteStaleProjects :exec
+DELETE FROM wakatime_projects
+WHERE day_id = $1 AND name <> ALL($2::text[])
+`
+
+type DeleteStaleProjectsParams struct {
+	DayID int32
+	Names []string
+}
+
+func (q *Queries) DeleteStaleProjects(ctx context.Context, arg DeleteStaleProjectsParams) error {
+	_, err := q.db.Exec(ctx, deleteStaleProjects, arg.DayID, arg.Names)
+	return err
+}
+
 const deleteSummary = `-- name: DeleteSummary :exec
 DELETE FROM wakatime_summaries WHERE id = $1
 `
@@ -438,7 +531,7 @@ func (q *Queries) DeleteSummary(ctx context.Context, id int32) error {
 }
 
 const getDayByDate = `-- name: GetDayByDate :one
-SELECT id, user_id, date, total_seconds, text, created_at, updated_at FROM wakatime_days WHERE user_id = $1 AND date = $2
+SELECT id, user_id, date, total_seconds, text, created_at, updated_at, content_hash FROM wakatime_days WHERE user_id = $1 AND date = $2
 `
 
 type GetDayByDateParams struct {
@@ -457,12 +550,13 @@ func (q *Queries) GetDayByDate(ctx context.Context, arg GetDayByDateParams) (Wak
 		&i.Text,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ContentHash,
 	)
 	return i, err
 }
 
 const getDayByID = `-- name: GetDayByID :one
-SELECT id, user_id, date, total_seconds, text, created_at, updated_at FROM wakatime_days WHERE id = $1
+SELECT id, user_id, date, total_seconds, text, created_at, updated_at, content_hash FROM wakatime_days WHERE id = $1
 `
 
 func (q *Queries) GetDayByID(ctx context.Context, id int32) (WakatimeDay, error) {
@@ -476,12 +570,13 @@ func (q *Queries) GetDayByID(ctx context.Context, id int32) (WakatimeDay, error)
 		&i.Text,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ContentHash,
 	)
 	return i, err
 }
 
 const getDaysByDateRange = `-- name: GetDaysByDateRange :many
-SELECT id, user_id, date, total_seconds, text, created_at, updated_at FROM wakatime_days
+SELECT id, user_id, date, total_seconds, text, created_at, updated_at, content_hash FROM wakatime_days
 WHERE user_id = $1 AND date BETWEEN $2 AND $3
 ORDER BY date DESC
 `
@@ -509,6 +604,7 @@ func (q *Queries) GetDaysByDateRange(ctx context.Context, arg GetDaysByDateRange
 			&i.Text,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ContentHash,
 		); err != nil {
 			return nil, err
 		}
@@ -539,6 +635,62 @@ func (q *Queries) GetProjectByID(ctx context.Context, id int32) (WakatimeProject
 	return i, err
 }
 
+const getProjectsByDateRange = `-- name: GetProjectsByDateRange :many
+SELECT
+    d.date,
+    d.total_seconds as day_seconds,
+    p.name,
+    p.total_seconds
+FROM
+    wakatime_days d
+    INNER JOIN
+    wakatime_projects p ON d.id = p.day_id
+WHERE
+    d.user_id = $1
+  AND d.date >= $2
+  AND d.date <= $3
+ORDER BY
+    d.date ASC, p.total_seconds DESC
+`
+
+type GetProjectsByDateRangeParams struct {
+	UserID pgtype.UUID
+	Date   pgtype.Date
+	Date_2 pgtype.Date
+}
+
+type GetProjectsByDateRangeRow struct {
+	Date         pgtype.Date
+	DaySeconds   float64
+	Name         string
+	TotalSeconds float64
+}
+
+func (q *Queries) GetProjectsByDateRange(ctx context.Context, arg GetProjectsByDateRangeParams) ([]GetProjectsByDateRangeRow, error) {
+	rows, err := q.db.Query(ctx, getProjectsByDateRange, arg.UserID, arg.Date, arg.Date_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProjectsByDateRangeRow
+	for rows.Next() {
+		var i GetProjectsByDateRangeRow
+		if err := rows.Scan(
+			&i.Date,
+			&i.DaySeconds,
+			&i.Name,
+			&i.TotalSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSummaryByID = `-- name: GetSummaryByID :one
 SELECT id, user_id, start_time, end_time, range, total_seconds, daily_average, best_day_id, created_at FROM wakatime_summaries WHERE id = $1
 `
@@ -743,7 +895,7 @@ func (q *Queries) GetWakatimeStatsByDateRange(ctx context.Context, arg GetWakati
 }
 
 const listDaysByUser = `-- name: ListDaysByUser :many
-SELECT id, user_id, date, total_seconds, text, created_at, updated_at FROM wakatime_days WHERE user_id = $1 ORDER BY date DESC
+SELECT id, user_id, date, total_seconds, text, created_at, updated_at, content_hash FROM wakatime_days WHERE user_id = $1 ORDER BY date DESC
 `
 
 func (q *Queries) ListDaysByUser(ctx context.Context, userID pgtype.UUID) ([]WakatimeDay, error) {
@@ -763,6 +915,7 @@ func (q *Queries) ListDaysByUser(ctx context.Context, userID pgtype.UUID) ([]Wak
 			&i.Text,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ContentHash,
 		); err != nil {
 			return nil, err
 		}
@@ -1002,19 +1155,25 @@ func (q *Queries) ListSummariesByUser(ctx context.Context, userID pgtype.UUID) (
 
 const updateDay = `-- name: UpdateDay :one
 UPDATE wakatime_days
-SET total_seconds = $2, text = $3, updated_at = now()
+SET total_seconds = $2, text = $3, content_hash = $4, updated_at = now()
 WHERE id = $1
-RETURNING id, user_id, date, total_seconds, text, created_at, updated_at
+RETURNING id, user_id, date, total_seconds, text, created_at, updated_at, content_hash
 `
 
 type UpdateDayParams struct {
 	ID           int32
 	TotalSeconds float64
 	Text         pgtype.Text
+	ContentHash  pgtype.Text
 }
 
 func (q *Queries) UpdateDay(ctx context.Context, arg UpdateDayParams) (WakatimeDay, error) {
-	row := q.db.QueryRow(ctx, updateDay, arg.ID, arg.TotalSeconds, arg.Text)
+	row := q.db.QueryRow(ctx, updateDay,
+		arg.ID,
+		arg.TotalSeconds,
+		arg.Text,
+		arg.ContentHash,
+	)
 	var i WakatimeDay
 	err := row.Scan(
 		&i.ID,
@@ -1024,6 +1183,7 @@ func (q *Queries) UpdateDay(ctx context.Context, arg UpdateDayParams) (WakatimeD
 		&i.Text,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ContentHash,
 	)
 	return i, err
 }
@@ -0,0 +1,118 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Максимальная длина строки без переноса (RFC 5545, 3.1)
+const maxLineLength = 75
+
+// Encode записывает календарь в формате iCalendar. Время событий записывается в UTC,
+// поэтому VTIMEZONE не нужен; события на весь день записываются датами.
+// prodID попадает в PRODID, now - в DTSTAMP всех событий
+func Encode(w io.Writer, cal *Calendar, prodID string, now time.Time) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", prodID)
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		e.line("X-WR-CALNAME", escapeText(cal.Name))
+	}
+	if cal.TimeZone != "" {
+		e.line("X-WR-TIMEZONE", cal.TimeZone)
+	}
+
+	stamp := formatUTC(now)
+	for _, event := range cal.Events {
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", event.UID)
+		e.line("DTSTAMP", stamp)
+		if event.AllDay {
+			e.line("DTSTART;VALUE=DATE", event.Start.Format("20060102"))
+			e.line("DTEND;VALUE=DATE", event.End.Format("20060102"))
+		} else {
+			e.line("DTSTART", formatUTC(event.Start))
+			e.line("DTEND", formatUTC(event.End))
+		}
+		if !event.RecurrenceID.IsZero() {
+			e.line("RECURRENCE-ID", formatUTC(event.RecurrenceID))
+		}
+		e.text("SUMMARY", event.Summary)
+		e.text("DESCRIPTION", event.Description)
+		e.text("LOCATION", event.Location)
+		if event.Status != "" {
+			e.line("STATUS", strings.ToUpper(event.Status))
+		}
+		if event.Transparency != "" {
+			e.line("TRANSP", strings.ToUpper(event.Transparency))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeText(category)
+			}
+			e.line("CATEGORIES", strings.Join(categories, ","))
+		}
+		e.line("END", "VEVENT")
+	}
+
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// text записывает свойство типа TEXT, пустые значения пропускаются
+func (e *encoder) text(name, value string) {
+	if value != "" {
+		e.line(name, escapeText(value))
+	}
+}
+
+// line записывает свойство, перенося строки длиннее 75 байт (не разрывая символы UTF-8)
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+
+	s := name + ":" + value
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		_, e.err = e.w.WriteString(s[:cut] + "\r\n ")
+		if e.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Строка продолжения начинается с пробела
+		limit = maxLineLength - 1
+	}
+	_, e.err = e.w.WriteString(s + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,389 @@
+// Package ical разбирает и записывает календари в формате iCalendar (RFC 5545)
+// и разворачивает повторяющиеся события в отдельные экземпляры.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calendar содержимое VCALENDAR
+type Calendar struct {
+	Name     string // X-WR-CALNAME
+	TimeZone string // X-WR-TIMEZONE
+	Events   []Event
+}
+
+// Event событие VEVENT. Время хранится в часовом поясе из TZID,
+// события на весь день - полночью UTC
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Status       string // confirmed, tentative, cancelled
+	Transparency string // opaque, transparent
+	Categories   []string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Organizer    *Attendee
+	Attendees    []Attendee
+
+	// Повторения: RRULE, дополнительные и исключённые даты.
+	// RecurrenceID задан у изменённого экземпляра серии
+	RRule        string
+	RDates       []time.Time
+	ExDates      []time.Time
+	RecurrenceID time.Time
+}
+
+// Attendee участник или организатор события
+type Attendee struct {
+	Email    string
+	Name     string
+	PartStat string // accepted, declined, tentative, needsAction
+	Optional bool
+	Resource bool
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse читает календарь из r. Время без часового пояса (floating) и TZID,
+// которые не удалось распознать, считаются в loc
+func Parse(r io.Reader, loc *time.Location) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{loc: loc}
+	cal := &Calendar{}
+
+	var stack []string
+	var current *Event
+	var duration time.Duration
+	var hasEnd bool
+
+	for _, line := range lines {
+		prop, err := parseProperty(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			component := strings.ToUpper(prop.value)
+			if component == "VEVENT" && len(stack) == 1 {
+				current = &Event{}
+				duration, hasEnd = 0, false
+			}
+			stack = append(stack, component)
+			continue
+		case "END":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unexpected END:%s", prop.value)
+			}
+			stack = stack[:len(stack)-1]
+			if strings.ToUpper(prop.value) == "VEVENT" && current != nil && len(stack) == 1 {
+				if !hasEnd {
+					current.End = current.Start.Add(duration)
+					if duration == 0 && current.AllDay {
+						current.End = current.Start.AddDate(0, 0, 1)
+					}
+				}
+				if current.UID != "" && !current.Start.IsZero() {
+					cal.Events = append(cal.Events, *current)
+				}
+				current = nil
+			}
+			continue
+		}
+
+		if len(stack) == 1 && stack[0] == "VCALENDAR" {
+			switch prop.name {
+			case "X-WR-CALNAME":
+				cal.Name = unescapeText(prop.value)
+			case "X-WR-TIMEZONE":
+				cal.TimeZone = prop.value
+				if l, ok := lookupLocation(prop.value); ok {
+					p.loc = l
+				}
+			}
+			continue
+		}
+
+		// Свойства вложенных компонентов (VALARM и т.п.) пропускаются
+		if current == nil || len(stack) != 2 || stack[1] != "VEVENT" {
+			continue
+		}
+
+		switch prop.name {
+		case "UID":
+			current.UID = prop.value
+		case "SUMMARY":
+			current.Summary = unescapeText(prop.value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(prop.value)
+		case "LOCATION":
+			current.Location = unescapeText(prop.value)
+		case "STATUS":
+			current.Status = strings.ToLower(prop.value)
+		case "TRANSP":
+			current.Transparency = strings.ToLower(prop.value)
+		case "CATEGORIES":
+			for _, category := range splitText(prop.value) {
+				current.Categories = append(current.Categories, unescapeText(category))
+			}
+		case "DTSTART":
+			current.Start, current.AllDay, err = p.parseTime(prop)
+		case "DTEND":
+			current.End, _, err = p.parseTime(prop)
+			hasEnd = true
+		case "DURATION":
+			duration, err = parseDuration(prop.value)
+		case "RRULE":
+			current.RRule = prop.value
+		case "RDATE":
+			var dates []time.Time
+			dates, err = p.parseTimeList(prop)
+			current.RDates = append(current.RDates, dates...)
+		case "EXDATE":
+			var dates []time.Time
+			dates, err = p.parseTimeList(prop)
+			current.ExDates = append(current.ExDates, dates...)
+		case "RECURRENCE-ID":
+			current.RecurrenceID, _, err = p.parseTime(prop)
+		case "ORGANIZER":
+			organizer := parseAttendee(prop)
+			current.Organizer = &organizer
+		case "ATTENDEE":
+			current.Attendees = append(current.Attendees, parseAttendee(prop))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %s: %w", current.UID, prop.name, err)
+		}
+	}
+
+	return cal, nil
+}
+
+// unfold читает строки, склеивая перенесённые (начинающиеся с пробела или табуляции)
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// parseProperty разбирает строку вида NAME;PARAM=value;PARAM="quoted":value
+func parseProperty(line string) (property, error) {
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, fmt.Errorf("invalid content line: %q", line)
+	}
+
+	prop := property{params: map[string]string{}, value: line[colon+1:]}
+	parts := splitUnquoted(line[:colon], ';')
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// splitText делит список значений TEXT по запятым, кроме экранированных
+func splitText(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+func parseAttendee(prop property) Attendee {
+	email := prop.value
+	if len(email) > 7 && strings.EqualFold(email[:7], "mailto:") {
+		email = email[7:]
+	}
+
+	attendee := Attendee{
+		Email:    strings.ToLower(email),
+		Name:     prop.params["CN"],
+		Optional: prop.params["ROLE"] == "OPT-PARTICIPANT" || prop.params["ROLE"] == "NON-PARTICIPANT",
+		Resource: prop.params["CUTYPE"] == "RESOURCE" || prop.params["CUTYPE"] == "ROOM",
+	}
+
+	// Приводим PARTSTAT к значениям responseStatus Google Calendar
+	switch prop.params["PARTSTAT"] {
+	case "ACCEPTED":
+		attendee.PartStat = "accepted"
+	case "DECLINED":
+		attendee.PartStat = "declined"
+	case "TENTATIVE":
+		attendee.PartStat = "tentative"
+	case "NEEDS-ACTION":
+		attendee.PartStat = "needsAction"
+	}
+	return attendee
+}
+
+type parser struct {
+	loc *time.Location
+}
+
+// parseTime разбирает DATE или DATE-TIME с учётом VALUE и TZID
+func (p *parser) parseTime(prop property) (time.Time, bool, error) {
+	return p.parseValue(prop.value, prop.params)
+}
+
+func (p *parser) parseTimeList(prop property) ([]time.Time, error) {
+	var times []time.Time
+	for _, value := range strings.Split(prop.value, ",") {
+		if prop.params["VALUE"] == "PERIOD" {
+			value, _, _ = strings.Cut(value, "/")
+		}
+		t, _, err := p.parseValue(value, prop.params)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+func (p *parser) parseValue(value string, params map[string]string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.Parse("20060102", value)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	loc := p.loc
+	if tzid := params["TZID"]; tzid != "" {
+		if l, ok := lookupLocation(tzid); ok {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// parseDuration разбирает длительность вида [+-]P1W или [+-]P1DT2H30M15S
+func parseDuration(value string) (time.Duration, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+	s = strings.TrimLeft(s, "+-")
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	s = s[1:]
+
+	var total time.Duration
+	inTime := false
+	num := ""
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			num += string(c)
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		}
+
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		num = ""
+
+		switch {
+		case c == 'W':
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case c == 'D':
+			total += time.Duration(n) * 24 * time.Hour
+		case c == 'H' && inTime:
+			total += time.Duration(n) * time.Hour
+		case c == 'M' && inTime:
+			total += time.Duration(n) * time.Minute
+		case c == 'S' && inTime:
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	return sign * total, nil
+}
@@ -0,0 +1,307 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// calendar собирает VCALENDAR из строк с переводами строк CRLF, как их отдают серверы
+func calendar(lines ...string) string {
+	all := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...)
+	all = append(all, "END:VCALENDAR")
+	return strings.Join(all, "\r\n") + "\r\n"
+}
+
+func mustParse(t *testing.T, data string, loc *time.Location) *Calendar {
+	t.Helper()
+	cal, err := Parse(strings.NewReader(data), loc)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return cal
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s is not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestUnfold(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "space continuation",
+			data: "SUMMARY:Long\r\n  summary\r\n",
+			want: []string{"SUMMARY:Long summary"},
+		},
+		{
+			name: "tab continuation",
+			data: "DESCRIPTION:a\r\n\tb\r\n\tc\r\n",
+			want: []string{"DESCRIPTION:abc"},
+		},
+		{
+			name: "LF only and empty lines",
+			data: "UID:1\n\nSUMMARY:x\n",
+			want: []string{"UID:1", "SUMMARY:x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unfold(strings.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("unfold: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("unfold = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProperty(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   string
+		value  string
+		params map[string]string
+	}{
+		{
+			name:  "no params",
+			line:  "summary:Standup",
+			want:  "SUMMARY",
+			value: "Standup",
+		},
+		{
+			name:   "params",
+			line:   "DTSTART;TZID=Europe/Moscow;VALUE=DATE-TIME:20261019T100000",
+			want:   "DTSTART",
+			value:  "20261019T100000",
+			params: map[string]string{"TZID": "Europe/Moscow", "VALUE": "DATE-TIME"},
+		},
+		{
+			name:   "quoted param with separators",
+			line:   `ATTENDEE;CN="Doe; John: Jr";PARTSTAT=ACCEPTED:mailto:john@example.com`,
+			want:   "ATTENDEE",
+			value:  "mailto:john@example.com",
+			params: map[string]string{"CN": "Doe; John: Jr", "PARTSTAT": "ACCEPTED"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prop, err := parseProperty(tt.line)
+			if err != nil {
+				t.Fatalf("parseProperty: %v", err)
+			}
+			if prop.name != tt.want || prop.value != tt.value {
+				t.Errorf("parseProperty = %s:%s, want %s:%s", prop.name, prop.value, tt.want, tt.value)
+			}
+			for key, value := range tt.params {
+				if prop.params[key] != value {
+					t.Errorf("param %s = %q, want %q", key, prop.params[key], value)
+				}
+			}
+		})
+	}
+
+	if _, err := parseProperty("NO COLON"); err == nil {
+		t.Error("parseProperty without colon: expected error")
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	data := calendar(
+		"X-WR-CALNAME:Work",
+		"BEGIN:VEVENT",
+		"UID:event-1",
+		"SUMMARY:Planning\\, Q4",
+		"DESCRIPTION:Line one\\nLine two",
+		"CATEGORIES:Work,Meeting\\,Big",
+		"STATUS:CONFIRMED",
+		"DTSTART:20261019T100000Z",
+		"DTEND:20261019T110000Z",
+		"ORGANIZER;CN=Boss:MAILTO:Boss@Example.com",
+		"ATTENDEE;PARTSTAT=DECLINED;ROLE=OPT-PARTICIPANT:mailto:a@example.com",
+		"BEGIN:VALARM",
+		"DESCRIPTION:Reminder",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:No UID is skipped",
+		"DTSTART:20261019T100000Z",
+		"END:VEVENT",
+	)
+	cal := mustParse(t, data, time.UTC)
+
+	if cal.Name != "Work" {
+		t.Errorf("Name = %q, want Work", cal.Name)
+	}
+	if len(cal.Events) != 1 {
+		t.Fatalf("got %d events, want 1", len(cal.Events))
+	}
+	e := cal.Events[0]
+	if e.Summary != "Planning, Q4" {
+		t.Errorf("Summary = %q", e.Summary)
+	}
+	if e.Description != "Line one\nLine two" {
+		t.Errorf("Description = %q (VALARM must not override it)", e.Description)
+	}
+	if strings.Join(e.Categories, "|") != "Work|Meeting,Big" {
+		t.Errorf("Categories = %q", e.Categories)
+	}
+	if e.Status != "confirmed" {
+		t.Errorf("Status = %q", e.Status)
+	}
+	if e.Organizer == nil || e.Organizer.Email != "boss@example.com" || e.Organizer.Name != "Boss" {
+		t.Errorf("Organizer = %+v", e.Organizer)
+	}
+	if len(e.Attendees) != 1 || e.Attendees[0].PartStat != "declined" || !e.Attendees[0].Optional {
+		t.Errorf("Attendees = %+v", e.Attendees)
+	}
+}
+
+func TestParseTimes(t *testing.T) {
+	moscow := mustLocation(t, "Europe/Moscow")
+	newYork := mustLocation(t, "America/New_York")
+
+	tests := []struct {
+		name      string
+		lines     []string
+		loc       *time.Location
+		wantStart time.Time
+		wantEnd   time.Time
+		allDay    bool
+	}{
+		{
+			name:      "UTC",
+			lines:     []string{"DTSTART:20261019T100000Z", "DTEND:20261019T110000Z"},
+			loc:       moscow,
+			wantStart: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "TZID",
+			lines:     []string{"DTSTART;TZID=America/New_York:20261019T090000", "DTEND;TZID=America/New_York:20261019T100000"},
+			loc:       moscow,
+			wantStart: time.Date(2026, 10, 19, 9, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2026, 10, 19, 10, 0, 0, 0, newYork),
+		},
+		{
+			name:      "Windows TZID",
+			lines:     []string{`DTSTART;TZID="Eastern Standard Time":20261019T090000`, "DURATION:PT30M"},
+			loc:       moscow,
+			wantStart: time.Date(2026, 10, 19, 9, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2026, 10, 19, 9, 30, 0, 0, newYork),
+		},
+		{
+			name:      "unknown TZID falls back to default location",
+			lines:     []string{"DTSTART;TZID=Nowhere/Unknown:20261019T090000", "DTEND;TZID=Nowhere/Unknown:20261019T100000"},
+			loc:       moscow,
+			wantStart: time.Date(2026, 10, 19, 9, 0, 0, 0, moscow),
+			wantEnd:   time.Date(2026, 10, 19, 10, 0, 0, 0, moscow),
+		},
+		{
+			name:      "floating",
+			lines:     []string{"DTSTART:20261019T090000", "DTEND:20261019T100000"},
+			loc:       moscow,
+			wantStart: time.Date(2026, 10, 19, 9, 0, 0, 0, moscow),
+			wantEnd:   time.Date(2026, 10, 19, 10, 0, 0, 0, moscow),
+		},
+		{
+			name:      "all day without end",
+			lines:     []string{"DTSTART;VALUE=DATE:20261019"},
+			loc:       moscow,
+			wantStart: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+			allDay:    true,
+		},
+		{
+			name:      "duration with days and time",
+			lines:     []string{"DTSTART:20261019T100000Z", "DURATION:P1DT2H30M15S"},
+			loc:       time.UTC,
+			wantStart: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 10, 20, 12, 30, 15, 0, time.UTC),
+		},
+		{
+			name:      "duration in weeks",
+			lines:     []string{"DTSTART;VALUE=DATE:20261019", "DURATION:P1W"},
+			loc:       time.UTC,
+			wantStart: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC),
+			allDay:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := append([]string{"BEGIN:VEVENT", "UID:1"}, tt.lines...)
+			cal := mustParse(t, calendar(append(lines, "END:VEVENT")...), tt.loc)
+			if len(cal.Events) != 1 {
+				t.Fatalf("got %d events, want 1", len(cal.Events))
+			}
+			e := cal.Events[0]
+			if !e.Start.Equal(tt.wantStart) || !e.End.Equal(tt.wantEnd) {
+				t.Errorf("got %s - %s, want %s - %s", e.Start, e.End, tt.wantStart, tt.wantEnd)
+			}
+			if e.AllDay != tt.allDay {
+				t.Errorf("AllDay = %v, want %v", e.AllDay, tt.allDay)
+			}
+		})
+	}
+}
+
+func TestParseCalendarTimeZone(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	data := calendar(
+		"X-WR-TIMEZONE:Europe/Berlin",
+		"BEGIN:VEVENT",
+		"UID:1",
+		"DTSTART:20261019T090000",
+		"END:VEVENT",
+	)
+	cal := mustParse(t, data, time.UTC)
+	if cal.TimeZone != "Europe/Berlin" {
+		t.Errorf("TimeZone = %q", cal.TimeZone)
+	}
+	if want := time.Date(2026, 10, 19, 9, 0, 0, 0, berlin); !cal.Events[0].Start.Equal(want) {
+		t.Errorf("floating start = %s, want %s", cal.Events[0].Start, want)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "PT15M", want: 15 * time.Minute},
+		{value: "P2D", want: 48 * time.Hour},
+		{value: "-PT1H", want: -time.Hour},
+		{value: "+P1W", want: 7 * 24 * time.Hour},
+		{value: "1H", wantErr: true},
+		{value: "P1H", wantErr: true},
+		{value: "PT5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDuration(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDuration(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
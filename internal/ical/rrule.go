@@ -0,0 +1,453 @@
+package ical
+
+import (
+	"DataLake/internal/logger"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Предел числа экземпляров одной серии в окне, чтобы ошибочное правило не разворачивалось бесконечно
+const maxOccurrences = 5000
+
+// Occurrence отдельный экземпляр события в окне разворачивания
+type Occurrence struct {
+	Event
+
+	// InstanceID совпадает с UID для одиночных событий, для экземпляров серии - UID_<время начала>
+	InstanceID string
+	// RecurringUID UID серии, пусто для одиночных событий
+	RecurringUID string
+}
+
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+type rrule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []int
+	bySetPos   []int
+	wkst       time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Expand разворачивает события в экземпляры, пересекающиеся с окном [from, to).
+// Учитываются RRULE, RDATE, EXDATE и изменённые экземпляры (RECURRENCE-ID).
+// Серии с неподдерживаемым правилом пропускаются с предупреждением в лог, остальные события разворачиваются
+func Expand(events []Event, from, to time.Time) []Occurrence {
+	log := logger.Get()
+
+	overrides := make(map[string]map[string]bool)
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			if overrides[e.UID] == nil {
+				overrides[e.UID] = make(map[string]bool)
+			}
+			overrides[e.UID][instanceSuffix(e.RecurrenceID, e.AllDay)] = true
+		}
+	}
+
+	var result []Occurrence
+	for _, e := range events {
+		if !e.RecurrenceID.IsZero() {
+			if overlaps(e.Start, e.End, from, to) {
+				result = append(result, Occurrence{
+					Event:        e,
+					InstanceID:   e.UID + "_" + instanceSuffix(e.RecurrenceID, e.AllDay),
+					RecurringUID: e.UID,
+				})
+			}
+			continue
+		}
+
+		if e.RRule == "" && len(e.RDates) == 0 {
+			if overlaps(e.Start, e.End, from, to) {
+				result = append(result, Occurrence{Event: e, InstanceID: e.UID})
+			}
+			continue
+		}
+
+		length := e.End.Sub(e.Start)
+		starts := []time.Time{e.Start}
+		if e.RRule != "" {
+			rule, err := parseRRule(e.RRule, e.Start.Location())
+			if err != nil {
+				log.Warn().Err(err).Str("uid", e.UID).Str("rrule", e.RRule).Msg("skipping event with unsupported recurrence rule")
+				continue
+			}
+			// Экземпляры, начавшиеся до окна, но ещё идущие в нём, тоже нужны
+			starts = rule.occurrences(e.Start, from.Add(-length), to)
+		}
+		starts = append(starts, e.RDates...)
+
+		excluded := make(map[string]bool, len(e.ExDates))
+		for _, ex := range e.ExDates {
+			excluded[instanceSuffix(ex, e.AllDay)] = true
+		}
+
+		seen := make(map[string]bool, len(starts))
+		for _, start := range starts {
+			suffix := instanceSuffix(start, e.AllDay)
+			if seen[suffix] || excluded[suffix] || overrides[e.UID][suffix] {
+				continue
+			}
+			seen[suffix] = true
+
+			end := start.Add(length)
+			if e.AllDay {
+				end = start.AddDate(0, 0, int(length.Hours()/24))
+			}
+			if !overlaps(start, end, from, to) {
+				continue
+			}
+
+			occurrence := Occurrence{Event: e, InstanceID: e.UID + "_" + suffix, RecurringUID: e.UID}
+			occurrence.Start, occurrence.End = start, end
+			result = append(result, occurrence)
+		}
+	}
+
+	return result
+}
+
+// overlaps проверяет пересечение события с окном; события нулевой длины - по времени начала
+func overlaps(start, end, from, to time.Time) bool {
+	if !end.After(start) {
+		return !start.Before(from) && start.Before(to)
+	}
+	return start.Before(to) && end.After(from)
+}
+
+// instanceSuffix форматирует время начала экземпляра так же, как Google Calendar в id экземпляров
+func instanceSuffix(t time.Time, allDay bool) string {
+	if allDay {
+		return t.Format("20060102")
+	}
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func parseRRule(value string, loc *time.Location) (*rrule, error) {
+	r := &rrule{interval: 1, wkst: time.Monday}
+
+	for _, part := range strings.Split(strings.TrimPrefix(value, "RRULE:"), ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(val)
+		case "COUNT":
+			r.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			p := &parser{loc: loc}
+			var dateOnly bool
+			r.until, dateOnly, err = p.parseValue(val, nil)
+			// UNTIL в виде даты включает весь день
+			if dateOnly && err == nil {
+				r.until = r.until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				d = strings.ToUpper(strings.TrimSpace(d))
+				if len(d) < 2 {
+					return nil, fmt.Errorf("invalid BYDAY %q", val)
+				}
+				day, ok := weekdays[d[len(d)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %q", val)
+				}
+				n := 0
+				if prefix := d[:len(d)-2]; prefix != "" {
+					if n, err = strconv.Atoi(prefix); err != nil {
+						return nil, fmt.Errorf("invalid BYDAY %q", val)
+					}
+				}
+				r.byDay = append(r.byDay, weekdayNum{n: n, day: day})
+			}
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseInts(val)
+		case "BYMONTH":
+			r.byMonth, err = parseInts(val)
+		case "BYSETPOS":
+			r.bySetPos, err = parseInts(val)
+		case "WKST":
+			if day, ok := weekdays[strings.ToUpper(val)]; ok {
+				r.wkst = day
+			}
+		default:
+			// BYHOUR, BYMINUTE, BYSECOND, BYWEEKNO, BYYEARDAY и прочие части не поддерживаются:
+			// молча пропустить их значит развернуть серию неверно
+			if strings.HasPrefix(strings.ToUpper(key), "BY") {
+				return nil, fmt.Errorf("unsupported RRULE part %s", strings.ToUpper(key))
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid RRULE %s: %w", key, err)
+		}
+	}
+
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported RRULE FREQ %q", r.freq)
+	}
+	if r.interval < 1 {
+		r.interval = 1
+	}
+	return r, nil
+}
+
+func parseInts(value string) ([]int, error) {
+	var result []int
+	for _, s := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+// occurrences возвращает начала экземпляров серии в [from, to) с учётом COUNT и UNTIL.
+// COUNT отсчитывается от dtstart, поэтому с ним серия перебирается с начала;
+// без COUNT перебор начинается с периода, предшествующего from
+func (r *rrule) occurrences(dtstart, from, to time.Time) []time.Time {
+	var result []time.Time
+	hour, minute, second := dtstart.Clock()
+	loc := dtstart.Location()
+
+	period, counted := 0, 0
+	if r.count == 0 {
+		period = r.periodsBefore(dtstart, from)
+	}
+	for ; len(result) < maxOccurrences; period++ {
+		days, first := r.periodDays(dtstart, period)
+		if first.After(to) {
+			break
+		}
+
+		candidates := make([]time.Time, 0, len(days))
+		for _, d := range days {
+			candidates = append(candidates, time.Date(d.Year(), d.Month(), d.Day(), hour, minute, second, 0, loc))
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+		candidates = applySetPos(candidates, r.bySetPos)
+
+		for _, t := range candidates {
+			if t.Before(dtstart) {
+				continue
+			}
+			if !r.until.IsZero() && t.After(r.until) {
+				return result
+			}
+			if !t.Before(to) {
+				return result
+			}
+			counted++
+			if !t.Before(from) {
+				result = append(result, t)
+			}
+			if r.count > 0 && counted >= r.count {
+				return result
+			}
+		}
+	}
+
+	return result
+}
+
+// periodsBefore возвращает номер периода, с которого можно начинать перебор экземпляров не раньше from:
+// на период раньше периода с from, чтобы не потерять экземпляры на границе
+func (r *rrule) periodsBefore(dtstart, from time.Time) int {
+	if !from.After(dtstart) {
+		return 0
+	}
+	base := time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, time.UTC)
+	target := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+
+	var units int
+	switch r.freq {
+	case "DAILY":
+		units = int(target.Sub(base).Hours() / 24)
+	case "WEEKLY":
+		units = int(target.Sub(base).Hours()/24) / 7
+	case "MONTHLY":
+		units = (target.Year()-base.Year())*12 + int(target.Month()) - int(base.Month())
+	default: // YEARLY
+		units = target.Year() - base.Year()
+	}
+	return max(units/r.interval-1, 0)
+}
+
+// periodDays возвращает дни-кандидаты периода с номером period и первый день периода
+func (r *rrule) periodDays(dtstart time.Time, period int) ([]time.Time, time.Time) {
+	base := time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, time.UTC)
+	step := period * r.interval
+
+	switch r.freq {
+	case "DAILY":
+		day := base.AddDate(0, 0, step)
+		if r.matchesMonth(day.Month()) && r.matchesMonthDay(day) && r.matchesWeekday(day.Weekday()) {
+			return []time.Time{day}, day
+		}
+		return nil, day
+
+	case "WEEKLY":
+		offset := (int(base.Weekday()) - int(r.wkst) + 7) % 7
+		weekStart := base.AddDate(0, 0, -offset+7*step)
+		var days []time.Time
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if len(r.byDay) == 0 && day.Weekday() != base.Weekday() {
+				continue
+			}
+			if r.matchesWeekday(day.Weekday()) && r.matchesMonth(day.Month()) {
+				days = append(days, day)
+			}
+		}
+		return days, weekStart
+
+	case "MONTHLY":
+		month := time.Date(base.Year(), base.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		if !r.matchesMonth(month.Month()) {
+			return nil, month
+		}
+		return r.monthDays(month, base.Day()), month
+
+	default: // YEARLY
+		year := time.Date(base.Year()+step, time.January, 1, 0, 0, 0, 0, time.UTC)
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []int{int(base.Month())}
+		}
+		var days []time.Time
+		for _, m := range months {
+			days = append(days, r.monthDays(time.Date(year.Year(), time.Month(m), 1, 0, 0, 0, 0, time.UTC), base.Day())...)
+		}
+		return days, year
+	}
+}
+
+// monthDays возвращает дни месяца по BYMONTHDAY и BYDAY, без них - день dtstart
+func (r *rrule) monthDays(month time.Time, defaultDay int) []time.Time {
+	dim := month.AddDate(0, 1, -1).Day()
+
+	if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+		if defaultDay > dim {
+			return nil
+		}
+		return []time.Time{month.AddDate(0, 0, defaultDay-1)}
+	}
+
+	var days []time.Time
+	for d := 1; d <= dim; d++ {
+		day := month.AddDate(0, 0, d-1)
+		if len(r.byMonthDay) > 0 && !r.matchesMonthDay(day) {
+			continue
+		}
+		if len(r.byDay) > 0 && !r.matchesWeekdayInMonth(day, dim) {
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+func (r *rrule) matchesMonth(m time.Month) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, bm := range r.byMonth {
+		if time.Month(bm) == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rrule) matchesMonthDay(day time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	dim := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.byMonthDay {
+		if md == day.Day() || (md < 0 && dim+1+md == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rrule) matchesWeekday(wd time.Weekday) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	for _, bd := range r.byDay {
+		if bd.day == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekdayInMonth учитывает порядковый номер дня недели в месяце (1MO, -1FR)
+func (r *rrule) matchesWeekdayInMonth(day time.Time, dim int) bool {
+	for _, bd := range r.byDay {
+		if bd.day != day.Weekday() {
+			continue
+		}
+		switch {
+		case bd.n == 0:
+			return true
+		case bd.n > 0 && (day.Day()-1)/7+1 == bd.n:
+			return true
+		case bd.n < 0 && (dim-day.Day())/7+1 == -bd.n:
+			return true
+		}
+	}
+	return false
+}
+
+// applySetPos оставляет кандидатов периода с позициями из BYSETPOS (отрицательные - с конца)
+func applySetPos(candidates []time.Time, positions []int) []time.Time {
+	if len(positions) == 0 {
+		return candidates
+	}
+
+	var result []time.Time
+	for _, pos := range positions {
+		i := pos - 1
+		if pos < 0 {
+			i = len(candidates) + pos
+		}
+		if i >= 0 && i < len(candidates) {
+			result = append(result, candidates[i])
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
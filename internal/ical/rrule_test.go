@@ -0,0 +1,260 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// event собирает VEVENT из свойств
+func event(lines ...string) []string {
+	return append(append([]string{"BEGIN:VEVENT"}, lines...), "END:VEVENT")
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse("20060102T150405Z", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// suffixes возвращает времена начала экземпляров в формате instanceSuffix
+func suffixes(occurrences []Occurrence) string {
+	var result []string
+	for _, o := range occurrences {
+		result = append(result, instanceSuffix(o.Start, o.AllDay))
+	}
+	return strings.Join(result, ",")
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   [][]string
+		from, to string
+		want     string
+	}{
+		{
+			name:   "single event in window",
+			events: [][]string{event("UID:a", "DTSTART:20261019T100000Z", "DTEND:20261019T110000Z")},
+			from:   "20261019T000000Z", to: "20261020T000000Z",
+			want: "20261019T100000Z",
+		},
+		{
+			name:   "single event outside window",
+			events: [][]string{event("UID:a", "DTSTART:20261021T100000Z", "DTEND:20261021T110000Z")},
+			from:   "20261019T000000Z", to: "20261020T000000Z",
+			want: "",
+		},
+		{
+			name:   "daily with COUNT",
+			events: [][]string{event("UID:a", "DTSTART:20261019T100000Z", "DURATION:PT1H", "RRULE:FREQ=DAILY;COUNT=3")},
+			from:   "20261001T000000Z", to: "20261101T000000Z",
+			want: "20261019T100000Z,20261020T100000Z,20261021T100000Z",
+		},
+		{
+			name:   "daily with UNTIL",
+			events: [][]string{event("UID:a", "DTSTART:20261019T100000Z", "DURATION:PT1H", "RRULE:FREQ=DAILY;UNTIL=20261021T100000Z")},
+			from:   "20261001T000000Z", to: "20261101T000000Z",
+			want: "20261019T100000Z,20261020T100000Z,20261021T100000Z",
+		},
+		{
+			name:   "daily with date UNTIL includes the whole day",
+			events: [][]string{event("UID:a", "DTSTART:20261019T230000Z", "DURATION:PT30M", "RRULE:FREQ=DAILY;UNTIL=20261020")},
+			from:   "20261001T000000Z", to: "20261101T000000Z",
+			want: "20261019T230000Z,20261020T230000Z",
+		},
+		{
+			name:   "weekly BYDAY with INTERVAL",
+			events: [][]string{event("UID:a", "DTSTART:20261019T100000Z", "DURATION:PT1H", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE")},
+			from:   "20261019T000000Z", to: "20261105T000000Z",
+			want: "20261019T100000Z,20261021T100000Z,20261102T100000Z,20261104T100000Z",
+		},
+		{
+			name:   "monthly last Friday",
+			events: [][]string{event("UID:a", "DTSTART:20261030T100000Z", "DURATION:PT1H", "RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=3")},
+			from:   "20261001T000000Z", to: "20270201T000000Z",
+			want: "20261030T100000Z,20261127T100000Z,20261225T100000Z",
+		},
+		{
+			name:   "monthly BYSETPOS last weekday",
+			events: [][]string{event("UID:a", "DTSTART:20261030T100000Z", "DURATION:PT1H", "RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=2")},
+			from:   "20261001T000000Z", to: "20270201T000000Z",
+			want: "20261030T100000Z,20261130T100000Z",
+		},
+		{
+			name:   "yearly",
+			events: [][]string{event("UID:a", "DTSTART;VALUE=DATE:20241019", "RRULE:FREQ=YEARLY")},
+			from:   "20260101T000000Z", to: "20280101T000000Z",
+			want: "20261019,20271019",
+		},
+		{
+			name: "EXDATE",
+			events: [][]string{event("UID:a", "DTSTART:20261019T100000Z", "DURATION:PT1H", "RRULE:FREQ=DAILY;COUNT=3",
+				"EXDATE:20261020T100000Z")},
+			from: "20261001T000000Z", to: "20261101T000000Z",
+			want: "20261019T100000Z,20261021T100000Z",
+		},
+		{
+			name: "EXDATE with TZID",
+			events: [][]string{event("UID:a", "DTSTART;TZID=Europe/Berlin:20261019T120000", "DURATION:PT1H", "RRULE:FREQ=DAILY;COUNT=2",
+				"EXDATE;TZID=Europe/Berlin:20261019T120000")},
+			from: "20261001T000000Z", to: "20261101T000000Z",
+			want: "20261020T100000Z",
+		},
+		{
+			name: "RDATE",
+			events: [][]string{event("UID:a", "DTSTART:20261019T100000Z", "DURATION:PT1H", "RRULE:FREQ=DAILY;COUNT=1",
+				"RDATE:20261025T150000Z")},
+			from: "20261001T000000Z", to: "20261101T000000Z",
+			want: "20261019T100000Z,20261025T150000Z",
+		},
+		{
+			name: "TZID keeps local time across DST",
+			events: [][]string{event("UID:a", "DTSTART;TZID=America/New_York:20261030T090000", "DURATION:PT1H",
+				"RRULE:FREQ=DAILY;COUNT=4")},
+			from: "20261001T000000Z", to: "20261201T000000Z",
+			want: "20261030T130000Z,20261031T130000Z,20261101T140000Z,20261102T140000Z",
+		},
+		{
+			name:   "instance started before window is included",
+			events: [][]string{event("UID:a", "DTSTART:20261018T230000Z", "DURATION:PT2H", "RRULE:FREQ=DAILY")},
+			from:   "20261019T000000Z", to: "20261019T120000Z",
+			want: "20261018T230000Z",
+		},
+		{
+			name:   "long series without COUNT keeps in-window instances",
+			events: [][]string{event("UID:a", "DTSTART:20100101T090000Z", "DURATION:PT1H", "RRULE:FREQ=DAILY")},
+			from:   "20261019T000000Z", to: "20261022T000000Z",
+			want: "20261019T090000Z,20261020T090000Z,20261021T090000Z",
+		},
+		{
+			name:   "long series with COUNT counts from DTSTART",
+			events: [][]string{event("UID:a", "DTSTART:20100101T090000Z", "DURATION:PT1H", "RRULE:FREQ=DAILY;COUNT=10000")},
+			from:   "20261019T000000Z", to: "20261021T000000Z",
+			want: "20261019T090000Z,20261020T090000Z",
+		},
+		{
+			name:   "COUNT exhausted before window",
+			events: [][]string{event("UID:a", "DTSTART:20100101T090000Z", "DURATION:PT1H", "RRULE:FREQ=DAILY;COUNT=5000")},
+			from:   "20261019T000000Z", to: "20261021T000000Z",
+			want: "",
+		},
+		{
+			name:   "long weekly series with INTERVAL",
+			events: [][]string{event("UID:a", "DTSTART:20100104T090000Z", "DURATION:PT1H", "RRULE:FREQ=WEEKLY;INTERVAL=3;BYDAY=MO")},
+			from:   "20261001T000000Z", to: "20261101T000000Z",
+			want: "20261019T090000Z",
+		},
+		{
+			name: "unsupported FREQ skips only that event",
+			events: [][]string{
+				event("UID:hourly", "DTSTART:20261019T100000Z", "DURATION:PT10M", "RRULE:FREQ=HOURLY"),
+				event("UID:minutely", "DTSTART:20261019T100000Z", "DURATION:PT1M", "RRULE:FREQ=MINUTELY;INTERVAL=5"),
+				event("UID:single", "DTSTART:20261019T120000Z", "DURATION:PT1H"),
+			},
+			from: "20261019T000000Z", to: "20261020T000000Z",
+			want: "20261019T120000Z",
+		},
+		{
+			name: "unsupported BY part skips only that event",
+			events: [][]string{
+				event("UID:byhour", "DTSTART:20261019T090000Z", "DURATION:PT10M", "RRULE:FREQ=DAILY;BYHOUR=9,17"),
+				event("UID:single", "DTSTART:20261019T120000Z", "DURATION:PT1H"),
+			},
+			from: "20261019T000000Z", to: "20261020T000000Z",
+			want: "20261019T120000Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines []string
+			for _, e := range tt.events {
+				lines = append(lines, e...)
+			}
+			cal := mustParse(t, calendar(lines...), time.UTC)
+			got := Expand(cal.Events, utc(tt.from), utc(tt.to))
+			if s := suffixes(got); s != tt.want {
+				t.Errorf("Expand = %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandRecurrenceID(t *testing.T) {
+	var lines []string
+	lines = append(lines, event("UID:series", "SUMMARY:Standup", "DTSTART:20261019T100000Z", "DURATION:PT15M",
+		"RRULE:FREQ=DAILY;COUNT=3")...)
+	// Второй экземпляр перенесён на вечер, третий перенесён за пределы окна
+	lines = append(lines, event("UID:series", "SUMMARY:Standup (moved)", "RECURRENCE-ID:20261020T100000Z",
+		"DTSTART:20261020T170000Z", "DURATION:PT15M")...)
+	lines = append(lines, event("UID:series", "SUMMARY:Standup (next month)", "RECURRENCE-ID:20261021T100000Z",
+		"DTSTART:20261201T100000Z", "DURATION:PT15M")...)
+	cal := mustParse(t, calendar(lines...), time.UTC)
+
+	got := Expand(cal.Events, utc("20261019T000000Z"), utc("20261101T000000Z"))
+	if len(got) != 2 {
+		t.Fatalf("got %d occurrences (%s), want 2", len(got), suffixes(got))
+	}
+
+	byID := map[string]Occurrence{}
+	for _, o := range got {
+		if o.RecurringUID != "series" {
+			t.Errorf("%s: RecurringUID = %q, want series", o.InstanceID, o.RecurringUID)
+		}
+		byID[o.InstanceID] = o
+	}
+	if o, ok := byID["series_20261019T100000Z"]; !ok || o.Summary != "Standup" {
+		t.Errorf("first instance = %+v", o)
+	}
+	moved, ok := byID["series_20261020T100000Z"]
+	if !ok {
+		t.Fatalf("moved instance must keep the id of its original start, got %v", byID)
+	}
+	if moved.Summary != "Standup (moved)" || !moved.Start.Equal(utc("20261020T170000Z")) {
+		t.Errorf("moved instance = %s at %s", moved.Summary, moved.Start)
+	}
+}
+
+func TestExpandOccurrenceLimit(t *testing.T) {
+	cal := mustParse(t, calendar(event("UID:a", "DTSTART:20000101T000000Z", "DURATION:PT1M", "RRULE:FREQ=DAILY")...), time.UTC)
+
+	got := Expand(cal.Events, utc("20000101T000000Z"), utc("21000101T000000Z"))
+	if len(got) != maxOccurrences {
+		t.Errorf("got %d occurrences, want %d", len(got), maxOccurrences)
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: "FREQ=DAILY"},
+		{value: "RRULE:FREQ=WEEKLY;BYDAY=1MO,-2FR;WKST=SU"},
+		{value: "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{value: "FREQ=YEARLY;BYMONTH=1,7;UNTIL=20301231"},
+		{value: "FREQ=HOURLY", wantErr: true},
+		{value: "FREQ=MINUTELY;INTERVAL=15", wantErr: true},
+		{value: "FREQ=SECONDLY", wantErr: true},
+		{value: "COUNT=3", wantErr: true},
+		{value: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{value: "FREQ=DAILY;COUNT=many", wantErr: true},
+		{value: "FREQ=DAILY;BYHOUR=9,17", wantErr: true},
+		{value: "FREQ=DAILY;BYMINUTE=0,30", wantErr: true},
+		{value: "FREQ=DAILY;BYSECOND=0", wantErr: true},
+		{value: "FREQ=YEARLY;BYWEEKNO=20", wantErr: true},
+		{value: "FREQ=YEARLY;BYYEARDAY=100", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := parseRRule(tt.value, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRRule(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package ical
+
+import (
+	"strings"
+	"time"
+)
+
+// windowsZones соответствие часто встречающихся Windows-имён часовых поясов (Outlook, Exchange) зонам IANA
+var windowsZones = map[string]string{
+	"UTC":                             "UTC",
+	"GMT Standard Time":               "Europe/London",
+	"Greenwich Standard Time":         "Atlantic/Reykjavik",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"Central European Standard Time":  "Europe/Warsaw",
+	"Romance Standard Time":           "Europe/Paris",
+	"E. Europe Standard Time":         "Europe/Chisinau",
+	"FLE Standard Time":               "Europe/Kiev",
+	"GTB Standard Time":               "Europe/Bucharest",
+	"Turkey Standard Time":            "Europe/Istanbul",
+	"Israel Standard Time":            "Asia/Jerusalem",
+	"Russian Standard Time":           "Europe/Moscow",
+	"Belarus Standard Time":           "Europe/Minsk",
+	"Kaliningrad Standard Time":       "Europe/Kaliningrad",
+	"Russia Time Zone 3":              "Europe/Samara",
+	"Ekaterinburg Standard Time":      "Asia/Yekaterinburg",
+	"N. Central Asia Standard Time":   "Asia/Novosibirsk",
+	"North Asia Standard Time":        "Asia/Krasnoyarsk",
+	"North Asia East Standard Time":   "Asia/Irkutsk",
+	"Yakutsk Standard Time":           "Asia/Yakutsk",
+	"Vladivostok Standard Time":       "Asia/Vladivostok",
+	"Arabian Standard Time":           "Asia/Dubai",
+	"India Standard Time":             "Asia/Kolkata",
+	"China Standard Time":             "Asia/Shanghai",
+	"Singapore Standard Time":         "Asia/Singapore",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"Korea Standard Time":             "Asia/Seoul",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"New Zealand Standard Time":       "Pacific/Auckland",
+	"Eastern Standard Time":           "America/New_York",
+	"Central Standard Time":           "America/Chicago",
+	"Mountain Standard Time":          "America/Denver",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Atlantic Standard Time":          "America/Halifax",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"Central Standard Time (Mexico)":  "America/Mexico_City",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"Egypt Standard Time":             "Africa/Cairo",
+	"Central Asia Standard Time":      "Asia/Almaty",
+	"West Asia Standard Time":         "Asia/Tashkent",
+	"Georgian Standard Time":          "Asia/Tbilisi",
+	"Caucasus Standard Time":          "Asia/Yerevan",
+	"Azerbaijan Standard Time":        "Asia/Baku",
+	"Pakistan Standard Time":          "Asia/Karachi",
+	"SE Asia Standard Time":           "Asia/Bangkok",
+	"Taipei Standard Time":            "Asia/Taipei",
+	"W. Australia Standard Time":      "Australia/Perth",
+	"Canada Central Standard Time":    "America/Regina",
+	"Newfoundland Standard Time":      "America/St_Johns",
+	"Argentina Standard Time":         "America/Buenos_Aires",
+	"Pacific SA Standard Time":        "America/Santiago",
+	"Morocco Standard Time":           "Africa/Casablanca",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+	"E. Africa Standard Time":         "Africa/Nairobi",
+}
+
+// lookupLocation находит часовой пояс по TZID: сначала как имя IANA, затем как Windows-имя.
+// Некоторые клиенты добавляют к TZID префикс вида /mozilla.org/20050126_1/
+func lookupLocation(tzid string) (*time.Location, bool) {
+	tzid = strings.Trim(tzid, `"`)
+	if strings.HasPrefix(tzid, "/") {
+		parts := strings.Split(tzid, "/")
+		if len(parts) > 3 {
+			tzid = strings.Join(parts[3:], "/")
+		}
+	}
+
+	if loc, err := time.LoadLocation(tzid); err == nil {
+		return loc, true
+	}
+	if name, ok := windowsZones[tzid]; ok {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, true
+		}
+	}
+	return nil, false
+}
@@ -30,7 +30,7 @@ func CORS(next http.Handler) http.Handler {
 		}
 
 		// Разрешаем методы
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 
 		// Разрешаем заголовки
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
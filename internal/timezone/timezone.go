@@ -0,0 +1,49 @@
+package timezone
+
+import (
+	"DataLake/internal/logger"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	location *time.Location
+	once     sync.Once
+)
+
+// Location возвращает часовой пояс пользователя из USER_TIMEZONE (IANA, например Europe/Moscow).
+// Если переменная не задана или некорректна, используется UTC
+func Location() *time.Location {
+	once.Do(func() {
+		location = time.UTC
+
+		name := os.Getenv("USER_TIMEZONE")
+		if name == "" {
+			return
+		}
+
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log := logger.Get()
+			log.Warn().Err(err).Str("timezone", name).Msg("invalid USER_TIMEZONE, falling back to UTC")
+			return
+		}
+		location = loc
+	})
+
+	return location
+}
+
+// StartOfDay возвращает локальную полночь дня, в который попадает t
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// Date возвращает календарную дату t в часовом поясе loc как полночь UTC,
+// чтобы значение одинаково сохранялось в колонку DATE
+func Date(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
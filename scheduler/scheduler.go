@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	caldavauth "DataLake/auth/caldav"
 	"DataLake/googlecalendar"
 	"DataLake/googlefit"
 	internal_db "DataLake/internal/db"
@@ -83,6 +84,54 @@ func (s *Scheduler) collectGoogleFitData() {
 	} else {
 		s.logger.Info().Msg("данные Google Fit успешно сохранены")
 	}
+
+	sources, sourceStats, err := googlefit.FetchSourceStats(7)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при получении источников данных Google Fit")
+	} else if err := googlefit.SaveSourceStats(s.store, sources, sourceStats, s.userID); err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при сохранении источников данных Google Fit")
+	} else {
+		s.logger.Info().Int("count", len(sources)).Msg("источники данных Google Fit успешно сохранены")
+	}
+
+	hourly, err := googlefit.FetchHourlySummaries(2)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при получении почасовых данных Google Fit")
+	} else if err := googlefit.SaveHourlySummaries(s.store, hourly, s.userID); err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при сохранении почасовых данных Google Fit")
+	} else {
+		s.logger.Info().Int("count", len(hourly)).Msg("почасовые данные Google Fit успешно сохранены")
+	}
+
+	segments, err := googlefit.FetchSleepSegments(7)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при получении данных сна Google Fit")
+	} else if err := googlefit.SaveSleepSegments(s.store, segments, s.userID); err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при сохранении данных сна Google Fit")
+	} else {
+		s.logger.Info().Int("count", len(segments)).Msg("данные сна Google Fit успешно сохранены")
+	}
+
+	measurements, err := googlefit.FetchBodyMeasurements(30)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при получении измерений тела Google Fit")
+	} else if err := googlefit.SaveBodyMeasurements(s.store, measurements, s.userID); err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при сохранении измерений тела Google Fit")
+	} else {
+		s.logger.Info().Int("count", len(measurements)).Msg("измерения тела Google Fit успешно сохранены")
+	}
+
+	workouts, deleted, err := googlefit.FetchWorkouts(7)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при получении тренировок Google Fit")
+		return
+	}
+
+	if err := googlefit.SaveWorkouts(s.store, workouts, deleted, s.userID); err != nil {
+		s.logger.Error().Err(err).Msg("ошибка при сохранении тренировок Google Fit")
+	} else {
+		s.logger.Info().Int("count", len(workouts)).Msg("тренировки Google Fit успешно сохранены")
+	}
 }
 
 func (s *Scheduler) collectGoogleCalendarData() {
@@ -93,4 +142,20 @@ func (s *Scheduler) collectGoogleCalendarData() {
 	} else {
 		s.logger.Info().Msg("данные Google Calendar успешно сохранены")
 	}
+
+	if len(googlecalendar.FeedsFromEnv()) > 0 {
+		if err := googlecalendar.FetchAndStoreFeeds(s.store, 30, s.userID); err != nil {
+			s.logger.Error().Err(err).Msg("ошибка при получении календарей iCalendar")
+		} else {
+			s.logger.Info().Msg("календари iCalendar успешно сохранены")
+		}
+	}
+
+	if caldavauth.NewProviderFromEnv().Configured() {
+		if err := googlecalendar.FetchAndStoreCalDAV(s.store, 30, s.userID); err != nil {
+			s.logger.Error().Err(err).Msg("ошибка при получении календарей CalDAV")
+		} else {
+			s.logger.Info().Msg("календари CalDAV успешно сохранены")
+		}
+	}
 }
@@ -7,7 +7,8 @@ echo "Initializing database schema..."
 for migration in /docker-entrypoint-initdb.d/migrations/*.up.sql; do
     if [ -f "$migration" ]; then
         echo "Running migration: $(basename $migration)"
-        psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" -f "$migration"
+        PGOPTIONS="-c datalake.api_user_id=$API_USER_ID" \
+            psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" -f "$migration"
     fi
 done
 
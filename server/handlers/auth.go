@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"DataLake/auth"
+	caldavauth "DataLake/auth/caldav"
 	"DataLake/internal/logger"
 	"encoding/json"
 	"net/http"
@@ -11,6 +12,7 @@ type AuthStatusResponse struct {
 	WakaTime       bool `json:"wakatime"`
 	GoogleFit      bool `json:"googlefit"`
 	GoogleCalendar bool `json:"googlecalendar"`
+	CalDAV         bool `json:"caldav"`
 }
 
 func HandleAuthStatus() http.HandlerFunc {
@@ -37,6 +39,10 @@ func HandleAuthStatus() http.HandlerFunc {
 			status.GoogleCalendar = true
 		}
 
+		if _, err := storage.LoadToken(caldavauth.ProviderName); err == nil {
+			status.CalDAV = true
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(status); err != nil {
 			log.Error().Err(err).Msg("failed to encode auth status response")
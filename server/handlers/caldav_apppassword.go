@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"DataLake/auth"
+	caldavauth "DataLake/auth/caldav"
+	"DataLake/internal/logger"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type caldavAppPasswordRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// HandleCaldavAppPassword проверяет и сохраняет пароль приложения CalDAV сервера из CALDAV_URL
+func HandleCaldavAppPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.Get()
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		provider := caldavauth.NewProviderFromEnv()
+		if !provider.Configured() {
+			http.Error(w, "CALDAV_URL is not configured", http.StatusBadRequest)
+			return
+		}
+
+		var req caldavAppPasswordRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			log.Error().Err(err).Msg("failed to decode app password request")
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		username := strings.TrimSpace(req.Username)
+		if username == "" || req.Password == "" {
+			http.Error(w, "Missing username or password", http.StatusBadRequest)
+			return
+		}
+
+		if err := provider.VerifyAppPassword(r.Context(), username, req.Password); err != nil {
+			log.Error().Err(err).Msg("failed to verify caldav app password")
+			http.Error(w, "Invalid CalDAV credentials", http.StatusBadRequest)
+			return
+		}
+
+		storage, err := auth.NewFileTokenStorageFromEnv("tokens.json")
+		if err != nil {
+			log.Error().Err(err).Msg("failed to initialize token storage")
+			http.Error(w, "Internal Server Error: failed to initialize storage", http.StatusInternalServerError)
+			return
+		}
+		if err := storage.SaveToken(caldavauth.ProviderName, caldavauth.NewAppPasswordToken(username, req.Password)); err != nil {
+			log.Error().Err(err).Msg("failed to save app password")
+			http.Error(w, "Failed to save app password", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info().Str("server_url", provider.ServerURL()).Msg("caldav app password saved")
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "CalDAV app password saved"})
+	}
+}
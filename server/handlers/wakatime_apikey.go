@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"DataLake/auth"
+	wakatimeauth "DataLake/auth/wakatime"
+	"DataLake/internal/logger"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type wakatimeAPIKeyRequest struct {
+	APIKey string `json:"api_key"`
+}
+
+// HandleWakatimeAPIKey сохраняет персональный API ключ WakaTime
+// как альтернативу OAuth авторизации (удобно для headless установок)
+func HandleWakatimeAPIKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.Get()
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req wakatimeAPIKeyRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			log.Error().Err(err).Msg("failed to decode api key request")
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		apiKey := strings.TrimSpace(req.APIKey)
+		if apiKey == "" {
+			http.Error(w, "Missing api_key", http.StatusBadRequest)
+			return
+		}
+
+		provider := wakatimeauth.NewProviderFromEnv()
+		if err := provider.VerifyAPIKey(r.Context(), apiKey); err != nil {
+			log.Error().Err(err).Msg("failed to verify wakatime api key")
+			http.Error(w, "Invalid WakaTime API key", http.StatusBadRequest)
+			return
+		}
+
+		storage, err := auth.NewFileTokenStorageFromEnv("tokens.json")
+		if err != nil {
+			log.Error().Err(err).Msg("failed to initialize token storage")
+			http.Error(w, "Internal Server Error: failed to initialize storage", http.StatusInternalServerError)
+			return
+		}
+		if err := storage.SaveToken("wakatime", wakatimeauth.NewAPIKeyToken(apiKey)); err != nil {
+			log.Error().Err(err).Msg("failed to save api key")
+			http.Error(w, "Failed to save api key", http.StatusInternalServerError)
+			return
+		}
+
+		log.Info().Str("api_url", provider.APIURL()).Msg("wakatime api key saved")
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "WakaTime API key saved"})
+	}
+}
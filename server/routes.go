@@ -26,6 +26,7 @@ func (s *Server) routes(apiRouter http.Handler) {
 
 	// WakaTime OAuth
 	s.mux.Handle("/callback", middleware.CORS(middleware.Logging(handlers.HandleCallback())))
+	s.mux.Handle("/auth/wakatime/apikey", middleware.CORS(middleware.Logging(middleware.APIKeyAuth(handlers.HandleWakatimeAPIKey()))))
 
 	// Google Fit OAuth
 	s.mux.Handle("/auth/googlefit", middleware.CORS(middleware.Logging(handlers.HandleGoogleFitAuth())))
@@ -35,6 +36,9 @@ func (s *Server) routes(apiRouter http.Handler) {
 	s.mux.Handle("/auth/googlecalendar", middleware.CORS(middleware.Logging(handlers.HandleGoogleCalendarAuth())))
 	s.mux.Handle("/oauth2callback/calendar", middleware.CORS(middleware.Logging(handlers.HandleGoogleCalendarCallback())))
 
+	// CalDAV (пароль приложения)
+	s.mux.Handle("/auth/caldav", middleware.CORS(middleware.Logging(middleware.APIKeyAuth(handlers.HandleCaldavAppPassword()))))
+
 	// API v1 (с CORS и Rate Limiting)
 	s.mux.Handle("/api/v1/auth/status", middleware.RateLimit(middleware.CORS(middleware.Logging(handlers.HandleAuthStatus()))))
 	s.mux.Handle("/api/v1/", middleware.RateLimit(middleware.CORS(http.StripPrefix("/api/v1", apiRouter))))
@@ -46,7 +46,8 @@ func FetchSummaries() ([]DailySummary, error) {
 	startDate := end.AddDate(0, 0, -6)
 
 	url := fmt.Sprintf(
-		"https://wakatime.com/api/v1/users/current/summaries?start=%s&end=%s",
+		"%s/users/current/summaries?start=%s&end=%s",
+		provider.APIURL(),
 		startDate.Format("2006-01-02"),
 		end.Format("2006-01-02"),
 	)
@@ -62,7 +63,7 @@ func FetchSummaries() ([]DailySummary, error) {
 		log.Error().Err(err).Msg("failed to create request")
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Authorization", wakatimeauth.AuthorizationHeader(token))
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
@@ -110,8 +111,15 @@ func SaveSummaries(store *internal_db.Store, dailySummaries []DailySummary, user
 		Str("user_id", userID.String()).
 		Msg("saving wakatime summaries")
 
+	daysSkipped := 0
+
 	for _, daySummary := range dailySummaries {
-		err := store.ExecTx(ctx, func(q *wakatime_db.Queries) error {
+		contentHash, err := daySummary.ContentHash()
+		if err != nil {
+			return fmt.Errorf("failed to hash day %s: %w", daySummary.Range.Date, err)
+		}
+
+		err = store.ExecTx(ctx, func(q *wakatime_db.Queries) error {
 			dayDate, err := time.Parse("2006-01-02", daySummary.Range.Date)
 			if err != nil {
 				log.Error().
@@ -126,12 +134,20 @@ func SaveSummaries(store *internal_db.Store, dailySummaries []DailySummary, user
 				Date:   pgtype.Date{Time: dayDate, Valid: true},
 			})
 
+			// День не изменился с прошлого запуска - ничего не пишем
+			if err == nil && existingDay.ContentHash.Valid && existingDay.ContentHash.String == contentHash {
+				daysSkipped++
+				log.Debug().Str("date", daySummary.Range.Date).Msg("day unchanged, skipping")
+				return nil
+			}
+
 			var day wakatime_db.WakatimeDay
 			if err == nil {
 				day, err = q.UpdateDay(ctx, wakatime_db.UpdateDayParams{
 					ID:           existingDay.ID,
 					TotalSeconds: daySummary.GrandTotal.TotalSeconds,
 					Text:         pgtype.Text{String: daySummary.GrandTotal.Text, Valid: true},
+					ContentHash:  pgtype.Text{String: contentHash, Valid: true},
 				})
 				if err != nil {
 					metrics.DatabaseOperationsTotal.WithLabelValues("update_day", "error").Inc()
@@ -146,6 +162,7 @@ func SaveSummaries(store *internal_db.Store, dailySummaries []DailySummary, user
 					Date:         pgtype.Date{Time: dayDate, Valid: true},
 					TotalSeconds: daySummary.GrandTotal.TotalSeconds,
 					Text:         pgtype.Text{String: daySummary.GrandTotal.Text, Valid: true},
+					ContentHash:  pgtype.Text{String: contentHash, Valid: true},
 				})
 				if err != nil {
 					metrics.DatabaseOperationsTotal.WithLabelValues("create_day", "error").Inc()
@@ -158,106 +175,124 @@ func SaveSummaries(store *internal_db.Store, dailySummaries []DailySummary, user
 
 			dayID := day.ID
 
-			if err := q.DeleteProjectsByDay(ctx, dayID); err != nil {
-				return fmt.Errorf("failed to delete old projects: %w", err)
-			}
-			if err := q.DeleteLanguagesByDay(ctx, dayID); err != nil {
-				return fmt.Errorf("failed to delete old languages: %w", err)
-			}
-			if err := q.DeleteEditorsByDay(ctx, dayID); err != nil {
-				return fmt.Errorf("failed to delete old editors: %w", err)
-			}
-			if err := q.DeleteOSByDay(ctx, dayID); err != nil {
-				return fmt.Errorf("failed to delete old OS: %w", err)
-			}
-			if err := q.DeleteDependenciesByDay(ctx, dayID); err != nil {
-				return fmt.Errorf("failed to delete old dependencies: %w", err)
-			}
-			if err := q.DeleteMachinesByDay(ctx, dayID); err != nil {
-				return fmt.Errorf("failed to delete old machines: %w", err)
-			}
-
 			// Сохраняем проекты
+			projects := make([]wakatime_db.UpsertProjectsParams, 0, len(daySummary.Projects))
+			projectNames := make([]string, 0, len(daySummary.Projects))
 			for _, p := range daySummary.Projects {
-				_, err := q.CreateProject(ctx, wakatime_db.CreateProjectParams{
+				projects = append(projects, wakatime_db.UpsertProjectsParams{
 					DayID:        dayID,
 					Name:         p.Name,
 					TotalSeconds: p.TotalSeconds,
 					Percent:      pgtype.Float8{Float64: p.Percent, Valid: true},
 					Text:         pgtype.Text{String: p.Text, Valid: true},
 				})
-				if err != nil {
-					return fmt.Errorf("failed to insert project %q: %w", p.Name, err)
-				}
+				projectNames = append(projectNames, p.Name)
+			}
+			if err := execBatch(q.UpsertProjects(ctx, projects).Exec); err != nil {
+				return fmt.Errorf("failed to upsert projects: %w", err)
+			}
+			if err := q.DeleteStaleProjects(ctx, wakatime_db.DeleteStaleProjectsParams{DayID: dayID, Names: projectNames}); err != nil {
+				return fmt.Errorf("failed to delete stale projects: %w", err)
 			}
 
 			// Сохраняем языки
+			languages := make([]wakatime_db.UpsertLanguagesParams, 0, len(daySummary.Languages))
+			languageNames := make([]string, 0, len(daySummary.Languages))
 			for _, lang := range daySummary.Languages {
-				_, err := q.CreateLanguage(ctx, wakatime_db.CreateLanguageParams{
+				languages = append(languages, wakatime_db.UpsertLanguagesParams{
 					DayID:        dayID,
 					Name:         lang.Name,
 					TotalSeconds: lang.TotalSeconds,
 					Percent:      pgtype.Float8{Float64: lang.Percent, Valid: true},
 					Text:         pgtype.Text{String: lang.Text, Valid: true},
 				})
-				if err != nil {
-					return fmt.Errorf("failed to insert language %q: %w", lang.Name, err)
-				}
+				languageNames = append(languageNames, lang.Name)
+			}
+			if err := execBatch(q.UpsertLanguages(ctx, languages).Exec); err != nil {
+				return fmt.Errorf("failed to upsert languages: %w", err)
+			}
+			if err := q.DeleteStaleLanguages(ctx, wakatime_db.DeleteStaleLanguagesParams{DayID: dayID, Names: languageNames}); err != nil {
+				return fmt.Errorf("failed to delete stale languages: %w", err)
 			}
 
 			// Сохраняем редакторы
+			editors := make([]wakatime_db.UpsertEditorsParams, 0, len(daySummary.Editors))
+			editorNames := make([]string, 0, len(daySummary.Editors))
 			for _, e := range daySummary.Editors {
-				_, err := q.CreateEditor(ctx, wakatime_db.CreateEditorParams{
+				editors = append(editors, wakatime_db.UpsertEditorsParams{
 					DayID:        dayID,
 					Name:         e.Name,
 					TotalSeconds: e.TotalSeconds,
 					Percent:      pgtype.Float8{Float64: e.Percent, Valid: true},
 					Text:         pgtype.Text{String: e.Text, Valid: true},
 				})
-				if err != nil {
-					return fmt.Errorf("failed to insert editor %q: %w", e.Name, err)
-				}
+				editorNames = append(editorNames, e.Name)
+			}
+			if err := execBatch(q.UpsertEditors(ctx, editors).Exec); err != nil {
+				return fmt.Errorf("failed to upsert editors: %w", err)
+			}
+			if err := q.DeleteStaleEditors(ctx, wakatime_db.DeleteStaleEditorsParams{DayID: dayID, Names: editorNames}); err != nil {
+				return fmt.Errorf("failed to delete stale editors: %w", err)
 			}
 
 			// Сохраняем OS
-			for _, os := range daySummary.OS {
-				_, err := q.CreateOS(ctx, wakatime_db.CreateOSParams{
+			osItems := make([]wakatime_db.UpsertOSParams, 0, len(daySummary.OS))
+			osNames := make([]string, 0, len(daySummary.OS))
+			for _, o := range daySummary.OS {
+				osItems = append(osItems, wakatime_db.UpsertOSParams{
 					DayID:        dayID,
-					Name:         os.Name,
-					TotalSeconds: os.TotalSeconds,
-					Percent:      pgtype.Float8{Float64: os.Percent, Valid: true},
-					Text:         pgtype.Text{String: os.Text, Valid: true},
+					Name:         o.Name,
+					TotalSeconds: o.TotalSeconds,
+					Percent:      pgtype.Float8{Float64: o.Percent, Valid: true},
+					Text:         pgtype.Text{String: o.Text, Valid: true},
 				})
-				if err != nil {
-					return fmt.Errorf("failed to insert OS %q: %w", os.Name, err)
-				}
+				osNames = append(osNames, o.Name)
+			}
+			if err := execBatch(q.UpsertOS(ctx, osItems).Exec); err != nil {
+				return fmt.Errorf("failed to upsert OS: %w", err)
+			}
+			if err := q.DeleteStaleOS(ctx, wakatime_db.DeleteStaleOSParams{DayID: dayID, Names: osNames}); err != nil {
+				return fmt.Errorf("failed to delete stale OS: %w", err)
 			}
 
 			// Сохраняем зависимости
+			dependencies := make([]wakatime_db.UpsertDependenciesParams, 0, len(daySummary.Dependencies))
+			dependencyNames := make([]string, 0, len(daySummary.Dependencies))
 			for _, d := range daySummary.Dependencies {
-				_, err := q.CreateDependency(ctx, wakatime_db.CreateDependencyParams{
+				dependencies = append(dependencies, wakatime_db.UpsertDependenciesParams{
 					DayID:        dayID,
 					Name:         d.Name,
 					TotalSeconds: d.TotalSeconds,
 					Percent:      pgtype.Float8{Float64: d.Percent, Valid: true},
 					Text:         pgtype.Text{String: d.Text, Valid: true},
 				})
-				if err != nil {
-					return fmt.Errorf("failed to insert dependency %q: %w", d.Name, err)
-				}
+				dependencyNames = append(dependencyNames, d.Name)
+			}
+			if err := execBatch(q.UpsertDependencies(ctx, dependencies).Exec); err != nil {
+				return fmt.Errorf("failed to upsert dependencies: %w", err)
+			}
+			if err := q.DeleteStaleDependencies(ctx, wakatime_db.DeleteStaleDependenciesParams{DayID: dayID, Names: dependencyNames}); err != nil {
+				return fmt.Errorf("failed to delete stale dependencies: %w", err)
 			}
 
+			// Сохраняем машины
+			machines := make([]wakatime_db.UpsertMachinesParams, 0, len(daySummary.Machines))
+			machineNames := make([]string, 0, len(daySummary.Machines))
 			for _, m := range daySummary.Machines {
-				_, err := q.CreateMachine(ctx, wakatime_db.CreateMachineParams{
+				machines = append(machines, wakatime_db.UpsertMachinesParams{
 					DayID:        dayID,
 					Name:         m.Name,
 					TotalSeconds: m.TotalSeconds,
 					Percent:      pgtype.Float8{Float64: m.Percent, Valid: true},
 					Text:         pgtype.Text{String: m.Text, Valid: true},
 				})
-				if err != nil {
-					return fmt.Errorf("failed to insert machine %q: %w", m.Name, err)
-				}
+				machineNames = append(machineNames, m.Name)
+			}
+			if err := execBatch(q.UpsertMachines(ctx, machines).Exec); err != nil {
+				return fmt.Errorf("failed to upsert machines: %w", err)
+			}
+			if err := q.DeleteStaleMachines(ctx, wakatime_db.DeleteStaleMachinesParams{DayID: dayID, Names: machineNames}); err != nil {
+				return fmt.Errorf("failed to delete stale machines: %w", err)
 			}
 
 			log.Debug().
@@ -278,9 +313,21 @@ func SaveSummaries(store *internal_db.Store, dailySummaries []DailySummary, user
 
 	metrics.DatabaseOperationDuration.WithLabelValues("save_summaries").Observe(time.Since(start).Seconds())
 	log.Info().
-		Int("days_saved", len(dailySummaries)).
+		Int("days_saved", len(dailySummaries)-daysSkipped).
+		Int("days_skipped", daysSkipped).
 		Dur("duration", time.Since(start)).
 		Msg("successfully saved all wakatime summaries")
 
 	return nil
 }
+
+// execBatch выполняет batch запрос и возвращает первую ошибку
+func execBatch(exec func(func(int, error))) error {
+	var batchErr error
+	exec(func(_ int, err error) {
+		if err != nil && batchErr == nil {
+			batchErr = err
+		}
+	})
+	return batchErr
+}
@@ -1,6 +1,9 @@
 package wakatime
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"strings"
 	"time"
 )
@@ -103,6 +106,17 @@ type DailySummary struct {
 	} `json:"range"`
 }
 
+// ContentHash возвращает sha256 содержимого дня. Совпадение хеша с сохранённым
+// означает, что день не изменился и его можно не перезаписывать
+func (d DailySummary) ContentHash() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 type SummariesResponse struct {
 	Data  []DailySummary `json:"data"`
 	Start string         `json:"start"`